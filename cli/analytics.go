@@ -0,0 +1,434 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	j "github.com/andygrunwald/go-jira"
+)
+
+const (
+	GroupByType     = "type"
+	GroupByAssignee = "assignee"
+	// GroupByProject groups issues by the project in their key, usually a team
+	GroupByProject = "project"
+)
+
+// DefaultPercentiles are the percentiles of lead and cycle times reported when none are input
+var DefaultPercentiles = []int{50, 85, 95}
+
+// Analytics computes metrics over the issues found with a jql query from their changelogs.
+// The embedded List provides the jira connection and link discovery options
+type Analytics struct {
+	List
+	// GroupBy is 'type', 'assignee' or 'project' to report each group of issues separately, or empty for all together
+	GroupBy string
+	// Format is 'text', 'csv' or 'json'
+	Format      string
+	Percentiles []int
+}
+
+// issueTimes is how long an issue spent in each status and how long it took to get done
+type issueTimes struct {
+	Key      string     `json:"key"`
+	Type     string     `json:"type"`
+	Assignee string     `json:"assignee"`
+	Status   string     `json:"status"`
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Done     *time.Time `json:"done,omitempty"`
+	// LeadTime is from created to done and CycleTime from first in progress to done, in hours
+	LeadTime  *float64 `json:"leadTimeHours,omitempty"`
+	CycleTime *float64 `json:"cycleTimeHours,omitempty"`
+	// TimeInStatus is the hours spent in each status, time in done statuses isn't counted
+	TimeInStatus map[string]float64 `json:"timeInStatusHours"`
+}
+
+type cycleTimeGroup struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Done  int    `json:"done"`
+	// LeadTime and CycleTime map a percentile eg 'p85' to hours
+	LeadTime  map[string]float64 `json:"leadTimeHours"`
+	CycleTime map[string]float64 `json:"cycleTimeHours"`
+	// TimeInStatus is the mean hours issues that were in a status spent in it
+	TimeInStatus map[string]float64 `json:"meanTimeInStatusHours"`
+}
+
+func (a Analytics) format(allowed ...string) (string, error) {
+	format := a.Format
+	if format == "" {
+		format = FormatText
+	}
+	if !containsString(allowed, format) {
+		return "", fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(allowed, ", "))
+	}
+	return format, nil
+}
+
+func (a Analytics) percentiles() ([]int, error) {
+	if len(a.Percentiles) == 0 {
+		return DefaultPercentiles, nil
+	}
+	for _, p := range a.Percentiles {
+		if p <= 0 || p > 100 {
+			return nil, fmt.Errorf("percentile %d should be between 1 and 100", p)
+		}
+	}
+	return a.Percentiles, nil
+}
+
+// CycleTime reports the time issues spent in each status, their lead time and their cycle time
+func (a Analytics) CycleTime() error {
+	format, err := a.format(FormatText, FormatCSV, FormatJSON)
+	if err != nil {
+		return err
+	}
+	if a.GroupBy != "" && a.GroupBy != GroupByType && a.GroupBy != GroupByAssignee && a.GroupBy != GroupByProject {
+		return fmt.Errorf("unknown grouping %q, expected one of %s, %s or %s", a.GroupBy, GroupByType, GroupByAssignee, GroupByProject)
+	}
+	percentiles, err := a.percentiles()
+	if err != nil {
+		return err
+	}
+	if a.Jql == "" {
+		return fmt.Errorf("a jql query is required")
+	}
+
+	times, err := a.issueTimes(time.Now())
+	if err != nil {
+		return err
+	}
+	groups := a.cycleTimeGroups(times, percentiles)
+
+	switch format {
+	case FormatJSON:
+		out, err := json.MarshalIndent(map[string]interface{}{"issues": times, "groups": groups}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding cycle times: %v", err)
+		}
+		fmt.Println(string(out))
+	case FormatCSV:
+		out, err := cycleTimeCSV(times)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+	default:
+		fmt.Print(cycleTimeText(groups, percentiles))
+	}
+	return nil
+}
+
+func (a Analytics) issueTimes(now time.Time) ([]issueTimes, error) {
+	categories, err := a.statusCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	p := a.tracker()
+	issues, err := p.ListIssues(a.Jql)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make([]issueTimes, 0)
+	for _, issue := range issues {
+		withChangelog, err := p.GetIssueWithChangeLog(issue.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving issueId %s with changelog: %v", issue.ID, err)
+		}
+		t, err := timesOf(withChangelog, categories, now)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+// statusCategories maps the name of each status to the key of its category, 'new', 'indeterminate' or 'done'
+func (a Analytics) statusCategories() (map[string]string, error) {
+	statuses, err := a.tracker().ListStatuses()
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make(map[string]string)
+	for _, status := range statuses {
+		categories[strings.ToLower(status.Name)] = status.StatusCategory.Key
+	}
+	return categories, nil
+}
+
+// statusChange is a change of an issue's status from its changelog
+type statusChange struct {
+	at       time.Time
+	from, to string
+	author   j.User
+}
+
+// statusChanges returns the status changes of an issue, oldest first
+func statusChanges(issue *j.Issue) ([]statusChange, error) {
+	changes := make([]statusChange, 0)
+	if issue.Changelog == nil {
+		return changes, nil
+	}
+
+	for _, history := range issue.Changelog.Histories {
+		created, err := time.Parse(jiraTimeFormat, history.Created)
+		if err != nil {
+			return nil, fmt.Errorf("parsing changelog time %q on issue %s: %v", history.Created, issue.Key, err)
+		}
+		for _, item := range history.Items {
+			if item.Field == "status" {
+				changes = append(changes, statusChange{at: created, from: item.FromString, to: item.ToString, author: history.Author})
+			}
+		}
+	}
+	sort.SliceStable(changes, func(i, k int) bool { return changes[i].at.Before(changes[k].at) })
+	return changes, nil
+}
+
+// timesOf walks the status changes of an issue, an issue that is reopened isn't done until it gets done again
+func timesOf(issue *j.Issue, categories map[string]string, now time.Time) (issueTimes, error) {
+	t := issueTimes{
+		Key:          issue.Key,
+		Assignee:     assigneeName(*issue),
+		TimeInStatus: make(map[string]float64),
+	}
+	if issue.Fields != nil {
+		t.Type = issue.Fields.Type.Name
+		t.Created = time.Time(issue.Fields.Created)
+		if issue.Fields.Status != nil {
+			t.Status = issue.Fields.Status.Name
+		}
+	}
+
+	changes, err := statusChanges(issue)
+	if err != nil {
+		return t, err
+	}
+
+	status := t.Status
+	if len(changes) > 0 {
+		status = changes[0].from
+	}
+	cursor := t.Created
+	enter := func(status string, at time.Time) {
+		switch categories[strings.ToLower(status)] {
+		case "indeterminate":
+			if t.Started == nil {
+				started := at
+				t.Started = &started
+			}
+			t.Done = nil
+		case "done":
+			if t.Done == nil {
+				done := at
+				t.Done = &done
+			}
+		default:
+			t.Done = nil
+		}
+	}
+	enter(status, cursor)
+
+	for _, change := range changes {
+		if categories[strings.ToLower(status)] != "done" {
+			t.TimeInStatus[status] += change.at.Sub(cursor).Hours()
+		}
+		cursor = change.at
+		status = change.to
+		enter(status, cursor)
+	}
+	if categories[strings.ToLower(status)] != "done" {
+		t.TimeInStatus[status] += now.Sub(cursor).Hours()
+	}
+
+	if t.Done != nil {
+		lead := t.Done.Sub(t.Created).Hours()
+		t.LeadTime = &lead
+		if t.Started != nil {
+			cycle := t.Done.Sub(*t.Started).Hours()
+			t.CycleTime = &cycle
+		}
+	}
+	return t, nil
+}
+
+// groupName is the name of the group an issue is reported in
+func (a Analytics) groupName(key string, issueType string, assignee string) string {
+	switch a.GroupBy {
+	case GroupByType:
+		return issueType
+	case GroupByAssignee:
+		return assignee
+	case GroupByProject:
+		return strings.SplitN(key, "-", 2)[0]
+	}
+	return "all issues"
+}
+
+func (a Analytics) cycleTimeGroups(times []issueTimes, percentiles []int) []cycleTimeGroup {
+	byName := make(map[string][]issueTimes)
+	names := make([]string, 0)
+	for _, t := range times {
+		name := a.groupName(t.Key, t.Type, t.Assignee)
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], t)
+	}
+	sort.Strings(names)
+
+	groups := make([]cycleTimeGroup, 0)
+	for _, name := range names {
+		lead, cycle := make([]float64, 0), make([]float64, 0)
+		totals, counts := make(map[string]float64), make(map[string]int)
+		group := cycleTimeGroup{Name: name, Count: len(byName[name]), TimeInStatus: make(map[string]float64)}
+		for _, t := range byName[name] {
+			if t.LeadTime != nil {
+				group.Done++
+				lead = append(lead, *t.LeadTime)
+			}
+			if t.CycleTime != nil {
+				cycle = append(cycle, *t.CycleTime)
+			}
+			for status, hours := range t.TimeInStatus {
+				totals[status] += hours
+				counts[status]++
+			}
+		}
+		group.LeadTime = percentileMap(lead, percentiles)
+		group.CycleTime = percentileMap(cycle, percentiles)
+		for status, total := range totals {
+			group.TimeInStatus[status] = total / float64(counts[status])
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func percentileMap(values []float64, percentiles []int) map[string]float64 {
+	m := make(map[string]float64)
+	if len(values) == 0 {
+		return m
+	}
+	for _, p := range percentiles {
+		m[fmt.Sprintf("p%d", p)] = percentile(values, p)
+	}
+	return m
+}
+
+// percentile uses the nearest rank method, the smallest value at least p percent of the values are less than or
+// equal to
+func percentile(values []float64, p int) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// formatHours formats hours as days when they are at least a day eg '3.5d', otherwise as hours eg '5.2h'
+func formatHours(hours float64) string {
+	if hours >= 24 || hours <= -24 {
+		return strconv.FormatFloat(hours/24, 'f', 1, 64) + "d"
+	}
+	return strconv.FormatFloat(hours, 'f', 1, 64) + "h"
+}
+
+func cycleTimeText(groups []cycleTimeGroup, percentiles []int) string {
+	b := &strings.Builder{}
+	for _, group := range groups {
+		fmt.Fprintf(b, "\n%s (%d issues, %d done)\n", group.Name, group.Count, group.Done)
+		for _, metric := range []struct {
+			name   string
+			values map[string]float64
+		}{{"lead time", group.LeadTime}, {"cycle time", group.CycleTime}} {
+			fmt.Fprintf(b, "  %s:", metric.name)
+			if len(metric.values) == 0 {
+				fmt.Fprintf(b, " no done issues")
+			}
+			for _, p := range percentiles {
+				if v, ok := metric.values[fmt.Sprintf("p%d", p)]; ok {
+					fmt.Fprintf(b, " p%d %s", p, formatHours(v))
+				}
+			}
+			fmt.Fprintln(b)
+		}
+
+		statuses := make([]string, 0)
+		for status := range group.TimeInStatus {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+		inStatus := make([]string, 0)
+		for _, status := range statuses {
+			inStatus = append(inStatus, fmt.Sprintf("%s %s", status, formatHours(group.TimeInStatus[status])))
+		}
+		fmt.Fprintf(b, "  mean time in status: %s\n", strings.Join(inStatus, ", "))
+	}
+	return b.String()
+}
+
+func cycleTimeCSV(times []issueTimes) (string, error) {
+	statuses := make([]string, 0)
+	for _, t := range times {
+		for status := range t.TimeInStatus {
+			if !containsString(statuses, status) {
+				statuses = append(statuses, status)
+			}
+		}
+	}
+	sort.Strings(statuses)
+
+	b := &strings.Builder{}
+	w := csv.NewWriter(b)
+	header := []string{"key", "type", "assignee", "status", "created", "started", "done", "lead_time_hours", "cycle_time_hours"}
+	for _, status := range statuses {
+		header = append(header, "hours_in_"+status)
+	}
+	if err := w.Write(header); err != nil {
+		return "", fmt.Errorf("writing csv: %v", err)
+	}
+
+	hours := func(h *float64) string {
+		if h == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*h, 'f', 2, 64)
+	}
+	timestamp := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	for _, t := range times {
+		created := t.Created
+		row := []string{t.Key, t.Type, t.Assignee, t.Status, timestamp(&created), timestamp(t.Started), timestamp(t.Done), hours(t.LeadTime), hours(t.CycleTime)}
+		for _, status := range statuses {
+			h, ok := t.TimeInStatus[status]
+			if !ok {
+				row = append(row, "")
+				continue
+			}
+			row = append(row, hours(&h))
+		}
+		if err := w.Write(row); err != nil {
+			return "", fmt.Errorf("writing csv: %v", err)
+		}
+	}
+	w.Flush()
+	return b.String(), w.Error()
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/jira"
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestCycleTime(t *testing.T) {
+	created := time.Now().UTC().AddDate(0, 0, -30).Truncate(time.Second)
+	day := func(n int) time.Time { return created.AddDate(0, 0, n) }
+	moved := func(at time.Time, from string, to string) jiratest.History {
+		return jiratest.History{Author: "someone", Created: at, Items: []jiratest.HistoryItem{{Field: "status", From: from, To: to}}}
+	}
+
+	s := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Type: "Story", Assignee: "jane", Status: "Done", StatusCategory: "done", Created: created, Changelog: []jiratest.History{
+				moved(day(1), "To Do", "In Progress"),
+				moved(day(3), "In Progress", "In Review"),
+				moved(day(4), "In Review", "Done"),
+			}},
+			{Key: "IPL-2", Type: "Bug", Assignee: "joe", Status: "Done", StatusCategory: "done", Created: created, Changelog: []jiratest.History{
+				moved(day(2), "To Do", "In Progress"),
+				moved(day(4), "In Progress", "Done"),
+				moved(day(5), "Done", "In Progress"),
+				moved(day(10), "In Progress", "Done"),
+			}},
+			{Key: "IPL-3", Type: "Story", Assignee: "jane", Status: "In Progress", StatusCategory: "indeterminate", Created: created, Changelog: []jiratest.History{
+				moved(day(20), "To Do", "In Progress"),
+			}},
+		},
+		StatusCategories: map[string]string{"To Do": "new", "In Progress": "indeterminate", "In Review": "indeterminate", "Done": "done"},
+	})
+	defer s.Close()
+
+	// the statuses, issues and changelogs all come through the tracker
+	a := Analytics{List: List{Tracker: jira.Project{JiraUrl: s.URL}, Jql: "project = IPL"}}
+	times, err := a.issueTimes(day(30))
+	if err != nil {
+		t.Fatalf("getting issue times: %v", err)
+	}
+
+	byKey := make(map[string]issueTimes)
+	for _, it := range times {
+		byKey[it.Key] = it
+	}
+
+	first := byKey["IPL-1"]
+	if *first.LeadTime != 96 || *first.CycleTime != 72 {
+		t.Errorf("expected IPL-1 to have a lead time of 96h and cycle time of 72h, got %v and %v", *first.LeadTime, *first.CycleTime)
+	}
+	expected := map[string]float64{"To Do": 24, "In Progress": 48, "In Review": 24}
+	for status, hours := range expected {
+		if first.TimeInStatus[status] != hours {
+			t.Errorf("expected IPL-1 to spend %vh in %s, got %v", hours, status, first.TimeInStatus[status])
+		}
+	}
+	if _, ok := first.TimeInStatus["Done"]; ok {
+		t.Errorf("expected time in done statuses not to be counted")
+	}
+
+	reopened := byKey["IPL-2"]
+	if *reopened.LeadTime != 240 || *reopened.CycleTime != 192 {
+		t.Errorf("expected reopened IPL-2 to be done when it was done again, got lead %v cycle %v", *reopened.LeadTime, *reopened.CycleTime)
+	}
+	if open := byKey["IPL-3"]; open.LeadTime != nil || open.TimeInStatus["In Progress"] != 240 {
+		t.Errorf("expected IPL-3 to not be done and have been in progress for 240h, got %+v", open)
+	}
+
+	a.GroupBy = GroupByAssignee
+	groups := a.cycleTimeGroups(times, DefaultPercentiles)
+	if len(groups) != 2 || groups[0].Name != "jane" || groups[0].Count != 2 || groups[0].Done != 1 || groups[0].LeadTime["p50"] != 96 {
+		t.Errorf("expected jane's group to have 2 issues with 1 done in 96h, got %+v", groups)
+	}
+
+	out, err := cycleTimeCSV(times)
+	if err != nil {
+		t.Fatalf("writing csv: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "key,type,assignee,status,created,started,done,lead_time_hours,cycle_time_hours,hours_in_In Progress") {
+		t.Errorf("expected a csv header and a row per issue, got %q", out)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{5, 1, 4, 2, 3, 6, 7, 8, 9, 10}
+	cases := map[int]float64{50: 5, 85: 9, 95: 10, 100: 10, 1: 1}
+	for p, expected := range cases {
+		if actual := percentile(values, p); actual != expected {
+			t.Errorf("expected p%d to be %v, got %v", p, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/jira"
+)
+
+// Audit prints the changelog entries of issues as a timeline, marking the changes made by the accounts the tool runs
+// as so automation can be told apart from people's edits
+type Audit struct {
+	JiraToken string
+	JiraUrl   string
+	UserName  string
+	Jql       string
+	IssueKeys []string
+	// Fields limits the timeline to changes of these fields eg 'status' or 'Sprint'
+	Fields []string
+	// Authors limits the timeline to changes by these accounts, matched by name, email, account id or display name
+	Authors []string
+	// StartDate and EndDate, in the format YYYY-MM-DD, limit the timeline to changes made on or between them
+	StartDate string
+	EndDate   string
+	// Format is 'text', 'csv' or 'json'
+	Format string
+	// Guard's BotAccounts are the accounts whose changes are marked as automated, defaulting to the jira user
+	Guard Guard
+}
+
+type auditEntry struct {
+	Key       string    `json:"key"`
+	At        time.Time `json:"at"`
+	Field     string    `json:"field"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Author    string    `json:"author"`
+	Automated bool      `json:"automated"`
+}
+
+func (a Audit) project() jira.Project {
+	return jira.Project{
+		Token:    a.JiraToken,
+		UserName: a.UserName,
+		JiraUrl:  a.JiraUrl,
+	}
+}
+
+// Audit prints the timeline of changes to the issues found with the jql query or issue keys
+func (a Audit) Audit() error {
+	format := a.Format
+	if format == "" {
+		format = FormatText
+	}
+	if format != FormatText && format != FormatCSV && format != FormatJSON {
+		return fmt.Errorf("unknown format %q, expected one of %s, %s or %s", format, FormatText, FormatCSV, FormatJSON)
+	}
+
+	entries, err := a.entries()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatJSON:
+		out, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding audit: %v", err)
+		}
+		fmt.Println(string(out))
+	case FormatCSV:
+		rows := [][]string{{"key", "at", "field", "from", "to", "author", "automated"}}
+		for _, e := range entries {
+			rows = append(rows, []string{e.Key, e.At.Format(time.RFC3339), e.Field, e.From, e.To, e.Author, strconv.FormatBool(e.Automated)})
+		}
+		out, err := writeCSV(rows)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+	default:
+		fmt.Print(auditText(entries))
+	}
+	return nil
+}
+
+// entries returns the changes to the issues that pass the filters, oldest first
+func (a Audit) entries() ([]auditEntry, error) {
+	start, err := parseDate(a.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseDate(a.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	if end != nil {
+		// the end date is included
+		next := end.AddDate(0, 0, 1)
+		end = &next
+	}
+
+	p := a.project()
+	guard, err := a.Guard.withDefaultBots(a.UserName, p)
+	if err != nil {
+		return nil, err
+	}
+
+	issues, err := findIssues(a.IssueKeys, a.Jql, p)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]auditEntry, 0)
+	for _, issue := range issues {
+		withChangelog, err := p.GetIssueWithChangeLog(issue.ID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving issueId %s with changelog: %v", issue.ID, err)
+		}
+		if withChangelog.Changelog == nil {
+			continue
+		}
+
+		for _, history := range withChangelog.Changelog.Histories {
+			at, err := time.Parse(jiraTimeFormat, history.Created)
+			if err != nil {
+				return nil, fmt.Errorf("parsing changelog time %q on issue %s: %v", history.Created, issue.Key, err)
+			}
+			if (start != nil && at.Before(*start)) || (end != nil && !at.Before(*end)) {
+				continue
+			}
+			if len(a.Authors) > 0 && !matchesAccount(history.Author, a.Authors) {
+				continue
+			}
+
+			for _, item := range history.Items {
+				if len(a.Fields) > 0 && !containsFold(a.Fields, item.Field) {
+					continue
+				}
+				entries = append(entries, auditEntry{
+					Key:       withChangelog.Key,
+					At:        at,
+					Field:     item.Field,
+					From:      changeValue(item.FromString, item.From),
+					To:        changeValue(item.ToString, item.To),
+					Author:    authorName(history.Author),
+					Automated: guard.isBot(history.Author),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, k int) bool { return entries[i].At.Before(entries[k].At) })
+	return entries, nil
+}
+
+// changeValue is the display value of a changed field, falling back to its raw value eg for sprint ids
+func changeValue(display string, raw interface{}) string {
+	if display != "" || raw == nil {
+		return display
+	}
+	return fmt.Sprint(raw)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func auditText(entries []auditEntry) string {
+	b := &strings.Builder{}
+	automated := 0
+	for _, e := range entries {
+		mark := ""
+		if e.Automated {
+			mark = "\t[automated]"
+			automated++
+		}
+		fmt.Fprintf(b, "%s\t%s\t%s\t%q -> %q\t%s%s\n", e.At.Format("2006-01-02 15:04"), e.Key, e.Field, e.From, e.To, e.Author, mark)
+	}
+	fmt.Fprintf(b, "\n%d changes, %d automated and %d by people\n", len(entries), automated, len(entries)-automated)
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestAudit(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2026, 3, day, 12, 0, 0, 0, time.UTC) }
+	change := func(author string, day int, field string, from string, to string) jiratest.History {
+		return jiratest.History{Author: author, Created: at(day), Items: []jiratest.HistoryItem{{Field: field, From: from, To: to}}}
+	}
+
+	s := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "Done", Changelog: []jiratest.History{
+				change("jane", 1, "status", "To Do", "In Progress"),
+				change("bot", 4, "status", "In Progress", "Done"),
+			}},
+			{Key: "IPL-2", Status: "To Do", Changelog: []jiratest.History{
+				change("joe", 2, "labels", "", "needs-docs"),
+				{Author: "bot", Created: at(3), Items: []jiratest.HistoryItem{{Field: "Sprint", FromID: "", ToID: "7"}}},
+			}},
+		},
+		Queries: map[string][]string{"project = IPL": {"IPL-1", "IPL-2"}},
+		// changelogs past the first change are paged through
+		ChangelogPageSize: 1,
+		Myself:            "bot",
+	})
+	defer s.Close()
+
+	// changelogs show the account id rather than the email the tool authenticates with
+	a := Audit{JiraUrl: s.URL, UserName: "bot@example.com", Jql: "project = IPL"}
+	entries, err := a.entries()
+	if err != nil {
+		t.Fatalf("auditing: %v", err)
+	}
+	order := make([]string, 0)
+	for _, e := range entries {
+		order = append(order, e.Key+" "+e.Field)
+	}
+	if strings.Join(order, ",") != "IPL-1 status,IPL-2 labels,IPL-2 Sprint,IPL-1 status" {
+		t.Fatalf("expected a timeline of all changes oldest first, got %v", order)
+	}
+	if entries[0].Automated || !entries[2].Automated || entries[2].To != "7" {
+		t.Errorf("expected the jira user's changes to be marked automated and raw values used without display ones, got %+v", entries)
+	}
+
+	a = Audit{JiraUrl: s.URL, IssueKeys: []string{"IPL-1"}, Fields: []string{"Status"}, StartDate: "2026-03-02", EndDate: "2026-03-04",
+		Guard: Guard{BotAccounts: []string{"bot"}}}
+	entries, err = a.entries()
+	if err != nil {
+		t.Fatalf("auditing: %v", err)
+	}
+	if len(entries) != 1 || entries[0].To != "Done" || !entries[0].Automated {
+		t.Errorf("expected only the status change on the end date, got %+v", entries)
+	}
+
+	a = Audit{JiraUrl: s.URL, Jql: "project = IPL", Authors: []string{"JOE"}}
+	entries, err = a.entries()
+	if err != nil {
+		t.Fatalf("auditing: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Field != "labels" {
+		t.Errorf("expected only joe's change, got %+v", entries)
+	}
+	if out := auditText(entries); !strings.Contains(out, "1 changes, 0 automated and 1 by people") {
+		t.Errorf("expected a summary of automated changes, got %q", out)
+	}
+}
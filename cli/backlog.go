@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"fmt"
+
+	j "github.com/andygrunwald/go-jira"
+	c "github.com/gookit/color"
+	"github.com/jirallreadyforthis/lib/jira"
+)
+
+type Backlog struct {
+	JiraToken string
+	JiraUrl   string
+	UserName  string
+	Jql       string
+	IssueKeys []string
+	DryRun    bool
+	// SprintId, or Board and Sprint, is the sprint to remove issues from
+	SprintId int
+	Board    string
+	Sprint   string
+	// RankBefore and RankAfter are the key of the issue to rank issues before or after
+	RankBefore string
+	RankAfter  string
+}
+
+func (b Backlog) project() jira.Project {
+	return jira.Project{
+		Token:    b.JiraToken,
+		UserName: b.UserName,
+		JiraUrl:  b.JiraUrl,
+	}
+}
+
+// RemoveFromSprint moves the issues that are in the sprint to the backlog, issues not in it are left alone
+func (b Backlog) RemoveFromSprint() error {
+	p := b.project()
+
+	sprintId := b.SprintId
+	if sprintId == 0 {
+		id, err := resolveSprintId(p, b.Board, b.Sprint)
+		if err != nil {
+			return err
+		}
+		sprintId = id
+	}
+
+	issues, err := findIssues(b.IssueKeys, b.Jql, p)
+	if err != nil {
+		return err
+	}
+	inSprint, err := p.ListSprintIssues(sprintId)
+	if err != nil {
+		return err
+	}
+	ids := make(map[string]bool)
+	for _, issue := range inSprint {
+		ids[issue.ID] = true
+	}
+
+	issueIds := make([]string, 0)
+	for _, issue := range issues {
+		if !ids[issue.ID] {
+			c.Warn.Printf("issue %s is not in sprint %d\n", issue.Key, sprintId)
+			continue
+		}
+		fmt.Printf("removing issue (key %s id %s) from sprint (id %d)\n", issue.Key, issue.ID, sprintId)
+		issueIds = append(issueIds, issue.ID)
+	}
+
+	if !b.DryRun && len(issueIds) > 0 {
+		if err := p.MoveToBacklog(issueIds); err != nil {
+			return err
+		}
+	}
+
+	c.Info.Printf("\nFinished removing %d issues from sprint %d\n", len(issueIds), sprintId)
+	return nil
+}
+
+// MoveToBacklog moves issues out of any sprint they are in to the backlog
+func (b Backlog) MoveToBacklog() error {
+	p := b.project()
+
+	issues, err := findIssues(b.IssueKeys, b.Jql, p)
+	if err != nil {
+		return err
+	}
+
+	issueIds := make([]string, 0)
+	for _, issue := range issues {
+		fmt.Printf("moving issue (key %s id %s) to the backlog\n", issue.Key, issue.ID)
+		issueIds = append(issueIds, issue.ID)
+	}
+
+	if !b.DryRun && len(issueIds) > 0 {
+		if err := p.MoveToBacklog(issueIds); err != nil {
+			return err
+		}
+	}
+
+	c.Info.Printf("\nFinished moving %d issues to the backlog\n", len(issueIds))
+	return nil
+}
+
+// Rank ranks issues, in the order of the input keys or jql results, directly before or after another issue
+func (b Backlog) Rank() error {
+	if (b.RankBefore == "") == (b.RankAfter == "") {
+		return fmt.Errorf("exactly one of an issue to rank before or after is required")
+	}
+	anchor, position := b.RankBefore, "before"
+	if b.RankAfter != "" {
+		anchor, position = b.RankAfter, "after"
+	}
+
+	p := b.project()
+
+	issues, err := findIssues(b.IssueKeys, b.Jql, p)
+	if err != nil {
+		return err
+	}
+
+	issueIds := make([]string, 0)
+	for _, issue := range issues {
+		if issue.Key == anchor {
+			c.Warn.Printf("NOT ranking issue %s %s itself\n", issue.Key, position)
+			continue
+		}
+		fmt.Printf("ranking issue (key %s id %s) %s %s\n", issue.Key, issue.ID, position, anchor)
+		issueIds = append(issueIds, issue.ID)
+	}
+
+	if !b.DryRun && len(issueIds) > 0 {
+		if err := p.RankIssues(issueIds, b.RankBefore, b.RankAfter); err != nil {
+			return err
+		}
+	}
+
+	c.Info.Printf("\nFinished ranking %d issues %s %s\n", len(issueIds), position, anchor)
+	return nil
+}
+
+// findIssues finds issues by key, or with the jql query when there are no keys. Issues found by key only have
+// their id and key set
+func findIssues(issueKeys []string, jql string, p IssueTracker) ([]j.Issue, error) {
+	if len(issueKeys) > 0 {
+		issues := make([]j.Issue, 0)
+		for _, issueKey := range issueKeys {
+			issueId, err := getIssueIdFromKey(issueKey, p)
+			if err != nil {
+				return nil, err
+			}
+			issues = append(issues, j.Issue{ID: issueId, Key: issueKey})
+		}
+		return issues, nil
+	}
+	if jql != "" {
+		return p.ListIssues(jql)
+	}
+	return nil, fmt.Errorf("either issue keys or a jql query are required")
+}
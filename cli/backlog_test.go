@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func backlogServer() *jiratest.Server {
+	return jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{ID: "1", Key: "IPL-1", Status: "To Do", SprintID: 7},
+			{ID: "2", Key: "IPL-2", Status: "To Do", SprintID: 7},
+			{ID: "3", Key: "IPL-3", Status: "To Do", SprintID: 8},
+			{ID: "4", Key: "IPL-4", Status: "To Do"},
+		},
+		Boards:  []jiratest.Board{{ID: 1, Name: "Team X", Type: "scrum", ProjectKey: "IPL"}},
+		Sprints: []jiratest.Sprint{{ID: 7, BoardID: 1, Name: "Sprint 41", State: "active"}},
+		Queries: map[string][]string{
+			"labels = later": {"IPL-2", "IPL-3"},
+		},
+	})
+}
+
+func TestRemoveFromSprint(t *testing.T) {
+	s := backlogServer()
+	defer s.Close()
+
+	out := captureOutput(t, Backlog{JiraUrl: s.URL, Board: "Team X", Sprint: "active", IssueKeys: []string{"IPL-1", "IPL-3"}}.RemoveFromSprint)
+
+	expected := map[string]int{"IPL-1": 0, "IPL-2": 7, "IPL-3": 8}
+	for key, sprint := range expected {
+		if actual := s.Issue(key).SprintID; actual != sprint {
+			t.Errorf("expected %s to be in sprint %d, got %d", key, sprint, actual)
+		}
+	}
+	if !strings.Contains(out, "IPL-3 is not in sprint 7") {
+		t.Errorf("expected a warning for the issue not in the sprint, got %q", out)
+	}
+}
+
+func TestMoveToBacklog(t *testing.T) {
+	s := backlogServer()
+	defer s.Close()
+
+	captureOutput(t, Backlog{JiraUrl: s.URL, Jql: "labels = later", DryRun: true}.MoveToBacklog)
+	if s.Issue("IPL-2").SprintID != 7 {
+		t.Fatalf("expected a dry run not to move issues")
+	}
+
+	captureOutput(t, Backlog{JiraUrl: s.URL, Jql: "labels = later"}.MoveToBacklog)
+	expected := map[string]int{"IPL-1": 7, "IPL-2": 0, "IPL-3": 0}
+	for key, sprint := range expected {
+		if actual := s.Issue(key).SprintID; actual != sprint {
+			t.Errorf("expected %s to be in sprint %d, got %d", key, sprint, actual)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	s := backlogServer()
+	defer s.Close()
+
+	captureOutput(t, Backlog{JiraUrl: s.URL, IssueKeys: []string{"IPL-4", "IPL-3"}, RankBefore: "IPL-1"}.Rank)
+	if ranked := strings.Join(s.Ranked(), ","); ranked != "IPL-4,IPL-3,IPL-1,IPL-2" {
+		t.Errorf("expected IPL-4 and IPL-3 to be ranked before IPL-1, got %s", ranked)
+	}
+
+	captureOutput(t, Backlog{JiraUrl: s.URL, IssueKeys: []string{"IPL-4"}, RankAfter: "IPL-2"}.Rank)
+	if ranked := strings.Join(s.Ranked(), ","); ranked != "IPL-3,IPL-1,IPL-2,IPL-4" {
+		t.Errorf("expected IPL-4 to be ranked after IPL-2, got %s", ranked)
+	}
+
+	if err := (Backlog{JiraUrl: s.URL, IssueKeys: []string{"IPL-4"}}).Rank(); err == nil {
+		t.Errorf("expected an error without an issue to rank before or after")
+	}
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jirallreadyforthis/lib/bitbucket"
+)
+
+var (
+	bitbucketCloudLinkRe = regexp.MustCompile("https://bitbucket\\.org/(?P<project>[\\w.-]+)/(?P<slug>[\\w.-]+)/pull-requests/(?P<number>\\d+)")
+	// data center links can be on any host, possibly under a context path, and to personal repos under /users/
+	bitbucketDataCenterLinkRe = regexp.MustCompile("(?P<baseURL>https?://[\\w.-]+(?::\\d+)?(?:/[\\w.-]+)*?)/(?P<kind>projects|users)/(?P<project>[\\w.~-]+)/repos/(?P<slug>[\\w.-]+)/pull-requests/(?P<number>\\d+)")
+)
+
+// bitbucketHost is the bitbucket CodeHost, for both bitbucket cloud and data center pull requests
+type bitbucketHost struct {
+	UserName string
+	Token    string
+	// CloudApiUrl overrides the bitbucket cloud api url
+	CloudApiUrl string
+}
+
+func (b bitbucketHost) FindLinks(text string) []string {
+	links := bitbucketCloudLinkRe.FindAllString(text, -1)
+	return append(links, bitbucketDataCenterLinkRe.FindAllString(text, -1)...)
+}
+
+func (b bitbucketHost) Repo(link string) string {
+	repo, _, err := b.repo(link)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", strings.TrimPrefix(repo.Project, "~"), repo.Slug)
+}
+
+func (b bitbucketHost) repo(link string) (bitbucket.Repo, int, error) {
+	if m := bitbucketCloudLinkRe.FindStringSubmatch(link); m != nil {
+		number, _ := strconv.Atoi(m[bitbucketCloudLinkRe.SubexpIndex("number")])
+		repo := bitbucket.NewCloudRepo(m[bitbucketCloudLinkRe.SubexpIndex("project")], m[bitbucketCloudLinkRe.SubexpIndex("slug")], b.UserName, b.Token)
+		if b.CloudApiUrl != "" {
+			repo.BaseURL = strings.TrimSuffix(b.CloudApiUrl, "/")
+		}
+		return repo, number, nil
+	}
+
+	if m := bitbucketDataCenterLinkRe.FindStringSubmatch(link); m != nil {
+		number, _ := strconv.Atoi(m[bitbucketDataCenterLinkRe.SubexpIndex("number")])
+		project := m[bitbucketDataCenterLinkRe.SubexpIndex("project")]
+		// personal repos are addressed in the api as a project named after the user with a '~' prefix
+		if m[bitbucketDataCenterLinkRe.SubexpIndex("kind")] == "users" && !strings.HasPrefix(project, "~") {
+			project = "~" + project
+		}
+		repo := bitbucket.NewDataCenterRepo(m[bitbucketDataCenterLinkRe.SubexpIndex("baseURL")], project, m[bitbucketDataCenterLinkRe.SubexpIndex("slug")], b.UserName, b.Token)
+		return repo, number, nil
+	}
+
+	return bitbucket.Repo{}, 0, fmt.Errorf("%s is not a bitbucket pull request link", link)
+}
+
+func (b bitbucketHost) GetItem(link string) (*LinkedItem, error) {
+	repo, number, err := b.repo(link)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := repo.GetPullRequest(number)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &LinkedItem{
+		URL:             link,
+		Repo:            b.Repo(link),
+		Number:          pr.ID,
+		Title:           pr.Title,
+		State:           "open",
+		ClosedAt:        pr.ClosedAt,
+		PullRequest:     true,
+		Merged:          pr.State == bitbucket.StateMerged,
+		MergedAt:        pr.MergedAt,
+		Draft:           pr.Draft,
+		ReviewRequested: len(pr.Reviewers) > 0,
+		Base:            pr.Destination,
+		Head:            pr.Source,
+		HeadSHA:         pr.SourceCommit,
+		MergeCommitSHA:  pr.MergeCommit,
+	}
+	if pr.URL != "" {
+		item.URL = pr.URL
+	}
+	if pr.State != bitbucket.StateOpen {
+		item.State = "closed"
+	}
+
+	return item, nil
+}
+
+func (b bitbucketHost) ReviewDecision(item *LinkedItem) (string, error) {
+	repo, number, err := b.repo(item.URL)
+	if err != nil {
+		return "", err
+	}
+
+	pr, err := repo.GetPullRequest(number)
+	if err != nil {
+		return "", err
+	}
+
+	approved := false
+	for _, reviewer := range pr.Reviewers {
+		if reviewer.Status == bitbucket.ReviewerNeedsWork {
+			return ReviewChangesRequested, nil
+		}
+		if reviewer.Status == bitbucket.ReviewerApproved {
+			approved = true
+		}
+	}
+
+	if approved {
+		return ReviewApproved, nil
+	}
+	return ReviewRequired, nil
+}
+
+func (b bitbucketHost) ChecksState(item *LinkedItem) (string, error) {
+	repo, _, err := b.repo(item.URL)
+	if err != nil {
+		return "", err
+	}
+
+	states, err := repo.GetBuildStates(item.HeadSHA)
+	if err != nil {
+		return "", err
+	}
+
+	state := ChecksSuccess
+	for _, s := range states {
+		switch s {
+		case bitbucket.BuildFailed, bitbucket.BuildStopped:
+			return ChecksFailure, nil
+		case bitbucket.BuildInProgress:
+			state = ChecksPending
+		}
+	}
+	return state, nil
+}
+
+// ReleaseContaining always returns no release as bitbucket doesn't have releases, so with --require-release
+// bitbucket pull requests are never counted
+func (b bitbucketHost) ReleaseContaining(item *LinkedItem) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	j "github.com/andygrunwald/go-jira"
+	c "github.com/gookit/color"
+)
+
+// storyPointsFields are the names jira uses for the story points field, company-managed and team-managed projects
+// name it differently
+var storyPointsFields = []string{"Story Points", "Story point estimate"}
+
+// Capacity is a limit on the story points in a sprint, in total and for each assignee
+type Capacity struct {
+	// PointsField is the id or name of the story points field, found through the fields api when empty
+	PointsField string
+	// Sprint is the most points a sprint should hold, 0 for no limit
+	Sprint float64
+	// Assignees are the most points each assignee should have in a sprint, in the format 'name=points'
+	Assignees []string
+	// Refuse stops issues being added to a sprint over capacity rather than warning about it
+	Refuse bool
+}
+
+func (cp Capacity) enabled() bool {
+	return cp.PointsField != "" || cp.Sprint > 0 || len(cp.Assignees) > 0
+}
+
+// sprintLoad is the story points in a sprint, including those being added to it
+type sprintLoad struct {
+	field     string
+	inSprint  map[string]bool
+	existing  float64
+	adding    float64
+	assignees map[string]float64
+}
+
+// load finds the story points already in a sprint
+func (cp Capacity) load(sprintId int, p IssueTracker) (*sprintLoad, error) {
+	field, err := pointsFieldID(cp.PointsField, p)
+	if err != nil {
+		return nil, err
+	}
+
+	issues, err := p.ListSprintIssues(sprintId)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &sprintLoad{field: field, inSprint: make(map[string]bool), assignees: make(map[string]float64)}
+	for _, issue := range issues {
+		points := storyPoints(issue, field)
+		l.inSprint[issue.ID] = true
+		l.existing += points
+		l.assignees[assigneeName(issue)] += points
+	}
+	return l, nil
+}
+
+// add counts the points of an issue being added to the sprint, unless it is already in it
+func (l *sprintLoad) add(issue j.Issue) float64 {
+	points := storyPoints(issue, l.field)
+	if !l.inSprint[issue.ID] {
+		l.inSprint[issue.ID] = true
+		l.adding += points
+		l.assignees[assigneeName(issue)] += points
+	}
+	return points
+}
+
+// check prints the point totals of the sprint and its assignees, returning whether any are over capacity
+func (cp Capacity) check(sprintId int, l *sprintLoad) (bool, error) {
+	limits, err := cp.assigneeLimits()
+	if err != nil {
+		return false, err
+	}
+
+	total := l.existing + l.adding
+	over := false
+	fmt.Printf("\nsprint %d has %s points, %s added to %s already in it\n", sprintId, formatPoints(total),
+		formatPoints(l.adding), formatPoints(l.existing))
+	if cp.Sprint > 0 {
+		if total > cp.Sprint {
+			over = true
+			c.Warn.Printf("sprint %d is over capacity with %s of %s points\n", sprintId, formatPoints(total), formatPoints(cp.Sprint))
+		} else {
+			fmt.Printf("sprint %d is within capacity with %s of %s points\n", sprintId, formatPoints(total), formatPoints(cp.Sprint))
+		}
+	}
+
+	assignees := make([]string, 0)
+	for assignee := range l.assignees {
+		assignees = append(assignees, assignee)
+	}
+	sort.Strings(assignees)
+	for _, assignee := range assignees {
+		points := l.assignees[assignee]
+		limit, ok := limits[strings.ToLower(assignee)]
+		switch {
+		case !ok:
+			fmt.Printf("  %s: %s points\n", assignee, formatPoints(points))
+		case points > limit:
+			over = true
+			c.Warn.Printf("  %s: %s of %s points, over capacity\n", assignee, formatPoints(points), formatPoints(limit))
+		default:
+			fmt.Printf("  %s: %s of %s points\n", assignee, formatPoints(points), formatPoints(limit))
+		}
+	}
+
+	return over, nil
+}
+
+func (cp Capacity) assigneeLimits() (map[string]float64, error) {
+	limits := make(map[string]float64)
+	for _, assignee := range cp.Assignees {
+		parts := strings.SplitN(assignee, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("assignee capacity %q should be in the format 'name=points'", assignee)
+		}
+		points, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing points of assignee capacity %q: %v", assignee, err)
+		}
+		limits[strings.ToLower(strings.TrimSpace(parts[0]))] = points
+	}
+	return limits, nil
+}
+
+// pointsFieldID finds the id of the story points field from its id or name, or from the names jira uses for it
+func pointsFieldID(field string, p IssueTracker) (string, error) {
+	if strings.HasPrefix(field, "customfield_") {
+		return field, nil
+	}
+
+	names := storyPointsFields
+	if field != "" {
+		names = []string{field}
+	}
+	id, err := p.FindFieldID(names...)
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		return "", fmt.Errorf("no story points field named %s found, set its id with --story-points-field", strings.Join(names, " or "))
+	}
+	return id, nil
+}
+
+// storyPoints reads the story points of an issue, unestimated issues have 0 points
+func storyPoints(issue j.Issue, field string) float64 {
+	if issue.Fields == nil || issue.Fields.Unknowns == nil {
+		return 0
+	}
+	value, ok := issue.Fields.Unknowns.Value(field)
+	if !ok {
+		return 0
+	}
+	switch v := value.(type) {
+	case float64:
+		return v
+	case string:
+		points, _ := strconv.ParseFloat(v, 64)
+		return points
+	}
+	return 0
+}
+
+func assigneeName(issue j.Issue) string {
+	if issue.Fields == nil || issue.Fields.Assignee == nil {
+		return "unassigned"
+	}
+	if issue.Fields.Assignee.DisplayName != "" {
+		return issue.Fields.Assignee.DisplayName
+	}
+	return issue.Fields.Assignee.Name
+}
+
+func formatPoints(points float64) string {
+	return strconv.FormatFloat(points, 'f', -1, 64)
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"time"
+
+	j "github.com/andygrunwald/go-jira"
+	"github.com/jirallreadyforthis/lib/jira"
+)
+
+// IssueTracker is where the issues the commands work on live, jira.Project is the default implementation. It is
+// shaped after jira and uses go-jira's issue, transition, user and board types, so it is for wrapping jira eg with caching,
+// retries or fakes in tests. Another tracker would have to convert its issues to jira's model
+type IssueTracker interface {
+	ListIssues(jql string) ([]j.Issue, error)
+	GetIssue(issueId string) (*j.Issue, error)
+	GetIssueWithChangeLog(issueId string) (*j.Issue, error)
+	GetPossibleIssueTransitions(issueId string) ([]j.Transition, error)
+	TransitionIssueStatus(issueId string, transitionID string) error
+	AddToSprint(sprintId int, issueIds []string) error
+	ListSprintIssues(sprintId int) ([]j.Issue, error)
+	ListStatuses() ([]j.Status, error)
+	FindBoard(board string, projectKey string) (*j.Board, error)
+	ResolveSprint(boardId int, sprint string) (*jira.Sprint, error)
+	FindFieldID(names ...string) (string, error)
+	AddComment(issueId string, body string) error
+	GetCurrentUser() (*j.User, error)
+}
+
+// CodeHost resolves links to issues and pull requests on a code hosting service such as github
+type CodeHost interface {
+	// FindLinks returns the links to issues and pull requests on this host in some text
+	FindLinks(text string) []string
+	// Repo returns the 'owner/name' of the repo a link is in, or "" if the link isn't to this host
+	Repo(link string) string
+	// GetItem gets the current state of the issue or pull request a link is to
+	GetItem(link string) (*LinkedItem, error)
+	// ReviewDecision is the overall review state of a pull request, one of the Review constants
+	ReviewDecision(item *LinkedItem) (string, error)
+	// ChecksState is the combined state of the checks on the head of a pull request, one of the Checks constants
+	ChecksState(item *LinkedItem) (string, error)
+	// ReleaseContaining returns the first release made from the branch a merged pull request was merged into that
+	// includes it, or "" if it isn't released yet
+	ReleaseContaining(item *LinkedItem) (string, error)
+}
+
+// IssueEditor is implemented by code hosts whose linked issues gh-sync can update
+type IssueEditor interface {
+	// CloseIssue closes an issue as completed
+	CloseIssue(item *LinkedItem) error
+	AddLabels(item *LinkedItem, labels []string) error
+	RemoveLabel(item *LinkedItem, label string) error
+	// ListComments returns the bodies of the comments on an issue, oldest first
+	ListComments(item *LinkedItem) ([]string, error)
+	AddComment(item *LinkedItem, body string) error
+}
+
+// The review decisions and check states a CodeHost reports for a pull request
+const (
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes_requested"
+	ReviewRequired         = "review_required"
+	// ReviewCommented is a pull request whose reviewers have commented without approving or requesting changes
+	ReviewCommented = "commented"
+
+	ChecksSuccess = "success"
+	ChecksPending = "pending"
+	ChecksFailure = "failure"
+)
+
+// LinkedItem is an issue or pull request linked from an issue, in a form shared by all code hosts
+type LinkedItem struct {
+	URL    string
+	Repo   string
+	Number int
+	Title  string
+	// State is 'open' or 'closed'
+	State string
+	// StateReason is why a closed issue was closed, 'completed' or 'not_planned'
+	StateReason string
+	ClosedAt    *time.Time
+	Labels      []string
+
+	PullRequest bool
+	Merged      bool
+	MergedAt    *time.Time
+	Draft       bool
+	// ReviewRequested is set when a pull request is waiting on a review from someone
+	ReviewRequested bool
+	Base            string
+	Head            string
+	HeadSHA         string
+	MergeCommitSHA  string
+}
@@ -0,0 +1,513 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	j "github.com/andygrunwald/go-jira"
+	"github.com/jirallreadyforthis/lib/jira"
+)
+
+const (
+	MeasureIssues = "issues"
+	MeasurePoints = "points"
+)
+
+// chartWidth is the most characters a bar of an ascii chart takes up
+const chartWidth = 50
+
+// Flow rebuilds how the issues in a sprint or found with a jql query moved through statuses day by day, from their
+// changelogs. The embedded Analytics provides the jira connection and output options
+type Flow struct {
+	Analytics
+	// SprintId, or Board and Sprint, is the sprint to burn down, defaulting to the board's active sprint
+	SprintId int
+	Board    string
+	Sprint   string
+	// StartDate and EndDate, in the format YYYY-MM-DD, limit the days of a cumulative flow. They default to the
+	// last 30 days
+	StartDate string
+	EndDate   string
+	// Measure is whether a burndown counts remaining 'issues' or story 'points'. Points are the issues' current story
+	// points, changes to estimates during the sprint aren't replayed
+	Measure     string
+	PointsField string
+	// Chart adds an ascii chart to text output
+	Chart bool
+}
+
+// issueHistory is the state of an issue over time, rebuilt from its changelog
+type issueHistory struct {
+	key           string
+	created       time.Time
+	points        float64
+	initialStatus string
+	statuses      []statusChange
+	// initialInSprint is whether the issue was in the sprint before its first sprint change
+	initialInSprint bool
+	sprints         []sprintChange
+}
+
+type sprintChange struct {
+	at time.Time
+	in bool
+}
+
+type burndownDay struct {
+	Date      string  `json:"date"`
+	Remaining float64 `json:"remaining"`
+	Ideal     float64 `json:"ideal"`
+}
+
+type flowDay struct {
+	Date   string         `json:"date"`
+	Counts map[string]int `json:"counts"`
+}
+
+func (f Flow) project() jira.Project {
+	return jira.Project{Token: f.JiraToken, UserName: f.UserName, JiraUrl: f.JiraUrl}
+}
+
+// Burndown reports the issues or story points left to do in a sprint at the end of each of its days
+func (f Flow) Burndown() error {
+	format, err := f.format(FormatText, FormatCSV, FormatJSON)
+	if err != nil {
+		return err
+	}
+	measure := f.Measure
+	if measure == "" {
+		measure = MeasureIssues
+	}
+	if measure != MeasureIssues && measure != MeasurePoints {
+		return fmt.Errorf("unknown measure %q, expected one of %s or %s", measure, MeasureIssues, MeasurePoints)
+	}
+
+	p := f.project()
+	var sprint *jira.Sprint
+	if f.SprintId != 0 {
+		sprint, err = p.GetSprint(f.SprintId)
+	} else {
+		name := f.Sprint
+		if name == "" {
+			name = "active"
+		}
+		sprint, err = resolveSprint(p, f.Board, name)
+	}
+	if err != nil {
+		return err
+	}
+	if sprint.StartDate == nil {
+		return fmt.Errorf("sprint %s (id %d) hasn't started", sprint.Name, sprint.ID)
+	}
+
+	field := ""
+	if measure == MeasurePoints {
+		if field, err = pointsFieldID(f.PointsField, p); err != nil {
+			return err
+		}
+	}
+
+	days, err := f.burndown(sprint, field, time.Now())
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatJSON:
+		out, err := json.MarshalIndent(map[string]interface{}{"sprintId": sprint.ID, "sprint": sprint.Name, "measure": measure, "days": days}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding burndown: %v", err)
+		}
+		fmt.Println(string(out))
+	case FormatCSV:
+		rows := [][]string{{"date", "remaining", "ideal"}}
+		for _, day := range days {
+			rows = append(rows, []string{day.Date, formatPoints(day.Remaining), strconv.FormatFloat(day.Ideal, 'f', 2, 64)})
+		}
+		out, err := writeCSV(rows)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+	default:
+		fmt.Print(burndownText(sprint, measure, days, f.Chart))
+	}
+	return nil
+}
+
+// burndown rebuilds the remaining issues or points, when a points field is given, at the end of each day of a sprint.
+// Issues are counted with their current points
+func (f Flow) burndown(sprint *jira.Sprint, field string, now time.Time) ([]burndownDay, error) {
+	p := f.project()
+	categories, err := f.statusCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	issues, err := p.ListSprintIssues(sprint.ID)
+	if err != nil {
+		return nil, err
+	}
+	inSprint := make(map[string]bool)
+	for _, issue := range issues {
+		inSprint[issue.ID] = true
+	}
+	// issues removed from the sprint aren't in it any more, a jql query can find them to count them while they were
+	if f.Jql != "" {
+		more, err := p.ListIssues(f.Jql)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range more {
+			if !inSprint[issue.ID] {
+				issues = append(issues, issue)
+			}
+		}
+	}
+
+	histories := make([]issueHistory, 0)
+	for _, issue := range issues {
+		h, err := f.history(issue, inSprint[issue.ID], sprint.ID, field)
+		if err != nil {
+			return nil, err
+		}
+		histories = append(histories, h)
+	}
+
+	// a sprint often completes after its planned end, the days until it completes still count. The planned end only
+	// sets the ideal line
+	end := now
+	if sprint.CompleteDate != nil {
+		end = *sprint.CompleteDate
+	} else if sprint.EndDate != nil && sprint.EndDate.Before(end) {
+		end = *sprint.EndDate
+	}
+
+	remaining := func(at time.Time) float64 {
+		total := 0.0
+		for _, h := range histories {
+			// an issue created straight into the sprint has no sprint change, it joins the sprint when it's created
+			if h.created.After(at) || !h.inSprintAt(at) || categories[strings.ToLower(h.statusAt(at))] == "done" {
+				continue
+			}
+			if field == "" {
+				total++
+			} else {
+				total += h.points
+			}
+		}
+		return total
+	}
+
+	start := remaining(*sprint.StartDate)
+	ends := dayEnds(*sprint.StartDate, end)
+	sprintEnd := end
+	if sprint.EndDate != nil {
+		sprintEnd = *sprint.EndDate
+	}
+	length := sprintEnd.Sub(*sprint.StartDate).Hours()
+
+	days := make([]burndownDay, 0)
+	for _, at := range ends {
+		ideal := start
+		if length > 0 {
+			ideal = math.Max(0, start*(1-at.Sub(*sprint.StartDate).Hours()/length))
+		}
+		days = append(days, burndownDay{Date: formatDate(&at), Remaining: remaining(at), Ideal: ideal})
+	}
+	return days, nil
+}
+
+// CumulativeFlow reports the number of issues in each status at the end of each day
+func (f Flow) CumulativeFlow() error {
+	format, err := f.format(FormatText, FormatCSV, FormatJSON)
+	if err != nil {
+		return err
+	}
+	if f.Jql == "" {
+		return fmt.Errorf("a jql query is required")
+	}
+
+	now := time.Now()
+	start := now.AddDate(0, 0, -30)
+	if parsed, err := parseDate(f.StartDate); err != nil {
+		return err
+	} else if parsed != nil {
+		start = *parsed
+	}
+	end := now
+	if parsed, err := parseDate(f.EndDate); err != nil {
+		return err
+	} else if parsed != nil && parsed.Before(now) {
+		end = parsed.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	if !end.After(start) {
+		return fmt.Errorf("the end date must be after the start date")
+	}
+
+	statuses, days, err := f.cumulativeFlow(start, end)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatJSON:
+		out, err := json.MarshalIndent(map[string]interface{}{"statuses": statuses, "days": days}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding cumulative flow: %v", err)
+		}
+		fmt.Println(string(out))
+	case FormatCSV:
+		rows := [][]string{append([]string{"date"}, statuses...)}
+		for _, day := range days {
+			row := []string{day.Date}
+			for _, status := range statuses {
+				row = append(row, strconv.Itoa(day.Counts[status]))
+			}
+			rows = append(rows, row)
+		}
+		out, err := writeCSV(rows)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+	default:
+		fmt.Print(cumulativeFlowText(statuses, days, f.Chart))
+	}
+	return nil
+}
+
+// cumulativeFlow rebuilds the number of issues in each status at the end of each day, returning the statuses in
+// workflow order
+func (f Flow) cumulativeFlow(start time.Time, end time.Time) ([]string, []flowDay, error) {
+	categories, err := f.statusCategories()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	issues, err := f.tracker().ListIssues(f.Jql)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	histories := make([]issueHistory, 0)
+	seen := make(map[string]bool)
+	statuses := make([]string, 0)
+	for _, issue := range issues {
+		h, err := f.history(issue, false, 0, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		histories = append(histories, h)
+
+		names := []string{h.initialStatus}
+		for _, change := range h.statuses {
+			names = append(names, change.to)
+		}
+		for _, name := range names {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				statuses = append(statuses, name)
+			}
+		}
+	}
+	sort.SliceStable(statuses, func(i, k int) bool {
+		ri, rk := categoryRank(categories[strings.ToLower(statuses[i])]), categoryRank(categories[strings.ToLower(statuses[k])])
+		if ri != rk {
+			return ri < rk
+		}
+		return statuses[i] < statuses[k]
+	})
+
+	days := make([]flowDay, 0)
+	for _, at := range dayEnds(start, end) {
+		day := flowDay{Date: formatDate(&at), Counts: make(map[string]int)}
+		for _, status := range statuses {
+			day.Counts[status] = 0
+		}
+		for _, h := range histories {
+			if h.created.After(at) {
+				continue
+			}
+			day.Counts[h.statusAt(at)]++
+		}
+		days = append(days, day)
+	}
+	return statuses, days, nil
+}
+
+// history rebuilds the status and sprint membership of an issue over time from its changelog
+func (f Flow) history(issue j.Issue, inSprint bool, sprintId int, field string) (issueHistory, error) {
+	withChangelog, err := f.tracker().GetIssueWithChangeLog(issue.ID)
+	if err != nil {
+		return issueHistory{}, fmt.Errorf("retrieving issueId %s with changelog: %v", issue.ID, err)
+	}
+
+	h := issueHistory{key: issue.Key, initialInSprint: inSprint}
+	if field != "" {
+		h.points = storyPoints(issue, field)
+	}
+	if withChangelog.Fields != nil {
+		h.created = time.Time(withChangelog.Fields.Created)
+		if withChangelog.Fields.Status != nil {
+			h.initialStatus = withChangelog.Fields.Status.Name
+		}
+	}
+
+	if h.statuses, err = statusChanges(withChangelog); err != nil {
+		return h, err
+	}
+	if len(h.statuses) > 0 {
+		h.initialStatus = h.statuses[0].from
+	}
+
+	if sprintId == 0 || withChangelog.Changelog == nil {
+		return h, nil
+	}
+	sprint := strconv.Itoa(sprintId)
+	for _, history := range withChangelog.Changelog.Histories {
+		created, err := time.Parse(jiraTimeFormat, history.Created)
+		if err != nil {
+			return h, fmt.Errorf("parsing changelog time %q on issue %s: %v", history.Created, issue.Key, err)
+		}
+		for _, item := range history.Items {
+			if !strings.EqualFold(item.Field, "sprint") {
+				continue
+			}
+			h.sprints = append(h.sprints, sprintChange{at: created, in: containsString(splitIds(item.To), sprint)})
+			if len(h.sprints) == 1 {
+				h.initialInSprint = containsString(splitIds(item.From), sprint)
+			}
+		}
+	}
+	sort.SliceStable(h.sprints, func(i, k int) bool { return h.sprints[i].at.Before(h.sprints[k].at) })
+	return h, nil
+}
+
+func (h issueHistory) statusAt(at time.Time) string {
+	status := h.initialStatus
+	for _, change := range h.statuses {
+		if change.at.After(at) {
+			break
+		}
+		status = change.to
+	}
+	return status
+}
+
+func (h issueHistory) inSprintAt(at time.Time) bool {
+	in := h.initialInSprint
+	for _, change := range h.sprints {
+		if change.at.After(at) {
+			break
+		}
+		in = change.in
+	}
+	return in
+}
+
+// dayEnds returns the end of each day from start to end, the last day ends at end
+func dayEnds(start time.Time, end time.Time) []time.Time {
+	ends := make([]time.Time, 0)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	for !day.After(end) {
+		next := day.AddDate(0, 0, 1)
+		at := next.Add(-time.Nanosecond)
+		if at.After(end) {
+			at = end
+		}
+		ends = append(ends, at)
+		day = next
+	}
+	return ends
+}
+
+func writeCSV(rows [][]string) (string, error) {
+	b := &strings.Builder{}
+	w := csv.NewWriter(b)
+	if err := w.WriteAll(rows); err != nil {
+		return "", fmt.Errorf("writing csv: %v", err)
+	}
+	return b.String(), nil
+}
+
+func burndownText(sprint *jira.Sprint, measure string, days []burndownDay, chart bool) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "\nBurndown of sprint %s (id %d), remaining %s\n", sprint.Name, sprint.ID, measure)
+
+	most := 0.0
+	for _, day := range days {
+		most = math.Max(most, math.Max(day.Remaining, day.Ideal))
+	}
+	for _, day := range days {
+		fmt.Fprintf(b, "  %s\t%s\tideal %s", day.Date, formatPoints(day.Remaining), strconv.FormatFloat(day.Ideal, 'f', 1, 64))
+		if chart {
+			bar := []rune(strings.Repeat("#", scaled(day.Remaining, most)) + strings.Repeat(" ", chartWidth+1-scaled(day.Remaining, most)))
+			if ideal := scaled(day.Ideal, most); bar[ideal] == ' ' {
+				bar[ideal] = '|'
+			}
+			fmt.Fprintf(b, "\t%s", strings.TrimRight(string(bar), " "))
+		}
+		fmt.Fprintln(b)
+	}
+	if chart {
+		fmt.Fprintf(b, "\n  # remaining, | ideal\n")
+	}
+	return b.String()
+}
+
+// flowChars mark the statuses in a cumulative flow chart, in workflow order
+const flowChars = "#=+*o%@x.~"
+
+func cumulativeFlowText(statuses []string, days []flowDay, chart bool) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "\nCumulative flow\n  date\t\t%s\n", strings.Join(statuses, "\t"))
+
+	most := 0
+	for _, day := range days {
+		total := 0
+		for _, count := range day.Counts {
+			total += count
+		}
+		if total > most {
+			most = total
+		}
+	}
+
+	for _, day := range days {
+		counts := make([]string, 0)
+		bar := ""
+		for i, status := range statuses {
+			counts = append(counts, strconv.Itoa(day.Counts[status]))
+			bar += strings.Repeat(string(flowChars[i%len(flowChars)]), scaled(float64(day.Counts[status]), float64(most)))
+		}
+		fmt.Fprintf(b, "  %s\t%s", day.Date, strings.Join(counts, "\t"))
+		if chart {
+			fmt.Fprintf(b, "\t%s", bar)
+		}
+		fmt.Fprintln(b)
+	}
+
+	if chart {
+		legend := make([]string, 0)
+		for i, status := range statuses {
+			legend = append(legend, fmt.Sprintf("%c %s", flowChars[i%len(flowChars)], status))
+		}
+		fmt.Fprintf(b, "\n  %s\n", strings.Join(legend, ", "))
+	}
+	return b.String()
+}
+
+// scaled is the length of a bar for value in a chart whose longest bar is for most
+func scaled(value float64, most float64) int {
+	if most <= 0 || value <= 0 {
+		return 0
+	}
+	return int(math.Round(value / most * chartWidth))
+}
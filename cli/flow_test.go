@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/jira"
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestBurndown(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC)
+	at := func(day int, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC) }
+	sprintChange := func(at time.Time, from string, to string) jiratest.History {
+		return jiratest.History{Author: "someone", Created: at, Items: []jiratest.HistoryItem{{Field: "Sprint", FromID: from, ToID: to}}}
+	}
+	points := func(n float64) map[string]interface{} { return map[string]interface{}{"customfield_10016": n} }
+
+	s := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "Done", StatusCategory: "done", SprintID: 7, Created: at(1, 9), CustomFields: points(3), Changelog: []jiratest.History{
+				{Author: "someone", Created: at(3, 12), Items: []jiratest.HistoryItem{{Field: "status", From: "To Do", To: "Done"}}},
+			}},
+			{Key: "IPL-2", Status: "In Progress", StatusCategory: "indeterminate", SprintID: 7, Created: at(1, 9), CustomFields: points(5)},
+			{Key: "IPL-3", Status: "To Do", SprintID: 7, Created: at(1, 9), CustomFields: points(2), Changelog: []jiratest.History{
+				sprintChange(at(4, 10), "", "7"),
+			}},
+			{Key: "IPL-5", Status: "To Do", SprintID: 7, Created: at(4, 15), CustomFields: points(4)},
+			{Key: "IPL-4", Status: "To Do", Created: at(1, 9), CustomFields: points(1), Changelog: []jiratest.History{
+				sprintChange(at(3, 10), "6,7", "6"),
+			}},
+		},
+		Queries:          map[string][]string{"project = IPL": {"IPL-1", "IPL-2", "IPL-3", "IPL-4", "IPL-5"}},
+		StatusCategories: map[string]string{"To Do": "new", "In Progress": "indeterminate", "Done": "done"},
+		Sprints:          []jiratest.Sprint{{ID: 7, BoardID: 1, Name: "Sprint 41", State: "active", StartDate: &start, EndDate: &end}},
+	})
+	defer s.Close()
+
+	f := Flow{Analytics: Analytics{List: List{JiraUrl: s.URL, Jql: "project = IPL"}}}
+	sprint := &jira.Sprint{}
+	sprint.ID, sprint.Name, sprint.StartDate, sprint.EndDate = 7, "Sprint 41", &start, &end
+
+	days, err := f.burndown(sprint, "", at(10, 9))
+	if err != nil {
+		t.Fatalf("building burndown: %v", err)
+	}
+	expected := []float64{3, 1, 3, 3, 3}
+	if len(days) != len(expected) {
+		t.Fatalf("expected a day for each day of the sprint, got %+v", days)
+	}
+	for i, remaining := range expected {
+		if days[i].Remaining != remaining {
+			t.Errorf("expected %v issues remaining on %s, got %v", remaining, days[i].Date, days[i].Remaining)
+		}
+	}
+	if days[0].Date != "2026-03-02" || days[4].Ideal != 0 || days[0].Ideal >= 3 {
+		t.Errorf("expected the ideal line to fall from 3 to 0 from 2026-03-02, got %+v", days)
+	}
+
+	days, err = f.burndown(sprint, "customfield_10016", at(10, 9))
+	if err != nil {
+		t.Fatalf("building burndown of points: %v", err)
+	}
+	expected = []float64{9, 5, 11, 11, 11}
+	for i, remaining := range expected {
+		if days[i].Remaining != remaining {
+			t.Errorf("expected %v points remaining on %s, got %v", remaining, days[i].Date, days[i].Remaining)
+		}
+	}
+
+	out := burndownText(sprint, MeasurePoints, days, true)
+	if !strings.Contains(out, "2026-03-02\t9") || !strings.Contains(out, strings.Repeat("#", chartWidth)) {
+		t.Errorf("expected a chart whose longest bar is the first day, got %q", out)
+	}
+
+	// a sprint completed after its planned end runs until it completes, the ideal line still ends on the planned end
+	completed := at(9, 12)
+	sprint.CompleteDate = &completed
+	days, err = f.burndown(sprint, "", at(10, 9))
+	if err != nil {
+		t.Fatalf("building burndown of a late sprint: %v", err)
+	}
+	if len(days) != 8 || days[7].Date != "2026-03-09" || days[7].Remaining != 3 || days[4].Ideal != 0 {
+		t.Errorf("expected the burndown to run to 2026-03-09 with the ideal line done on 2026-03-06, got %+v", days)
+	}
+}
+
+func TestCumulativeFlow(t *testing.T) {
+	at := func(day int, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC) }
+	moved := func(at time.Time, from string, to string) jiratest.History {
+		return jiratest.History{Author: "someone", Created: at, Items: []jiratest.HistoryItem{{Field: "status", From: from, To: to}}}
+	}
+
+	s := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "Done", StatusCategory: "done", Created: at(1, 9), Changelog: []jiratest.History{
+				moved(at(2, 10), "To Do", "In Progress"),
+				moved(at(3, 10), "In Progress", "Done"),
+			}},
+			{Key: "IPL-2", Status: "In Progress", StatusCategory: "indeterminate", Created: at(2, 9), Changelog: []jiratest.History{
+				moved(at(3, 10), "To Do", "In Progress"),
+			}},
+			{Key: "IPL-3", Status: "To Do", Created: at(5, 9)},
+		},
+		Queries:          map[string][]string{"project = IPL": {"IPL-1", "IPL-2", "IPL-3"}},
+		StatusCategories: map[string]string{"To Do": "new", "In Progress": "indeterminate", "Done": "done"},
+	})
+	defer s.Close()
+
+	f := Flow{Analytics: Analytics{List: List{JiraUrl: s.URL, Jql: "project = IPL"}}}
+	statuses, days, err := f.cumulativeFlow(at(1, 0), at(4, 12))
+	if err != nil {
+		t.Fatalf("building cumulative flow: %v", err)
+	}
+	if strings.Join(statuses, ",") != "To Do,In Progress,Done" {
+		t.Errorf("expected statuses in workflow order, got %v", statuses)
+	}
+
+	expected := []map[string]int{
+		{"To Do": 1, "In Progress": 0, "Done": 0},
+		{"To Do": 1, "In Progress": 1, "Done": 0},
+		{"To Do": 0, "In Progress": 1, "Done": 1},
+		{"To Do": 0, "In Progress": 1, "Done": 1},
+	}
+	if len(days) != len(expected) {
+		t.Fatalf("expected 4 days, got %+v", days)
+	}
+	for i, counts := range expected {
+		for status, count := range counts {
+			if days[i].Counts[status] != count {
+				t.Errorf("expected %d issues in %s on %s, got %d", count, status, days[i].Date, days[i].Counts[status])
+			}
+		}
+	}
+
+	out := cumulativeFlowText(statuses, days, true)
+	if !strings.Contains(out, "# To Do, = In Progress, + Done") {
+		t.Errorf("expected a legend for the chart, got %q", out)
+	}
+}
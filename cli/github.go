@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jirallreadyforthis/lib/gh"
+)
+
+var githubLinkRe = regexp.MustCompile("https://github\\.com/(?P<repoName>[\\w-]+/[\\w-]+)/(?:pull|issues)/(?P<number>\\d+)")
+
+// githubHost is the github CodeHost, links are resolved through the github api
+type githubHost struct {
+	Token   string
+	BaseURL string
+}
+
+func (g githubHost) FindLinks(text string) []string {
+	return findGithubLinks(text)
+}
+
+func (g githubHost) Repo(link string) string {
+	matches := githubLinkRe.FindStringSubmatch(link)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[githubLinkRe.SubexpIndex("repoName")]
+}
+
+func (g githubHost) repo(item *LinkedItem) gh.Repo {
+	repo := gh.NewRepo(item.Repo, g.Token)
+	repo.BaseURL = g.BaseURL
+	return repo
+}
+
+func (g githubHost) GetItem(link string) (*LinkedItem, error) {
+	matches := githubLinkRe.FindStringSubmatch(link)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%s is not a github issue or pull request link", link)
+	}
+	number, _ := strconv.Atoi(matches[githubLinkRe.SubexpIndex("number")])
+
+	item := &LinkedItem{
+		Repo:   matches[githubLinkRe.SubexpIndex("repoName")],
+		Number: number,
+	}
+	repo := g.repo(item)
+
+	// pull requests are also returned by the issues api, so this works for both kinds of link
+	issue, err := repo.GetIssue(number)
+	if err != nil {
+		return nil, err
+	}
+	item.URL = issue.GetHTMLURL()
+	item.Title = issue.GetTitle()
+	item.State = issue.GetState()
+	item.StateReason = gh.CloseReason(issue)
+	item.ClosedAt = issue.ClosedAt.GetTime()
+	for _, label := range issue.Labels {
+		item.Labels = append(item.Labels, label.GetName())
+	}
+
+	if !issue.IsPullRequest() {
+		return item, nil
+	}
+
+	pr, err := repo.GetPullRequest(number)
+	if err != nil {
+		return nil, err
+	}
+	item.PullRequest = true
+	item.StateReason = ""
+	item.Merged = pr.GetMerged()
+	item.MergedAt = pr.MergedAt.GetTime()
+	item.Draft = pr.GetDraft()
+	item.ReviewRequested = len(pr.RequestedReviewers) > 0 || len(pr.RequestedTeams) > 0
+	item.Base = pr.GetBase().GetRef()
+	item.Head = pr.GetHead().GetRef()
+	item.HeadSHA = pr.GetHead().GetSHA()
+	item.MergeCommitSHA = pr.GetMergeCommitSHA()
+
+	return item, nil
+}
+
+// githubReviews maps github's review decisions to the CodeHost ones, github's check states are already the same
+var githubReviews = map[string]string{
+	gh.ReviewApproved:         ReviewApproved,
+	gh.ReviewChangesRequested: ReviewChangesRequested,
+	gh.ReviewRequired:         ReviewRequired,
+	gh.ReviewCommented:        ReviewCommented,
+}
+
+func (g githubHost) ReviewDecision(item *LinkedItem) (string, error) {
+	decision, err := g.repo(item).GetReviewDecision(item.Number)
+	return githubReviews[decision], err
+}
+
+func (g githubHost) ChecksState(item *LinkedItem) (string, error) {
+	return g.repo(item).GetChecksState(item.HeadSHA)
+}
+
+func (g githubHost) ReleaseContaining(item *LinkedItem) (string, error) {
+	return g.repo(item).FindReleaseContainingCommit(item.MergeCommitSHA, item.Base, item.MergedAt)
+}
+
+func (g githubHost) CloseIssue(item *LinkedItem) error {
+	return g.repo(item).CloseIssue(item.Number, gh.CloseReasonCompleted)
+}
+
+func (g githubHost) AddLabels(item *LinkedItem, labels []string) error {
+	return g.repo(item).AddLabels(item.Number, labels)
+}
+
+func (g githubHost) RemoveLabel(item *LinkedItem, label string) error {
+	return g.repo(item).RemoveLabel(item.Number, label)
+}
+
+func (g githubHost) ListComments(item *LinkedItem) ([]string, error) {
+	comments, err := g.repo(item).ListComments(item.Number)
+	if err != nil {
+		return nil, err
+	}
+	bodies := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		bodies = append(bodies, comment.GetBody())
+	}
+	return bodies, nil
+}
+
+func (g githubHost) AddComment(item *LinkedItem, body string) error {
+	return g.repo(item).CreateComment(item.Number, body)
+}
+
+func findGithubLinks(text string) []string {
+	re := regexp.MustCompile("https://github\\.com/[\\w-]+/[\\w-]+/(?:pull|issues)/\\d+")
+	matches := re.FindAllString(text, -1)
+
+	links := make([]string, 0)
+	for _, match := range matches {
+		match = strings.Split(match, "|")[0]
+		links = append(links, match)
+	}
+
+	return links
+}
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	j "github.com/andygrunwald/go-jira"
+	c "github.com/gookit/color"
+)
+
+const (
+	GithubActionClose   = "close"
+	GithubActionLabels  = "labels"
+	GithubActionComment = "comment"
+)
+
+// GithubSync updates the github issues linked from jira issues to reflect the state of the jira issues.
+// The embedded List provides the link discovery options
+type GithubSync struct {
+	List
+	DryRun bool
+	// Actions are the changes to make to linked github issues, any of 'close', 'labels' or 'comment'
+	Actions []string
+	// LabelPrefix is added to the jira status to make the label mirroring it eg 'jira: in review'
+	LabelPrefix string
+}
+
+func (g GithubSync) Sync() error {
+	if g.Jql == "" {
+		return fmt.Errorf("a jql query is required to sync github issues")
+	}
+	if len(g.Actions) == 0 {
+		return fmt.Errorf("at least one github action is required, any of %s, %s or %s", GithubActionClose, GithubActionLabels, GithubActionComment)
+	}
+	for _, action := range g.Actions {
+		if action != GithubActionClose && action != GithubActionLabels && action != GithubActionComment {
+			return fmt.Errorf("unknown github action %q, expected any of %s, %s or %s", action, GithubActionClose, GithubActionLabels, GithubActionComment)
+		}
+	}
+
+	p := g.tracker()
+	issues, err := p.ListIssues(g.Jql)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, issue := range issues {
+		issueWithComments, err := p.GetIssue(issue.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, link := range g.findLinks(issue, issueWithComments) {
+			item, host := g.getLinkedItem(link)
+			// pull requests are left alone
+			if item == nil || item.PullRequest {
+				continue
+			}
+			editor, ok := host.(IssueEditor)
+			if !ok {
+				warnf("skipping %s as issues on its host can't be updated", item.URL)
+				continue
+			}
+
+			changed, err := g.syncIssue(issue, editor, item)
+			if err != nil {
+				return err
+			}
+			if changed {
+				count++
+			}
+		}
+	}
+
+	c.Info.Printf("\nFinished updating %d github issues\n", count)
+
+	return nil
+}
+
+// syncIssue applies the actions to a linked issue, returning whether anything needed changing
+func (g GithubSync) syncIssue(issue j.Issue, editor IssueEditor, item *LinkedItem) (bool, error) {
+	status := ""
+	done := false
+	if issue.Fields.Status != nil {
+		status = issue.Fields.Status.Name
+		done = issue.Fields.Status.StatusCategory.Key == "done"
+	}
+	changed := false
+
+	if containsString(g.Actions, GithubActionClose) && done && item.State == "open" {
+		fmt.Printf("closing %s as %s is %s\n", item.URL, issue.Key, status)
+		if !g.DryRun {
+			if err := editor.CloseIssue(item); err != nil {
+				return changed, err
+			}
+		}
+		changed = true
+	}
+
+	if containsString(g.Actions, GithubActionLabels) && status != "" {
+		label := g.LabelPrefix + strings.ToLower(status)
+		hasLabel := false
+		for _, name := range item.Labels {
+			if strings.EqualFold(name, label) {
+				hasLabel = true
+				continue
+			}
+			// only labels mirroring a previous jira status are removed
+			if g.LabelPrefix != "" && strings.HasPrefix(strings.ToLower(name), strings.ToLower(g.LabelPrefix)) {
+				fmt.Printf("removing label %q from %s\n", name, item.URL)
+				if !g.DryRun {
+					if err := editor.RemoveLabel(item, name); err != nil {
+						return changed, err
+					}
+				}
+				changed = true
+			}
+		}
+		if !hasLabel {
+			fmt.Printf("adding label %q to %s\n", label, item.URL)
+			if !g.DryRun {
+				if err := editor.AddLabels(item, []string{label}); err != nil {
+					return changed, err
+				}
+			}
+			changed = true
+		}
+	}
+
+	if containsString(g.Actions, GithubActionComment) {
+		body := g.statusComment(issue)
+		last, err := g.lastStatusComment(editor, item, issue.Key)
+		if err != nil {
+			return changed, err
+		}
+		// only comment when something has changed since the last comment
+		if last != body {
+			fmt.Printf("commenting on %s with the status of %s\n", item.URL, issue.Key)
+			if !g.DryRun {
+				if err := editor.AddComment(item, body); err != nil {
+					return changed, err
+				}
+			}
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+// statusCommentMarker is hidden in status comments so later syncs can find them
+func statusCommentMarker(issueKey string) string {
+	return fmt.Sprintf("<!-- jirallreadyforthis:%s -->", issueKey)
+}
+
+func (g GithubSync) statusComment(issue j.Issue) string {
+	status := "unknown"
+	if issue.Fields.Status != nil {
+		status = issue.Fields.Status.Name
+	}
+	assignee := "nobody"
+	if issue.Fields.Assignee != nil {
+		assignee = issue.Fields.Assignee.DisplayName
+	}
+
+	return fmt.Sprintf("Jira issue [%s](%s) is **%s** and assigned to %s\n\n%s", issue.Key, g.getJiraHtmlUrl(issue.Key), status, assignee, statusCommentMarker(issue.Key))
+}
+
+// lastStatusComment returns the body of the most recent status comment for a jira issue, or "" if there isn't one
+func (g GithubSync) lastStatusComment(editor IssueEditor, item *LinkedItem, issueKey string) (string, error) {
+	comments, err := editor.ListComments(item)
+	if err != nil {
+		return "", err
+	}
+
+	last := ""
+	for _, body := range comments {
+		if strings.Contains(body, statusCommentMarker(issueKey)) {
+			last = body
+		}
+	}
+	return last, nil
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jirallreadyforthis/lib/gh/ghtest"
+	"github.com/jirallreadyforthis/lib/jira"
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestGithubSync(t *testing.T) {
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "Done", StatusCategory: "done", Assignee: "Sam", Description: "https://github.com/acme/app/issues/1"},
+			{Key: "IPL-2", Status: "In Review", StatusCategory: "indeterminate", Description: "https://github.com/acme/app/issues/2 https://github.com/acme/app/pull/3"},
+		},
+	})
+	defer jiraServer.Close()
+
+	ghServer := ghtest.NewServer(ghtest.Seed{
+		Issues: []ghtest.Issue{
+			{Repo: "acme/app", Number: 1, State: "open", Labels: []string{"bug", "jira: in review"}},
+			{Repo: "acme/app", Number: 2, State: "open", Labels: []string{"bug"}},
+			{Repo: "acme/app", Number: 3, State: "open", Pull: &ghtest.Pull{Base: "main"}},
+		},
+	})
+	defer ghServer.Close()
+
+	g := GithubSync{
+		List: List{
+			JiraUrl:  jiraServer.URL,
+			GHApiUrl: ghServer.URL,
+			Jql:      "project = IPL",
+		},
+		Actions:     []string{GithubActionClose, GithubActionLabels, GithubActionComment},
+		LabelPrefix: "jira: ",
+		DryRun:      true,
+	}
+
+	captureOutput(t, g.Sync)
+	if issue := ghServer.Issue("acme/app", 1); issue.State != "open" || len(issue.Comments) != 0 {
+		t.Fatalf("expected a dry run to leave acme/app#1 alone, got %+v", issue)
+	}
+
+	g.DryRun = false
+	captureOutput(t, g.Sync)
+
+	first := ghServer.Issue("acme/app", 1)
+	if first.State != "closed" || first.StateReason != "completed" {
+		t.Errorf("expected acme/app#1 to be closed as completed, got %s %s", first.State, first.StateReason)
+	}
+	if labels := strings.Join(first.Labels, ","); labels != "bug,jira: done" {
+		t.Errorf("expected acme/app#1 to have labels bug,jira: done, got %s", labels)
+	}
+	if len(first.Comments) != 1 || !strings.Contains(first.Comments[0], "is **Done** and assigned to Sam") {
+		t.Errorf("expected a status comment on acme/app#1, got %v", first.Comments)
+	}
+
+	second := ghServer.Issue("acme/app", 2)
+	if second.State != "open" {
+		t.Errorf("expected acme/app#2 to stay open, got %s", second.State)
+	}
+	if labels := strings.Join(second.Labels, ","); labels != "bug,jira: in review" {
+		t.Errorf("expected acme/app#2 to have labels bug,jira: in review, got %s", labels)
+	}
+
+	if pr := ghServer.Issue("acme/app", 3); len(pr.Labels) != 0 || len(pr.Comments) != 0 {
+		t.Errorf("expected the linked pull request to be left alone, got %+v", pr)
+	}
+
+	// nothing has changed in jira so a second sync doesn't comment again
+	captureOutput(t, g.Sync)
+	if comments := ghServer.Issue("acme/app", 1).Comments; len(comments) != 1 {
+		t.Errorf("expected no new status comment, got %d comments", len(comments))
+	}
+}
+
+// editableHost is a stubHost whose issues can be updated, the changes are made to the items themselves
+type editableHost struct {
+	stubHost
+	comments map[string][]string
+}
+
+func (e editableHost) CloseIssue(item *LinkedItem) error {
+	item.State = "closed"
+	return nil
+}
+
+func (e editableHost) AddLabels(item *LinkedItem, labels []string) error {
+	item.Labels = append(item.Labels, labels...)
+	return nil
+}
+
+func (e editableHost) RemoveLabel(item *LinkedItem, label string) error {
+	labels := make([]string, 0)
+	for _, l := range item.Labels {
+		if l != label {
+			labels = append(labels, l)
+		}
+	}
+	item.Labels = labels
+	return nil
+}
+
+func (e editableHost) ListComments(item *LinkedItem) ([]string, error) {
+	return e.comments[item.URL], nil
+}
+
+func (e editableHost) AddComment(item *LinkedItem, body string) error {
+	e.comments[item.URL] = append(e.comments[item.URL], body)
+	return nil
+}
+
+// elsewhereHost is a stubHost for links to elsewhere.example
+type elsewhereHost struct {
+	stubHost
+}
+
+func (e elsewhereHost) FindLinks(text string) []string {
+	return regexp.MustCompile(`https://elsewhere\.example/[\w-]+/[\w-]+/\d+`).FindAllString(text, -1)
+}
+
+func TestGithubSyncCodeHost(t *testing.T) {
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "Done", StatusCategory: "done", Description: "https://code.example/acme/app/1 https://code.example/acme/app/2"},
+			{Key: "IPL-2", Status: "Done", StatusCategory: "done", Description: "https://elsewhere.example/acme/app/3"},
+		},
+	})
+	defer jiraServer.Close()
+
+	issue := &LinkedItem{URL: "https://code.example/acme/app/1", Repo: "acme/app", Number: 1, State: "open", Labels: []string{"jira: in review"}}
+	pr := &LinkedItem{URL: "https://code.example/acme/app/2", Repo: "acme/app", Number: 2, State: "open", PullRequest: true}
+	readOnly := &LinkedItem{URL: "https://elsewhere.example/acme/app/3", Repo: "acme/app", Number: 3, State: "open"}
+	host := editableHost{
+		stubHost: stubHost{items: map[string]*LinkedItem{issue.URL: issue, pr.URL: pr}},
+		comments: make(map[string][]string),
+	}
+
+	g := GithubSync{
+		List: List{
+			Tracker: jira.Project{JiraUrl: jiraServer.URL},
+			Hosts:   []CodeHost{host, elsewhereHost{stubHost{items: map[string]*LinkedItem{readOnly.URL: readOnly}}}},
+			Jql:     "project = IPL",
+		},
+		Actions:     []string{GithubActionClose, GithubActionLabels, GithubActionComment},
+		LabelPrefix: "jira: ",
+	}
+
+	out := captureOutput(t, g.Sync)
+	if issue.State != "closed" || strings.Join(issue.Labels, ",") != "jira: done" || len(host.comments[issue.URL]) != 1 {
+		t.Errorf("expected the linked issue to be closed, relabelled and commented on, got %+v %v", issue, host.comments)
+	}
+	if pr.State != "open" || len(pr.Labels) != 0 || len(host.comments[pr.URL]) != 0 {
+		t.Errorf("expected the linked pull request to be left alone, got %+v", pr)
+	}
+	if readOnly.State != "open" {
+		t.Errorf("expected the issue on a host that can't update issues to be left alone, got %+v", readOnly)
+	}
+	if !strings.Contains(out, "Finished updating 1 github issues") {
+		t.Errorf("expected one issue to be updated, got %q", out)
+	}
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/jirallreadyforthis/lib/gh"
+	"github.com/jirallreadyforthis/lib/gitlab"
+)
+
+// gitlab links have a '/-/' before the kind of item, so they can be recognised on self-hosted instances too
+var gitlabLinkRe = regexp.MustCompile("(?P<baseURL>https?://[\\w.-]+(?::\\d+)?)/(?P<project>(?:[\\w.-]+/)+[\\w.-]+)/-/(?P<kind>merge_requests|issues)/(?P<number>\\d+)")
+
+// gitlabHost is the gitlab CodeHost, merge requests are treated as pull requests
+type gitlabHost struct {
+	Token string
+}
+
+func (g gitlabHost) FindLinks(text string) []string {
+	return gitlabLinkRe.FindAllString(text, -1)
+}
+
+func (g gitlabHost) Repo(link string) string {
+	matches := gitlabLinkRe.FindStringSubmatch(link)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[gitlabLinkRe.SubexpIndex("project")]
+}
+
+func (g gitlabHost) project(link string) (gitlab.Project, string, int, error) {
+	matches := gitlabLinkRe.FindStringSubmatch(link)
+	if len(matches) == 0 {
+		return gitlab.Project{}, "", 0, fmt.Errorf("%s is not a gitlab issue or merge request link", link)
+	}
+	number, _ := strconv.Atoi(matches[gitlabLinkRe.SubexpIndex("number")])
+
+	p := gitlab.NewProject(matches[gitlabLinkRe.SubexpIndex("baseURL")], matches[gitlabLinkRe.SubexpIndex("project")], g.Token)
+	return p, matches[gitlabLinkRe.SubexpIndex("kind")], number, nil
+}
+
+func (g gitlabHost) GetItem(link string) (*LinkedItem, error) {
+	p, kind, number, err := g.project(link)
+	if err != nil {
+		return nil, err
+	}
+
+	if kind == "issues" {
+		issue, err := p.GetIssue(number)
+		if err != nil {
+			return nil, err
+		}
+		item := &LinkedItem{
+			URL:      issue.WebURL,
+			Repo:     p.Path,
+			Number:   issue.IID,
+			Title:    issue.Title,
+			State:    "open",
+			ClosedAt: issue.ClosedAt,
+		}
+		// gitlab doesn't record why an issue was closed
+		if issue.State == gitlab.StateClosed {
+			item.State = "closed"
+			item.StateReason = gh.CloseReasonCompleted
+		}
+		return item, nil
+	}
+
+	mr, err := p.GetMergeRequest(number)
+	if err != nil {
+		return nil, err
+	}
+	item := &LinkedItem{
+		URL:         mr.WebURL,
+		Repo:        p.Path,
+		Number:      mr.IID,
+		Title:       mr.Title,
+		State:       "open",
+		ClosedAt:    mr.ClosedAt,
+		PullRequest: true,
+		Merged:      mr.State == gitlab.StateMerged,
+		MergedAt:    mr.MergedAt,
+		Draft:       mr.Draft,
+		// gitlab doesn't drop reviewers once they have reviewed, so this also covers reviewed merge requests
+		ReviewRequested: len(mr.Reviewers) > 0,
+		Base:            mr.TargetBranch,
+		Head:            mr.SourceBranch,
+		HeadSHA:         mr.SHA,
+		MergeCommitSHA:  mr.MergeCommitSHA,
+	}
+	if item.MergeCommitSHA == "" {
+		item.MergeCommitSHA = mr.SquashCommitSHA
+	}
+	if mr.State == gitlab.StateMerged || mr.State == gitlab.StateClosed {
+		item.State = "closed"
+	}
+	// merged merge requests have no closed time, the merge time is used for them like it is for github
+	if item.Merged {
+		item.ClosedAt = mr.MergedAt
+	}
+
+	return item, nil
+}
+
+func (g gitlabHost) ReviewDecision(item *LinkedItem) (string, error) {
+	p, _, _, err := g.project(item.URL)
+	if err != nil {
+		return "", err
+	}
+
+	approved, err := p.MergeRequestApproved(item.Number)
+	if err != nil {
+		return "", err
+	}
+	if approved {
+		return ReviewApproved, nil
+	}
+	return ReviewRequired, nil
+}
+
+func (g gitlabHost) ChecksState(item *LinkedItem) (string, error) {
+	p, _, _, err := g.project(item.URL)
+	if err != nil {
+		return "", err
+	}
+
+	mr, err := p.GetMergeRequest(item.Number)
+	if err != nil {
+		return "", err
+	}
+	// like github, a merge request without a pipeline has nothing failing
+	if mr.HeadPipeline == nil {
+		return ChecksSuccess, nil
+	}
+
+	switch mr.HeadPipeline.Status {
+	case "success", "skipped":
+		return ChecksSuccess, nil
+	case "failed", "canceled":
+		return ChecksFailure, nil
+	}
+	return ChecksPending, nil
+}
+
+func (g gitlabHost) ReleaseContaining(item *LinkedItem) (string, error) {
+	p, _, _, err := g.project(item.URL)
+	if err != nil {
+		return "", err
+	}
+	return p.FindReleaseContainingCommit(item.MergeCommitSHA)
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	j "github.com/andygrunwald/go-jira"
+)
+
+const jiraTimeFormat = "2006-01-02T15:04:05.000-0700"
+
+// Guard protects changes people have made to issues by hand from being reverted by automated status changes
+type Guard struct {
+	// Within is how far back manual status changes are respected eg '7d' or '12h'
+	Within string
+	// BotAccounts are the accounts the tool runs as, their changes are never treated as manual. They are matched
+	// against the name, email, account id or display name of the author of a change
+	BotAccounts []string
+	// SkipIfTouchedSinceMerge leaves issues alone that anyone other than a bot changed after their pull request merged
+	SkipIfTouchedSinceMerge bool
+}
+
+// ParseDuration parses a go duration, with the addition of days and weeks eg '7d' or '2w'
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, found := strings.CutSuffix(s, suffix); found {
+			count, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return 0, fmt.Errorf("parsing duration %q: %v", s, err)
+			}
+			return time.Duration(count * float64(unit)), nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("parsing duration %q, expected eg '7d' or '12h': %v", s, err)
+	}
+	return d, nil
+}
+
+// withDefaultBots defaults the bot accounts to the jira user the tool runs as. Jira cloud hides the email of
+// changelog authors, so the user's account id is matched as well as the user name it logs in with
+func (g Guard) withDefaultBots(userName string, p IssueTracker) (Guard, error) {
+	if len(g.BotAccounts) > 0 {
+		return g, nil
+	}
+
+	user, err := p.GetCurrentUser()
+	if err != nil {
+		return g, err
+	}
+	g.BotAccounts = []string{userName, user.AccountID, user.Name, user.Key, user.EmailAddress}
+	return g, nil
+}
+
+func (g Guard) isBot(author j.User) bool {
+	return matchesAccount(author, g.BotAccounts)
+}
+
+// matchesAccount is whether the author is one of the accounts, by name, email, account id or display name
+func matchesAccount(author j.User, accounts []string) bool {
+	for _, account := range accounts {
+		if account == "" {
+			continue
+		}
+		for _, id := range []string{author.Name, author.EmailAddress, author.AccountID, author.DisplayName, author.Key} {
+			if strings.EqualFold(id, account) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Check returns the reason an issue shouldn't be moved to the target status, or "" if it can be. mergedAt is when
+// the pull request prompting the change merged, if there is one
+func (g Guard) Check(issueId string, target string, mergedAt *time.Time, p IssueTracker) (string, error) {
+	within, err := ParseDuration(g.Within)
+	if err != nil {
+		return "", err
+	}
+
+	issue, err := p.GetIssueWithChangeLog(issueId)
+	if err != nil {
+		return "", fmt.Errorf("retrieving issueId %s with changelog: %v", issueId, err)
+	}
+	if issue.Changelog == nil {
+		return "", nil
+	}
+
+	since := time.Now().Add(-within)
+	reason := ""
+	var reasonAt time.Time
+
+	// every history is checked as the order they are returned in isn't relied on, the latest reason is reported
+	for _, history := range issue.Changelog.Histories {
+		if g.isBot(history.Author) {
+			continue
+		}
+		created, err := time.Parse(jiraTimeFormat, history.Created)
+		if err != nil {
+			return "", fmt.Errorf("parsing changelog time %q on issue %s: %v", history.Created, issue.Key, err)
+		}
+		if !reasonAt.IsZero() && created.Before(reasonAt) {
+			continue
+		}
+		author := authorName(history.Author)
+
+		if g.SkipIfTouchedSinceMerge && mergedAt != nil && created.After(*mergedAt) {
+			fields := make([]string, 0)
+			for _, item := range history.Items {
+				fields = append(fields, item.Field)
+			}
+			reason = fmt.Sprintf("%s changed %s on %s after the pull request merged on %s", author, strings.Join(fields, ", "), created.Format(time.RFC3339), mergedAt.Format(time.RFC3339))
+			reasonAt = created
+			continue
+		}
+
+		if within <= 0 || created.Before(since) {
+			continue
+		}
+		for _, item := range history.Items {
+			// someone moving an issue away from the target status by hand shouldn't be undone
+			if item.Field == "status" && strings.EqualFold(item.FromString, target) {
+				reason = fmt.Sprintf("%s moved it from %q to %q on %s, within the last %s", author, item.FromString, item.ToString, created.Format(time.RFC3339), g.Within)
+				reasonAt = created
+			}
+		}
+	}
+
+	return reason, nil
+}
+
+// CheckSprint returns the reason an issue shouldn't be added to a sprint, or "" if it can be. An issue someone
+// removed from the sprint by hand within the guard window is left out of it
+func (g Guard) CheckSprint(issueId string, sprintId int, p IssueTracker) (string, error) {
+	within, err := ParseDuration(g.Within)
+	if err != nil {
+		return "", err
+	}
+	if within <= 0 {
+		return "", nil
+	}
+
+	issue, err := p.GetIssueWithChangeLog(issueId)
+	if err != nil {
+		return "", fmt.Errorf("retrieving issueId %s with changelog: %v", issueId, err)
+	}
+	if issue.Changelog == nil {
+		return "", nil
+	}
+
+	since := time.Now().Add(-within)
+	sprint := strconv.Itoa(sprintId)
+	reason := ""
+	var reasonAt time.Time
+
+	for _, history := range issue.Changelog.Histories {
+		if g.isBot(history.Author) {
+			continue
+		}
+		created, err := time.Parse(jiraTimeFormat, history.Created)
+		if err != nil {
+			return "", fmt.Errorf("parsing changelog time %q on issue %s: %v", history.Created, issue.Key, err)
+		}
+		if created.Before(since) || (!reasonAt.IsZero() && created.Before(reasonAt)) {
+			continue
+		}
+
+		for _, item := range history.Items {
+			if !strings.EqualFold(item.Field, "sprint") {
+				continue
+			}
+			// the raw values are comma separated lists of the ids of every sprint the issue was in
+			if containsString(splitIds(item.From), sprint) && !containsString(splitIds(item.To), sprint) {
+				reason = fmt.Sprintf("%s removed it from sprint %d on %s, within the last %s", authorName(history.Author), sprintId, created.Format(time.RFC3339), g.Within)
+				reasonAt = created
+			}
+		}
+	}
+
+	return reason, nil
+}
+
+func authorName(author j.User) string {
+	if author.DisplayName != "" {
+		return author.DisplayName
+	}
+	return author.Name
+}
+
+func splitIds(value interface{}) []string {
+	if value == nil {
+		return nil
+	}
+
+	ids := make([]string, 0)
+	for _, id := range strings.Split(fmt.Sprint(value), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/gh/ghtest"
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":     0,
+		"7d":   7 * 24 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+		"12h":  12 * time.Hour,
+		"1.5d": 36 * time.Hour,
+	}
+	for input, expected := range cases {
+		actual, err := ParseDuration(input)
+		if err != nil {
+			t.Errorf("parsing %q: %v", input, err)
+		} else if actual != expected {
+			t.Errorf("expected %q to be %s, got %s", input, expected, actual)
+		}
+	}
+
+	if _, err := ParseDuration("soon"); err == nil {
+		t.Errorf("expected an error parsing an invalid duration")
+	}
+}
+
+func TestSetStatusGuard(t *testing.T) {
+	now := time.Now()
+	moved := func(author string, daysAgo int) jiratest.History {
+		return jiratest.History{Author: author, Created: now.AddDate(0, 0, -daysAgo), Items: []jiratest.HistoryItem{{Field: "status", From: "Done", To: "In Review"}}}
+	}
+	older := jiratest.History{Author: "someone", Created: now.AddDate(0, 0, -30), Items: []jiratest.HistoryItem{{Field: "status", From: "In Progress", To: "In Review"}}}
+
+	s := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			// the manual change isn't the first history, which the guard used to only look at
+			{Key: "IPL-1", Status: "In Review", Changelog: []jiratest.History{older, moved("someone", 2)}},
+			{Key: "IPL-2", Status: "In Review", Changelog: []jiratest.History{older, moved("someone", 10)}},
+			{Key: "IPL-3", Status: "In Review", Changelog: []jiratest.History{older, moved("automation", 2)}},
+			// histories returned newest first are handled the same
+			{Key: "IPL-4", Status: "In Review", Changelog: []jiratest.History{moved("someone", 1), older}},
+		},
+		Transitions: []jiratest.Transition{
+			{ID: "31", Name: "Done", From: []string{"In Review"}, To: "Done"},
+		},
+	})
+	defer s.Close()
+
+	setStatus := SetStatus{
+		JiraUrl:     s.URL,
+		Jql:         "project = IPL",
+		Transitions: []string{"in review;done"},
+		CheckLog:    true,
+		Guard:       Guard{Within: "7d", BotAccounts: []string{"automation"}},
+		DryRun:      true,
+	}
+	// a dry run reports the same skips without updating anything
+	out := captureOutput(t, setStatus.SetStatus)
+	if !strings.Contains(out, "NOT updating issue IPL-1") || !strings.Contains(out, "Finished updating the status on 2 issues") {
+		t.Errorf("expected a dry run to report the skips, got:\n%s", out)
+	}
+	if actual := s.Issue("IPL-2").Status; actual != "In Review" {
+		t.Errorf("expected a dry run to leave IPL-2 alone, got %q", actual)
+	}
+
+	setStatus.DryRun = false
+	out = captureOutput(t, setStatus.SetStatus)
+
+	expected := map[string]string{
+		"IPL-1": "In Review",
+		"IPL-2": "Done",
+		"IPL-3": "Done",
+		"IPL-4": "In Review",
+	}
+	for key, status := range expected {
+		if actual := s.Issue(key).Status; actual != status {
+			t.Errorf("expected %s to have status %q, got %q", key, status, actual)
+		}
+	}
+
+	if !strings.Contains(out, `NOT updating issue IPL-1 to done as someone moved it from "Done" to "In Review"`) {
+		t.Errorf("expected the skip to be reported with its reason, got:\n%s", out)
+	}
+}
+
+func TestSetStatusGuardDefaultsToTheJiraAccount(t *testing.T) {
+	// jira cloud shows the account id but not the email of changelog authors
+	s := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "In Review", Changelog: []jiratest.History{
+				{Author: "5b10a2844c20165700ede21g", Created: time.Now().AddDate(0, 0, -1), Items: []jiratest.HistoryItem{{Field: "status", From: "Done", To: "In Review"}}},
+			}},
+		},
+		Transitions: []jiratest.Transition{{ID: "31", Name: "Done", From: []string{"In Review"}, To: "Done"}},
+		Myself:      "5b10a2844c20165700ede21g",
+	})
+	defer s.Close()
+
+	setStatus := SetStatus{
+		JiraUrl:     s.URL,
+		UserName:    "automation@example.com",
+		Jql:         "project = IPL",
+		Transitions: []string{"in review;done"},
+		CheckLog:    true,
+		Guard:       Guard{Within: "7d"},
+	}
+	captureOutput(t, setStatus.SetStatus)
+	if actual := s.Issue("IPL-1").Status; actual != "Done" {
+		t.Errorf("expected the jira user's own change not to be treated as manual, got status %q", actual)
+	}
+
+	setStatus.Guard.SkipIfTouchedSinceMerge = true
+	if err := setStatus.SetStatus(); err == nil || !strings.Contains(err.Error(), "pr-sync") {
+		t.Errorf("expected set-status to reject --skip-if-touched-since-merge, got %v", err)
+	}
+}
+
+func TestPRSyncSkipIfTouchedSinceMerge(t *testing.T) {
+	merged := time.Now().AddDate(0, 0, -3)
+
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "In Review", Description: "https://github.com/acme/app/pull/1", Changelog: []jiratest.History{
+				{Author: "someone", Created: time.Now().AddDate(0, 0, -1), Items: []jiratest.HistoryItem{{Field: "labels", From: "", To: "needs-docs"}}},
+			}},
+			{Key: "IPL-2", Status: "In Review", Description: "https://github.com/acme/app/pull/1", Changelog: []jiratest.History{
+				{Author: "someone", Created: time.Now().AddDate(0, 0, -5), Items: []jiratest.HistoryItem{{Field: "labels", From: "", To: "needs-docs"}}},
+			}},
+		},
+		Transitions: []jiratest.Transition{
+			{ID: "31", Name: "Done", From: []string{"In Review"}, To: "Done"},
+		},
+	})
+	defer jiraServer.Close()
+
+	ghServer := ghtest.NewServer(ghtest.Seed{
+		Issues: []ghtest.Issue{
+			{Repo: "acme/app", Number: 1, State: "closed", ClosedAt: &merged, Pull: &ghtest.Pull{Base: "main", Merged: true, MergedAt: &merged}},
+		},
+	})
+	defer ghServer.Close()
+
+	s := PRSync{
+		List: List{
+			JiraUrl:  jiraServer.URL,
+			GHApiUrl: ghServer.URL,
+			Jql:      "project = IPL",
+		},
+		CheckLog:    true,
+		Guard:       Guard{Within: "7d", SkipIfTouchedSinceMerge: true},
+		Transitions: []string{"in review;done"},
+	}
+	out := captureOutput(t, s.Sync)
+
+	if actual := jiraServer.Issue("IPL-1").Status; actual != "In Review" {
+		t.Errorf("expected IPL-1 touched after the merge to be left alone, got %q", actual)
+	}
+	if actual := jiraServer.Issue("IPL-2").Status; actual != "Done" {
+		t.Errorf("expected IPL-2 to be moved to Done, got %q", actual)
+	}
+	if !strings.Contains(out, "someone changed labels on") {
+		t.Errorf("expected the skip to be reported with its reason, got:\n%s", out)
+	}
+}
@@ -0,0 +1,307 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	j "github.com/andygrunwald/go-jira"
+	c "github.com/gookit/color"
+	"github.com/jirallreadyforthis/lib/index"
+)
+
+// Index syncs jira issues and their linked github items to a local index and queries it offline.
+// The embedded List provides the link discovery and filter options
+type Index struct {
+	List
+	Path string
+}
+
+func (i Index) path() (string, error) {
+	if i.Path != "" {
+		return i.Path, nil
+	}
+	return index.DefaultPath()
+}
+
+// sweepInterval is how often a sync lists every issue matching the jql, to drop issues that no longer match it which an
+// incremental sync doesn't see
+const sweepInterval = 24 * time.Hour
+
+// Sync updates the index with the issues matching the jql that have changed since the last sync, then re-checks
+// any linked github items that haven't yet been closed or merged
+func (i Index) Sync() error {
+	if i.Jql == "" {
+		return fmt.Errorf("a jql query is required to sync the index")
+	}
+
+	path, err := i.path()
+	if err != nil {
+		return err
+	}
+
+	idx, err := index.Load(path)
+	if err != nil {
+		return err
+	}
+	if idx == nil || idx.Jql != i.Jql || idx.JiraUrl != i.JiraUrl {
+		fmt.Printf("building a new index at %s\n", path)
+		idx = index.New(i.JiraUrl, i.Jql)
+	}
+
+	p := i.tracker()
+
+	jql := i.Jql
+	full := idx.LastSync.IsZero()
+	if !full {
+		jql = incrementalJql(i.Jql, idx.LastSync)
+		fmt.Printf("syncing issues updated since %s\n", idx.LastSync.Format(time.RFC3339))
+	}
+
+	syncStart := time.Now()
+	issues, err := p.ListIssues(jql)
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	if full {
+		idx.LastSweep = syncStart
+	} else if syncStart.Sub(idx.LastSweep) >= sweepInterval {
+		if removed, err = i.sweep(idx, p); err != nil {
+			return err
+		}
+		idx.LastSweep = syncStart
+	}
+
+	for _, issue := range issues {
+		issueWithComments, err := p.GetIssue(issue.ID)
+		if err != nil {
+			return err
+		}
+
+		links := i.findLinks(issue, issueWithComments)
+		idx.Issues[issue.Key] = indexIssue(issue, links)
+		for _, link := range links {
+			if _, ok := idx.Links[link]; !ok {
+				idx.Links[link] = index.Link{URL: link}
+			}
+		}
+	}
+
+	// drop links no issue refers to anymore, and re-check the ones that may still change
+	referenced := make(map[string]bool)
+	for _, issue := range idx.Issues {
+		for _, link := range issue.Links {
+			referenced[link] = true
+		}
+	}
+
+	checked := 0
+	for url, link := range idx.Links {
+		if !referenced[url] {
+			delete(idx.Links, url)
+			continue
+		}
+		if link.Terminal() {
+			continue
+		}
+		idx.Links[url] = i.resolveLink(url)
+		checked++
+	}
+
+	idx.LastSync = syncStart
+	if err := idx.Save(path); err != nil {
+		return err
+	}
+
+	c.Info.Printf("\nSynced %d updated issues, removed %d that no longer match and checked %d github links, the index now has %d issues\n",
+		len(issues), removed, checked, len(idx.Issues))
+
+	return nil
+}
+
+// sweep removes the issues from the index that the jql no longer returns, returning how many were removed
+func (i Index) sweep(idx *index.Index, p IssueTracker) (int, error) {
+	fmt.Printf("checking for issues that no longer match the jql\n")
+	issues, err := p.ListIssues(i.Jql)
+	if err != nil {
+		return 0, err
+	}
+
+	matching := make(map[string]bool)
+	for _, issue := range issues {
+		matching[issue.Key] = true
+	}
+
+	removed := 0
+	for key := range idx.Issues {
+		if !matching[key] {
+			delete(idx.Issues, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// Query lists the issues in the index whose linked github items are closed or merged, without calling jira or github
+func (i Index) Query() error {
+	// the index only keeps the state of each link, not its reviews, checks or releases
+	if len(i.PRStates) > 0 && !(len(i.PRStates) == 1 && i.PRStates[0] == PRStateMerged) {
+		return fmt.Errorf("--pr-state isn't supported when querying the index, use list instead")
+	}
+	if i.RequireRelease {
+		return fmt.Errorf("--require-release isn't supported when querying the index, use list instead")
+	}
+	if i.Rule != "" || i.RuleFile != "" {
+		return fmt.Errorf("--rule and --rule-file aren't supported when querying the index, use list instead")
+	}
+
+	path, err := i.path()
+	if err != nil {
+		return err
+	}
+
+	idx, err := index.Load(path)
+	if err != nil {
+		return err
+	}
+	if idx == nil {
+		return fmt.Errorf("no index found at %s, run 'index sync' first", path)
+	}
+
+	fmt.Printf("querying index of %q last synced %s\n", idx.Jql, idx.LastSync.Format(time.RFC3339))
+
+	keys := make([]string, 0, len(idx.Issues))
+	for key := range idx.Issues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	count := 0
+	for _, key := range keys {
+		issue := idx.Issues[key]
+
+		ready := make([]string, 0)
+		notReady := 0
+		for _, url := range issue.Links {
+			if !i.repoAllowed(url) {
+				continue
+			}
+			link, ok := idx.Links[url]
+			if !ok {
+				notReady++
+				continue
+			}
+			if s := i.indexedLinkReady(link); s != "" {
+				ready = append(ready, s)
+			} else {
+				notReady++
+			}
+		}
+
+		if len(ready) == 0 || (i.Require == RequireAll && notReady > 0) {
+			continue
+		}
+
+		count++
+		date := issue.Created.Format("2006-01-02")
+		c.Printf("\n\n<green>%s\t%s\t%s</>\n", date, i.getJiraHtmlUrl(issue.Key), issue.Summary)
+		c.Printf("\t%s", strings.Join(ready, "\t\n\t"))
+	}
+
+	c.Info.Printf("\nFinished listing %d issues\n", count)
+	return nil
+}
+
+// indexedLinkReady applies the list filters to an indexed link, returning a description of it if it counts
+func (i Index) indexedLinkReady(link index.Link) string {
+	if link.Error != "" || link.State != "closed" {
+		return ""
+	}
+
+	closedAt := link.ClosedAt
+	if link.IsPullRequest {
+		closedAt = link.MergedAt
+	}
+	if i.ClosedWithin > 0 && (closedAt == nil || closedAt.Before(time.Now().AddDate(0, 0, -i.ClosedWithin))) {
+		return ""
+	}
+	date := ""
+	if closedAt != nil {
+		date = closedAt.Format("2006-01-02")
+	}
+
+	if link.IsPullRequest {
+		if !link.Merged || !matchesBaseBranch(link.Base, i.BaseBranches) {
+			return ""
+		}
+		return c.Sprintf("<lightMagenta>%s\t%s\t%s</>", date, link.URL, link.Title)
+	}
+
+	if i.CloseReason != "" && i.CloseReason != CloseReasonAny && i.CloseReason != link.StateReason {
+		return ""
+	}
+	return c.Sprintf("<lightRed>%s\t%s\t%s\t(%s)</>", date, link.URL, link.Title, link.StateReason)
+}
+
+func (i Index) resolveLink(url string) index.Link {
+	link := index.Link{
+		URL:       url,
+		CheckedAt: time.Now(),
+	}
+
+	item, _ := i.getLinkedItem(url)
+	if item == nil {
+		link.Error = "unable to get linked item"
+		return link
+	}
+
+	link.Repo = item.Repo
+	link.Number = item.Number
+	link.Title = item.Title
+	link.State = item.State
+	link.StateReason = item.StateReason
+	link.ClosedAt = item.ClosedAt
+	link.IsPullRequest = item.PullRequest
+	link.Merged = item.Merged
+	link.Base = item.Base
+	link.MergedAt = item.MergedAt
+
+	return link
+}
+
+func indexIssue(issue j.Issue, links []string) index.Issue {
+	i := index.Issue{
+		Key:     issue.Key,
+		ID:      issue.ID,
+		Summary: issue.Fields.Summary,
+		Created: time.Time(issue.Fields.Created),
+		Updated: time.Time(issue.Fields.Updated),
+		Links:   links,
+	}
+	if status := issue.Fields.Status; status != nil {
+		i.Status = status.Name
+		i.StatusCategory = status.StatusCategory.Name
+	}
+	return i
+}
+
+// incrementalJql limits a jql query to issues updated since the given time. A relative time is used so the
+// query doesn't depend on the timezone of the jira user, with a few minutes of overlap so nothing is missed
+func incrementalJql(jql string, since time.Time) string {
+	query, orderBy := jql, ""
+	if loc := regexp.MustCompile("(?i)\\s*order\\s+by\\s").FindStringIndex(jql); loc != nil {
+		query, orderBy = jql[:loc[0]], " "+strings.TrimSpace(jql[loc[0]:])
+	}
+
+	minutes := int(time.Since(since).Minutes()) + 5
+	updated := fmt.Sprintf("updated >= \"-%dm\"", minutes)
+	if strings.TrimSpace(query) == "" {
+		return updated + orderBy
+	}
+
+	return fmt.Sprintf("(%s) AND %s%s", query, updated, orderBy)
+}
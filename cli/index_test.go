@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/gh/ghtest"
+	"github.com/jirallreadyforthis/lib/index"
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestIndexSyncAndQuery(t *testing.T) {
+	longAgo := time.Now().AddDate(-1, 0, 0)
+	merged := time.Now().AddDate(0, 0, -2)
+
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Summary: "landed", Updated: longAgo, Description: "https://github.com/acme/app/pull/1"},
+			{Key: "IPL-2", Summary: "moved away", Updated: longAgo, Description: "https://github.com/acme/app/pull/2"},
+			{Key: "IPL-3", Summary: "new", Updated: time.Now(), Description: "https://github.com/acme/app/pull/3"},
+		},
+		Queries: map[string][]string{"project = IPL": {"IPL-1", "IPL-2"}},
+	})
+	defer jiraServer.Close()
+
+	ghServer := ghtest.NewServer(ghtest.Seed{
+		Issues: []ghtest.Issue{
+			{Repo: "acme/app", Number: 1, State: "closed", ClosedAt: &merged, Pull: &ghtest.Pull{Merged: true, MergedAt: &merged, Base: "main"}},
+			{Repo: "acme/app", Number: 2, State: "open", Pull: &ghtest.Pull{Base: "main"}},
+			{Repo: "acme/app", Number: 3, State: "open", Pull: &ghtest.Pull{Base: "main"}},
+		},
+	})
+	defer ghServer.Close()
+
+	path := filepath.Join(t.TempDir(), "index.json")
+	i := Index{List: List{JiraUrl: jiraServer.URL, GHApiUrl: ghServer.URL, Jql: "project = IPL"}, Path: path}
+	load := func() *index.Index {
+		t.Helper()
+		idx, err := index.Load(path)
+		if err != nil || idx == nil {
+			t.Fatalf("loading index: %v", err)
+		}
+		return idx
+	}
+	keys := func(idx *index.Index) string {
+		k := make([]string, 0)
+		for _, key := range []string{"IPL-1", "IPL-2", "IPL-3"} {
+			if _, ok := idx.Issues[key]; ok {
+				k = append(k, key)
+			}
+		}
+		return strings.Join(k, ",")
+	}
+
+	captureOutput(t, i.Sync)
+	idx := load()
+	if keys(idx) != "IPL-1,IPL-2" || len(idx.Links) != 2 || !idx.Links["https://github.com/acme/app/pull/1"].Merged {
+		t.Fatalf("expected a full sync to index both issues and their links, got %+v", idx)
+	}
+
+	// IPL-2 stops matching the jql and IPL-3 starts, only IPL-3 has been updated since the last sync
+	jiraServer.SetQuery("project = IPL", []string{"IPL-1", "IPL-3"})
+	captureOutput(t, i.Sync)
+	if idx = load(); keys(idx) != "IPL-1,IPL-2,IPL-3" {
+		t.Errorf("expected an incremental sync to add the updated issue, got %s", keys(idx))
+	}
+
+	idx.LastSweep = time.Now().Add(-2 * sweepInterval)
+	if err := idx.Save(path); err != nil {
+		t.Fatalf("saving index: %v", err)
+	}
+	out := captureOutput(t, i.Sync)
+	if idx = load(); keys(idx) != "IPL-1,IPL-3" || !strings.Contains(out, "removed 1 that no longer match") {
+		t.Errorf("expected the periodic sweep to remove the issue that no longer matches, got %s: %s", keys(idx), out)
+	}
+	if _, ok := idx.Links["https://github.com/acme/app/pull/2"]; ok {
+		t.Errorf("expected the links of removed issues to be dropped")
+	}
+
+	out = captureOutput(t, i.Query)
+	if !strings.Contains(out, "IPL-1") || strings.Contains(out, "IPL-3") || !strings.Contains(out, "Finished listing 1 issues") {
+		t.Errorf("expected only the issue with a merged pull request from the index, got %q", out)
+	}
+
+	// the index doesn't hold what these filters need, so they are rejected rather than ignored
+	for _, list := range []List{{RequireRelease: true}, {PRStates: []string{PRStateApproved}}, {Rule: "count(prs) > 0"}} {
+		list.JiraUrl = jiraServer.URL
+		if err := (Index{List: list, Path: path}).Query(); err == nil {
+			t.Errorf("expected querying the index with %+v to fail", list)
+		}
+	}
+	i.PRStates = []string{PRStateMerged}
+	captureOutput(t, i.Query)
+}
+
+func TestIncrementalJql(t *testing.T) {
+	since := time.Now().Add(-time.Hour)
+	cases := map[string]string{
+		"project = IPL":                  `(project = IPL) AND updated >= "-65m"`,
+		"project = IPL ORDER BY created": `(project = IPL) AND updated >= "-65m" ORDER BY created`,
+		"order by key":                   `updated >= "-65m" order by key`,
+	}
+	for jql, expected := range cases {
+		if actual := incrementalJql(jql, since); actual != expected {
+			t.Errorf("expected %q to become %q, got %q", jql, expected, actual)
+		}
+	}
+}
@@ -2,12 +2,12 @@ package cli
 
 import (
 	"fmt"
-	"regexp"
-	"strconv"
+	"os"
+	"path"
 	"strings"
 	"time"
 
-	"github.com/google/go-github/v52/github"
+	j "github.com/andygrunwald/go-jira"
 	c "github.com/gookit/color"
 	"github.com/jirallreadyforthis/lib/gh"
 	"github.com/jirallreadyforthis/lib/jira"
@@ -23,14 +23,90 @@ type List struct {
 	GHToken      string
 	NotCommented int
 	ClosedWithin int
+	// BaseBranches limits merged pull requests to those merged into a matching base branch eg 'main' or 'release/*'
+	BaseBranches []string
+	// RequireRelease limits merged pull requests to those whose merge commit is part of a release
+	RequireRelease bool
+	// PRStates are the states a linked pull request must all be in to count, defaults to merged
+	PRStates []string
+	// Rule is a readiness rule expression, when set it replaces the linked/pr state checks
+	Rule     string
+	RuleFile string
+	// Require is whether any or all of the linked github items need to be closed or merged for an issue to be listed
+	Require string
+	// Repos limits which github repos links are followed to eg 'owner/name' or 'owner/*'
+	Repos []string
+	// CloseReason limits closed github issues to those closed for this reason, 'completed', 'not_planned' or 'any'
+	CloseReason string
+	// GHApiUrl overrides the github api url
+	GHApiUrl string
+	// GitLabToken is used to resolve gitlab merge request and issue links
+	GitLabToken string
+	// BitbucketUser and BitbucketToken are used to resolve bitbucket cloud and data center pull request links
+	BitbucketUser  string
+	BitbucketToken string
+	// Tracker is where issues are listed from, defaults to jira
+	Tracker IssueTracker
+	// Hosts resolve the links found in issues, defaults to github
+	Hosts []CodeHost
 }
 
-func (l List) ListJiraTickets() error {
-	p := jira.Project{
+const CloseReasonAny = "any"
+
+const (
+	RequireAny = "any"
+	RequireAll = "all"
+)
+
+const (
+	PRStateMerged        = "merged"
+	PRStateApproved      = "approved"
+	PRStateChecksPassing = "checks-passing"
+)
+
+func (l List) tracker() IssueTracker {
+	if l.Tracker != nil {
+		return l.Tracker
+	}
+	return jira.Project{
 		Token:    l.JiraToken,
 		UserName: l.UserName,
 		JiraUrl:  l.JiraUrl,
 	}
+}
+
+func (l List) hosts() []CodeHost {
+	if len(l.Hosts) > 0 {
+		return l.Hosts
+	}
+	return []CodeHost{
+		githubHost{Token: l.GHToken, BaseURL: l.GHApiUrl},
+		gitlabHost{Token: l.GitLabToken},
+		bitbucketHost{UserName: l.BitbucketUser, Token: l.BitbucketToken},
+	}
+}
+
+func (l List) ListJiraTickets() error {
+	p := l.tracker()
+
+	for _, state := range l.PRStates {
+		if state != PRStateMerged && state != PRStateApproved && state != PRStateChecksPassing {
+			return fmt.Errorf("unknown pr state %q, expected one of %s, %s or %s", state, PRStateMerged, PRStateApproved, PRStateChecksPassing)
+		}
+	}
+
+	if l.Require != "" && l.Require != RequireAll && l.Require != RequireAny {
+		return fmt.Errorf("unknown requirement %q, expected one of %s or %s", l.Require, RequireAll, RequireAny)
+	}
+
+	if l.CloseReason != "" && l.CloseReason != CloseReasonAny && l.CloseReason != gh.CloseReasonCompleted && l.CloseReason != gh.CloseReasonNotPlanned {
+		return fmt.Errorf("unknown close reason %q, expected one of %s, %s or %s", l.CloseReason, gh.CloseReasonCompleted, gh.CloseReasonNotPlanned, CloseReasonAny)
+	}
+
+	rule, err := l.loadRule()
+	if err != nil {
+		return err
+	}
 
 	issues, err := p.ListIssues(l.Jql)
 	if err != nil {
@@ -40,6 +116,10 @@ func (l List) ListJiraTickets() error {
 	count := 0
 	for _, issue := range issues {
 		issueWithComments, err := p.GetIssue(issue.ID)
+		if err != nil {
+			return err
+		}
+
 		if l.NotCommented > 0 {
 			if len(issueWithComments.Fields.Comments.Comments) > 0 {
 				lastComment := issueWithComments.Fields.Comments.Comments[len(issueWithComments.Fields.Comments.Comments)-1]
@@ -55,54 +135,60 @@ func (l List) ListJiraTickets() error {
 			}
 		}
 
-		if l.Linked {
-			githubLinks := make([]string, 0)
-			if len(l.CustomFields) > 0 {
-				for _, field := range l.CustomFields {
-					if issue.Fields.Unknowns != nil {
-						fieldValue, exists := issue.Fields.Unknowns.Value(field)
-						if exists && fieldValue != nil {
-							githubLinks = append(githubLinks, findGithubLinks(fieldValue.(string))...)
-						}
-					}
-				}
+		if rule != nil {
+			// like a failed lookup of a linked item, an issue the rule can't be checked against is skipped
+			env, linked, err := l.ruleEnv(rule, issue, issueWithComments)
+			if err != nil {
+				c.Errorf("\n Error getting the linked items of %s for the rule: %v\n", issue.Key, err)
+				continue
 			}
-			githubLinks = append(githubLinks, findGithubLinks(issue.Fields.Description)...)
-
-			// search issue comments for links
+			ready, err := rule.Eval(env)
 			if err != nil {
-				return err
+				c.Errorf("\n Error evaluating the rule for %s: %v\n", issue.Key, err)
+				continue
 			}
-
-			if issueWithComments != nil {
-				if len(issueWithComments.Fields.Comments.Comments) > 0 {
-					for _, comment := range issueWithComments.Fields.Comments.Comments {
-						githubLinks = append(githubLinks, findGithubLinks(comment.Body)...)
-					}
+			if ready {
+				count++
+				createdTime := time.Time(issue.Fields.Created)
+				date := strings.Split(createdTime.String(), " ")[0]
+				c.Printf("\n\n<green>%s\t%s\t%s</>\n", date, l.getJiraHtmlUrl(issue.Key), issue.Fields.Summary)
+				if len(linked) > 0 {
+					c.Printf("\t%s", strings.Join(linked, "\t\n\t"))
 				}
 			}
+			continue
+		}
+
+		if l.Linked {
+			githubLinks := l.findLinks(issue, issueWithComments)
 
 			ghClosedOrMerged := make([]string, 0)
-			githubLinks = removeDuplicates(githubLinks)
+			notReady := 0
 			for _, link := range githubLinks {
-				ghIssue, repo := l.getIssueAndRepoFromLink(link)
-				if ghIssue != nil && repo != nil {
-
-					closed, err := closedOrMergedWithin(ghIssue, l.ClosedWithin)
-					if err != nil {
-						return err
-					}
-					if !closed {
-						continue
-					}
-					s, err := l.closedOrMerged(ghIssue, *repo)
-					if err != nil {
-						return err
-					}
-					if s != "" {
-						ghClosedOrMerged = append(ghClosedOrMerged, s)
-					}
+				item, host := l.getLinkedItem(link)
+				if item == nil {
+					notReady++
+					continue
 				}
+
+				if l.ClosedWithin > 0 && item.State == "closed" && !closedWithin(item.ClosedAt, l.ClosedWithin) {
+					notReady++
+					continue
+				}
+				s, err := l.closedOrMerged(item, host)
+				if err != nil {
+					return err
+				}
+				if s == "" {
+					notReady++
+					continue
+				}
+				ghClosedOrMerged = append(ghClosedOrMerged, s)
+			}
+
+			// when all linked items are required every one of them has to be closed or merged
+			if l.Require == RequireAll && notReady > 0 {
+				continue
 			}
 
 			createdTime := time.Time(issue.Fields.Created)
@@ -122,54 +208,170 @@ func (l List) ListJiraTickets() error {
 	return nil
 }
 
-func (l List) closedOrMerged(issue *github.Issue, repo gh.Repo) (string, error) {
+// findLinks searches the description, comments and any custom fields of an issue for links to the code hosts
+func (l List) findLinks(issue j.Issue, issueWithComments *j.Issue) []string {
+	texts := make([]string, 0)
+	if len(l.CustomFields) > 0 {
+		for _, field := range l.CustomFields {
+			if issue.Fields.Unknowns != nil {
+				fieldValue, exists := issue.Fields.Unknowns.Value(field)
+				if exists && fieldValue != nil {
+					texts = append(texts, fieldValue.(string))
+				}
+			}
+		}
+	}
+	texts = append(texts, issue.Fields.Description)
+
+	// search issue comments for links
+	if issueWithComments != nil && issueWithComments.Fields.Comments != nil {
+		for _, comment := range issueWithComments.Fields.Comments.Comments {
+			texts = append(texts, comment.Body)
+		}
+	}
+
+	githubLinks := make([]string, 0)
+	for _, text := range texts {
+		for _, host := range l.hosts() {
+			githubLinks = append(githubLinks, host.FindLinks(text)...)
+		}
+	}
+
+	allowed := make([]string, 0)
+	for _, link := range removeDuplicates(githubLinks) {
+		if l.repoAllowed(link) {
+			allowed = append(allowed, link)
+		}
+	}
+
+	return allowed
+}
+
+// repoAllowed checks the repo of a link against the allowed repos, any repo is allowed if none are set
+func (l List) repoAllowed(link string) bool {
+	if len(l.Repos) == 0 {
+		return true
+	}
+
+	repoName := ""
+	if host := l.hostFor(link); host != nil {
+		repoName = strings.ToLower(host.Repo(link))
+	}
+	if repoName == "" {
+		return false
+	}
+
+	for _, pattern := range l.Repos {
+		if matched, err := path.Match(strings.ToLower(pattern), repoName); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (l List) closedOrMerged(item *LinkedItem, host CodeHost) (string, error) {
 	closedOrMergedString := ""
 
-	if issue != nil {
-		if issue.IsPullRequest() {
-			merged, err := repo.PullRequestIsMerged(*issue.Number)
-			if err != nil {
-				c.Errorf("Error checking if pr %d is merged %s: %v\n", *issue.Number, err)
+	if item != nil {
+		if item.PullRequest {
+			return l.pullRequestReady(item, host)
+		} else if item.State == "closed" {
+			if l.CloseReason != "" && l.CloseReason != CloseReasonAny && l.CloseReason != item.StateReason {
 				return closedOrMergedString, nil
 			}
-
-			if merged {
-				closedDate := strings.Split(issue.GetClosedAt().String(), " ")[0]
-				if l.ClosedWithin > 0 {
-					closed, err := closedOrMergedWithin(issue, l.ClosedWithin)
-					if err != nil {
-						return "", err
-					}
-					if !closed {
-						return closedOrMergedString, nil
-					}
-				}
-				closedOrMergedString = c.Sprintf("<lightMagenta>%s\t%s\t%s</>", closedDate, issue.GetHTMLURL(), issue.GetTitle())
-			}
-		} else if issue.GetState() == "closed" {
-			closedDate := strings.Split(issue.GetClosedAt().String(), " ")[0]
-			closedOrMergedString = c.Sprintf("<lightRed>%s\t%s\t%s</>", closedDate, issue.GetHTMLURL(), issue.GetTitle())
+			closedOrMergedString = describeItem(item)
 		}
 	}
 
 	return closedOrMergedString, nil
 }
 
+func (l List) pullRequestReady(item *LinkedItem, host CodeHost) (string, error) {
+	states := l.PRStates
+	if len(states) == 0 {
+		states = []string{PRStateMerged}
+	}
+	requireMerged := containsString(states, PRStateMerged)
+
+	if requireMerged && !item.Merged {
+		return "", nil
+	}
+	// a pull request that was closed without merging is never ready
+	if !item.Merged && item.State == "closed" {
+		return "", nil
+	}
+	if l.RequireRelease && !item.Merged {
+		return "", nil
+	}
+
+	if item.Merged && l.ClosedWithin > 0 && !closedWithin(item.ClosedAt, l.ClosedWithin) {
+		return "", nil
+	}
+
+	if !matchesBaseBranch(item.Base, l.BaseBranches) {
+		return "", nil
+	}
+
+	details := make([]string, 0)
+	if containsString(states, PRStateApproved) {
+		decision, err := host.ReviewDecision(item)
+		if err != nil {
+			c.Errorf("Error getting review decision for pr %d: %v\n", item.Number, err)
+			return "", nil
+		}
+		if decision != ReviewApproved {
+			return "", nil
+		}
+		details = append(details, "approved")
+	}
+
+	if containsString(states, PRStateChecksPassing) {
+		checks, err := host.ChecksState(item)
+		if err != nil {
+			c.Errorf("Error getting checks state for pr %d: %v\n", item.Number, err)
+			return "", nil
+		}
+		if checks != ChecksSuccess {
+			return "", nil
+		}
+		details = append(details, "checks passing")
+	}
+
+	release := ""
+	if l.RequireRelease {
+		var err error
+		release, err = host.ReleaseContaining(item)
+		if err != nil {
+			c.Errorf("Error finding release for pr %d: %v\n", item.Number, err)
+			return "", nil
+		}
+		if release == "" {
+			return "", nil
+		}
+	}
+
+	prString := describeItem(item)
+	if len(details) > 0 {
+		prString += c.Sprintf("\t<cyan>(%s)</>", strings.Join(details, ", "))
+	}
+	if release != "" {
+		prString += c.Sprintf("\t<cyan>(released in %s)</>", release)
+	}
+
+	return prString, nil
+}
+
 func (l List) getJiraHtmlUrl(issueKey string) string {
 	return fmt.Sprintf("%s/browse/%s", l.JiraUrl, issueKey)
 }
 
-func findGithubLinks(text string) []string {
-	re := regexp.MustCompile("https://github\\.com/[\\w-]+/[\\w-]+/(?:pull|issues)/\\d+")
-	matches := re.FindAllString(text, -1)
-
-	links := make([]string, 0)
-	for _, match := range matches {
-		match = strings.Split(match, "|")[0]
-		links = append(links, match)
+func containsString(slice []string, s string) bool {
+	for _, entry := range slice {
+		if entry == s {
+			return true
+		}
 	}
-
-	return links
+	return false
 }
 
 func removeDuplicates(slice []string) []string {
@@ -185,47 +387,78 @@ func removeDuplicates(slice []string) []string {
 	return list
 }
 
-func closedOrMergedWithin(issue *github.Issue, days int) (bool, error) {
-
-	closedDate := strings.Split(issue.GetClosedAt().String(), " ")[0]
-	parsedDate, err := time.Parse("2006-01-02", closedDate)
-	if err != nil {
-		return false, fmt.Errorf("parsing closed/merged time: %v", err)
+// matchesBaseBranch checks a pull request base branch against a list of patterns, any branch matches an empty list
+func matchesBaseBranch(base string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
 	}
 
-	if parsedDate.After(time.Now().AddDate(0, 0, -days)) {
-		return true, nil
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, base); err == nil && matched {
+			return true
+		}
 	}
+	return false
+}
 
-	return false, nil
+// closedWithin reports whether something closed at closedAt was closed in the last number of days
+func closedWithin(closedAt *time.Time, days int) bool {
+	return closedAt != nil && closedAt.After(time.Now().AddDate(0, 0, -days))
 }
 
-func (l List) getIssueAndRepoFromLink(link string) (*github.Issue, *gh.Repo) {
-	re := regexp.MustCompile("https://github\\.com/(?P<repoName>[\\w-]+/[\\w-]+)/(?:pull|issues)/(?P<number>\\d+)")
-	matches := re.FindAllStringSubmatch(link, -1)
+// describeItem formats a linked item for output, with the date it was merged or closed if it has been or else its state
+func describeItem(item *LinkedItem) string {
+	switch {
+	case item.PullRequest && item.Merged:
+		return c.Sprintf("<lightMagenta>%s\t%s\t%s</>", formatDate(item.ClosedAt), item.URL, item.Title)
+	case !item.PullRequest && item.State == "closed":
+		return c.Sprintf("<lightRed>%s\t%s\t%s\t(%s)</>", formatDate(item.ClosedAt), item.URL, item.Title, item.StateReason)
+	}
+	return c.Sprintf("<lightBlue>%s\t%s\t%s</>", item.State, item.URL, item.Title)
+}
 
-	repoName := ""
-	number := ""
-	if len(matches) > 0 {
-		repoIndex := re.SubexpIndex("repoName")
-		repoName = matches[0][repoIndex]
-		numberIndex := re.SubexpIndex("number")
-		number = matches[0][numberIndex]
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
 	}
+	return t.Format("2006-01-02")
+}
+
+// hostFor returns the code host a link is to, or nil if it isn't to any of them
+func (l List) hostFor(link string) CodeHost {
+	for _, host := range l.hosts() {
+		if host.Repo(link) != "" {
+			return host
+		}
+	}
+	return nil
+}
 
-	if repoName != "" && number != "" {
+// getLinkedItem resolves a link with the code host it is to, returning nil if it can't be resolved
+func (l List) getLinkedItem(link string) (*LinkedItem, CodeHost) {
+	item, host, err := l.resolveLink(link)
+	if err != nil {
+		c.Errorf("\n Error getting issue from extracted link %s: %v\n", link, err)
+		return nil, nil
+	}
+	return item, host
+}
 
-		repo := gh.NewRepo(repoName, l.GHToken)
-		i, _ := strconv.Atoi(number)
+// resolveLink resolves a link with the code host it is to, returning nil without an error if it isn't to any of them
+func (l List) resolveLink(link string) (*LinkedItem, CodeHost, error) {
+	host := l.hostFor(link)
+	if host == nil {
+		return nil, nil, nil
+	}
 
-		if strings.Contains(link, "issues") {
-			issue, err := repo.GetIssue(i)
-			if err != nil {
-				c.Errorf("\n Error getting issue from extracted link %s: %v\n", link, err)
-				return nil, nil
-			}
-			return issue, &repo
-		}
+	item, err := host.GetItem(link)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting item from link %s: %v", link, err)
 	}
-	return nil, nil
+	return item, host, nil
+}
+
+// warnf prints a warning to stderr, so it doesn't end up in csv or json written to stdout
+func warnf(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, "warning: "+format+"\n", a...)
 }
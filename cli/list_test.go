@@ -0,0 +1,432 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	c "github.com/gookit/color"
+	"github.com/jirallreadyforthis/lib/bitbucket/bitbuckettest"
+	"github.com/jirallreadyforthis/lib/gh/ghtest"
+	"github.com/jirallreadyforthis/lib/gitlab/gitlabtest"
+	"github.com/jirallreadyforthis/lib/jira"
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+// captureOutput returns what f prints through the color package, with colour tags removed
+func captureOutput(t *testing.T, f func() error) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	c.SetOutput(&buf)
+	enabled := c.Enable
+	c.Enable = false
+	defer func() {
+		c.ResetOutput()
+		c.Enable = enabled
+	}()
+
+	if err := f(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return buf.String()
+}
+
+func listServers(t *testing.T) (*jiratest.Server, *ghtest.Server) {
+	t.Helper()
+
+	now := time.Now()
+	merged := now.AddDate(0, 0, -2)
+	longAgo := now.AddDate(0, 0, -60)
+
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Summary: "merged into main", Status: "In Review", Created: longAgo, Description: "fixed by https://github.com/acme/app/pull/1"},
+			{Key: "IPL-2", Summary: "closed as not planned", Status: "In Review", Created: longAgo, Description: "see https://github.com/acme/app/issues/2"},
+			{Key: "IPL-3", Summary: "still open", Status: "In Progress", Created: longAgo, Description: "wip https://github.com/acme/app/pull/3"},
+			{Key: "IPL-4", Summary: "one of two merged", Status: "In Progress", Created: longAgo, Comments: []jiratest.Comment{
+				{Body: "https://github.com/acme/app/pull/1 and https://github.com/acme/app/pull/3", Created: longAgo},
+			}},
+			{Key: "IPL-5", Summary: "merged into a feature branch", Status: "In Review", Created: longAgo, Description: "https://github.com/acme/app/pull/5"},
+			{Key: "IPL-6", Summary: "upstream fix", Status: "In Review", Created: longAgo, Description: "https://github.com/upstream/lib/issues/6"},
+		},
+	})
+	t.Cleanup(jiraServer.Close)
+
+	ghServer := ghtest.NewServer(ghtest.Seed{
+		Issues: []ghtest.Issue{
+			{Repo: "acme/app", Number: 1, Title: "fix one", State: "closed", ClosedAt: &merged, Pull: &ghtest.Pull{Merged: true, MergedAt: &merged, Base: "main", HeadSHA: "sha1"}},
+			{Repo: "acme/app", Number: 2, Title: "wont fix", State: "closed", StateReason: "not_planned", ClosedAt: &merged},
+			{Repo: "acme/app", Number: 3, Title: "wip", State: "open", Pull: &ghtest.Pull{Base: "main", HeadSHA: "sha3", Reviews: []ghtest.Review{{User: "reviewer", State: "APPROVED"}}}},
+			{Repo: "acme/app", Number: 5, Title: "feature work", State: "closed", ClosedAt: &merged, Pull: &ghtest.Pull{Merged: true, MergedAt: &merged, Base: "feature/x", HeadSHA: "sha5"}},
+			{Repo: "upstream/lib", Number: 6, Title: "upstream", State: "closed", StateReason: "completed", ClosedAt: &merged},
+		},
+		Statuses: map[string][]ghtest.Status{
+			"sha3": {{Context: "ci", State: "success"}, {Context: "lint", State: "success", CheckRun: true}},
+		},
+	})
+	t.Cleanup(ghServer.Close)
+
+	return jiraServer, ghServer
+}
+
+func listedKeys(output string) []string {
+	keys := make([]string, 0)
+	for _, line := range strings.Split(output, "\n") {
+		if i := strings.Index(line, "/browse/"); i >= 0 {
+			key := strings.Fields(line[i+len("/browse/"):])[0]
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestListJiraTickets(t *testing.T) {
+	jiraServer, ghServer := listServers(t)
+
+	cases := []struct {
+		name     string
+		list     List
+		expected []string
+	}{
+		{
+			name:     "any closed or merged",
+			list:     List{},
+			expected: []string{"IPL-1", "IPL-2", "IPL-4", "IPL-5", "IPL-6"},
+		},
+		{
+			name:     "require all",
+			list:     List{Require: RequireAll},
+			expected: []string{"IPL-1", "IPL-2", "IPL-5", "IPL-6"},
+		},
+		{
+			name:     "completed only",
+			list:     List{CloseReason: "completed"},
+			expected: []string{"IPL-1", "IPL-4", "IPL-5", "IPL-6"},
+		},
+		{
+			name:     "base branch",
+			list:     List{BaseBranches: []string{"main", "release/*"}},
+			expected: []string{"IPL-1", "IPL-2", "IPL-4", "IPL-6"},
+		},
+		{
+			name:     "repo allowlist",
+			list:     List{Repos: []string{"acme/*"}},
+			expected: []string{"IPL-1", "IPL-2", "IPL-4", "IPL-5"},
+		},
+		{
+			name:     "approved with passing checks",
+			list:     List{PRStates: []string{PRStateApproved, PRStateChecksPassing}},
+			expected: []string{"IPL-2", "IPL-3", "IPL-4", "IPL-6"},
+		},
+		{
+			name:     "rule",
+			list:     List{Rule: `any(prs, .merged && .base == "main") && issue.status == "In Review"`},
+			expected: []string{"IPL-1"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := tc.list
+			l.JiraUrl = jiraServer.URL
+			l.GHApiUrl = ghServer.URL
+			l.Jql = "project = IPL"
+			l.Linked = true
+
+			keys := listedKeys(captureOutput(t, l.ListJiraTickets))
+			if strings.Join(keys, ",") != strings.Join(tc.expected, ",") {
+				t.Fatalf("expected issues %v to be listed, got %v", tc.expected, keys)
+			}
+		})
+	}
+}
+
+func TestListJiraTicketsRequireRelease(t *testing.T) {
+	day := func(d int) *time.Time {
+		at := time.Now().AddDate(0, 0, d-10)
+		return &at
+	}
+
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Summary: "released", Status: "In Review", Description: "https://github.com/acme/app/pull/1"},
+			{Key: "IPL-2", Summary: "merged after the release", Status: "In Review", Description: "https://github.com/acme/app/pull/2"},
+			{Key: "IPL-3", Summary: "still open", Status: "In Review", Description: "https://github.com/acme/app/pull/3"},
+		},
+	})
+	defer jiraServer.Close()
+
+	merged := func(number int, sha string, at int) ghtest.Issue {
+		return ghtest.Issue{Repo: "acme/app", Number: number, Title: fmt.Sprintf("pr %d", number), State: "closed", ClosedAt: day(at),
+			Pull: &ghtest.Pull{Merged: true, MergedAt: day(at), Base: "main", MergeCommitSHA: sha}}
+	}
+	ghServer := ghtest.NewServer(ghtest.Seed{
+		Issues: []ghtest.Issue{
+			merged(1, "a", 1),
+			merged(2, "c", 3),
+			{Repo: "acme/app", Number: 3, Title: "pr 3", State: "open", Pull: &ghtest.Pull{Base: "main"}},
+		},
+		Commits: []ghtest.Commit{
+			{SHA: "a", Date: *day(1)},
+			{SHA: "b", Parents: []string{"a"}, Date: *day(2)},
+			{SHA: "c", Parents: []string{"b"}, Date: *day(3)},
+		},
+		Releases: []ghtest.Release{{Repo: "acme/app", Tag: "v1.0.0", Commit: "b", PublishedAt: *day(2)}},
+		Branches: map[string]string{"main": "c"},
+	})
+	defer ghServer.Close()
+
+	l := List{JiraUrl: jiraServer.URL, GHApiUrl: ghServer.URL, Jql: "project = IPL", Linked: true, RequireRelease: true}
+	out := captureOutput(t, l.ListJiraTickets)
+	if keys := listedKeys(out); strings.Join(keys, ",") != "IPL-1" {
+		t.Errorf("expected only the issue with a released pull request to be listed, got %v", keys)
+	}
+	if !strings.Contains(out, "released in v1.0.0") {
+		t.Errorf("expected the release to be shown, got %q", out)
+	}
+}
+
+func TestListJiraTicketsInvalidRule(t *testing.T) {
+	l := List{Rule: "all(prs, .merged &&"}
+
+	err := l.ListJiraTickets()
+	if err == nil || !strings.Contains(err.Error(), "parsing rule at column 20") {
+		t.Fatalf("expected a parse error for the rule, got %v", err)
+	}
+}
+
+// stubHost is a code host whose items are fixed, for testing that any CodeHost can be plugged into List
+type stubHost struct {
+	items map[string]*LinkedItem
+}
+
+func (s stubHost) FindLinks(text string) []string {
+	return regexp.MustCompile(`https://code\.example/[\w-]+/[\w-]+/\d+`).FindAllString(text, -1)
+}
+
+func (s stubHost) Repo(link string) string {
+	if item, ok := s.items[link]; ok {
+		return item.Repo
+	}
+	return ""
+}
+
+func (s stubHost) GetItem(link string) (*LinkedItem, error) {
+	if item, ok := s.items[link]; ok {
+		return item, nil
+	}
+	return nil, fmt.Errorf("no item %s", link)
+}
+
+func (s stubHost) ReviewDecision(item *LinkedItem) (string, error) {
+	return ReviewRequired, nil
+}
+
+func (s stubHost) ChecksState(item *LinkedItem) (string, error) {
+	return ChecksPending, nil
+}
+
+func (s stubHost) ReleaseContaining(item *LinkedItem) (string, error) {
+	return "", nil
+}
+
+func TestListJiraTicketsCodeHost(t *testing.T) {
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Summary: "merged elsewhere", Status: "In Review", Description: "https://code.example/acme/app/1"},
+			{Key: "IPL-2", Summary: "open elsewhere", Status: "In Review", Description: "https://code.example/acme/app/2"},
+			{Key: "IPL-3", Summary: "github link", Status: "In Review", Description: "https://github.com/acme/app/pull/3"},
+		},
+	})
+	defer jiraServer.Close()
+
+	merged := time.Now()
+	l := List{
+		Tracker: jira.Project{JiraUrl: jiraServer.URL},
+		Hosts: []CodeHost{stubHost{items: map[string]*LinkedItem{
+			"https://code.example/acme/app/1": {URL: "https://code.example/acme/app/1", Repo: "acme/app", Number: 1, State: "closed", ClosedAt: &merged, PullRequest: true, Merged: true, Base: "main"},
+			"https://code.example/acme/app/2": {URL: "https://code.example/acme/app/2", Repo: "acme/app", Number: 2, State: "open", PullRequest: true, Base: "main"},
+		}}},
+		Jql:    "project = IPL",
+		Linked: true,
+	}
+
+	keys := listedKeys(captureOutput(t, l.ListJiraTickets))
+	if strings.Join(keys, ",") != "IPL-1" {
+		t.Fatalf("expected only IPL-1 to be listed, got %v", keys)
+	}
+}
+
+// unreviewableHost is a stubHost whose review lookups fail
+type unreviewableHost struct {
+	stubHost
+}
+
+func (u unreviewableHost) ReviewDecision(item *LinkedItem) (string, error) {
+	return "", fmt.Errorf("reviews unavailable")
+}
+
+func TestListJiraTicketsRuleSkipsFailures(t *testing.T) {
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Summary: "reviews fail", Status: "In Review", Description: "https://code.example/acme/app/1"},
+			{Key: "IPL-2", Summary: "no links", Status: "In Review"},
+		},
+	})
+	defer jiraServer.Close()
+
+	l := List{
+		Tracker: jira.Project{JiraUrl: jiraServer.URL},
+		Hosts: []CodeHost{unreviewableHost{stubHost{items: map[string]*LinkedItem{
+			"https://code.example/acme/app/1": {URL: "https://code.example/acme/app/1", Repo: "acme/app", Number: 1, State: "open", PullRequest: true, Base: "main"},
+		}}}},
+		Jql:  "project = IPL",
+		Rule: `all(prs, .approved)`,
+	}
+
+	out := captureOutput(t, l.ListJiraTickets)
+	if keys := listedKeys(out); strings.Join(keys, ",") != "IPL-2" {
+		t.Errorf("expected the issue whose reviews couldn't be checked to be skipped, got %v", keys)
+	}
+	if !strings.Contains(out, "Error getting the linked items of IPL-1 for the rule: reviews unavailable") {
+		t.Errorf("expected the failure to be reported, got %q", out)
+	}
+
+	// a type error only fails the issues it happens for
+	l.Rule = `count(prs) == 0 || any(prs, .number)`
+	out = captureOutput(t, l.ListJiraTickets)
+	if keys := listedKeys(out); strings.Join(keys, ",") != "IPL-2" || !strings.Contains(out, "Error evaluating the rule for IPL-1") {
+		t.Errorf("expected IPL-2 to be listed and IPL-1 reported, got %q", out)
+	}
+}
+
+func TestListJiraTicketsGitLab(t *testing.T) {
+	merged := time.Now().AddDate(0, 0, -1)
+	gitlabServer := gitlabtest.NewServer(gitlabtest.Seed{
+		MergeRequests: []gitlabtest.MergeRequest{
+			{Project: "acme/services/api", IID: 1, Title: "merged", State: "merged", TargetBranch: "main", MergedAt: &merged},
+			{Project: "acme/services/api", IID: 2, Title: "open", State: "opened", TargetBranch: "main", Approved: true, PipelineStatus: "success"},
+			{Project: "acme/services/api", IID: 3, Title: "closed", State: "closed", TargetBranch: "main", ClosedAt: &merged},
+		},
+		Issues: []gitlabtest.Issue{
+			{Project: "acme/services/api", IID: 4, Title: "done", State: "closed", ClosedAt: &merged},
+		},
+	})
+	defer gitlabServer.Close()
+
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Summary: "merged mr", Status: "In Review", Description: gitlabServer.MergeRequestURL("acme/services/api", 1)},
+			{Key: "IPL-2", Summary: "open mr", Status: "In Review", Description: gitlabServer.MergeRequestURL("acme/services/api", 2)},
+			{Key: "IPL-3", Summary: "closed mr", Status: "In Review", Description: gitlabServer.MergeRequestURL("acme/services/api", 3)},
+			{Key: "IPL-4", Summary: "closed issue", Status: "In Review", Description: "[issue|" + gitlabServer.IssueURL("acme/services/api", 4) + "]"},
+		},
+	})
+	defer jiraServer.Close()
+
+	cases := []struct {
+		name     string
+		list     List
+		expected []string
+	}{
+		{
+			name:     "merged or closed",
+			list:     List{},
+			expected: []string{"IPL-1", "IPL-4"},
+		},
+		{
+			name:     "approved with passing checks",
+			list:     List{PRStates: []string{PRStateApproved, PRStateChecksPassing}},
+			expected: []string{"IPL-2", "IPL-4"},
+		},
+		{
+			name:     "repo allowlist",
+			list:     List{Repos: []string{"acme/services/*"}},
+			expected: []string{"IPL-1", "IPL-4"},
+		},
+		{
+			name:     "repo allowlist excludes",
+			list:     List{Repos: []string{"acme/*"}},
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := tc.list
+			l.JiraUrl = jiraServer.URL
+			l.Jql = "project = IPL"
+			l.Linked = true
+
+			keys := listedKeys(captureOutput(t, l.ListJiraTickets))
+			if strings.Join(keys, ",") != strings.Join(tc.expected, ",") {
+				t.Fatalf("expected issues %v to be listed, got %v", tc.expected, keys)
+			}
+		})
+	}
+}
+
+func TestListJiraTicketsBitbucket(t *testing.T) {
+	merged := time.Now().AddDate(0, 0, -1)
+	bitbucketServer := bitbuckettest.NewServer(bitbuckettest.Seed{
+		PullRequests: []bitbuckettest.PullRequest{
+			{Project: "acme", Slug: "app", ID: 1, Title: "merged", State: "MERGED", Destination: "main", ClosedAt: &merged},
+			{Project: "acme", Slug: "app", ID: 2, Title: "declined", State: "DECLINED", Destination: "main", ClosedAt: &merged},
+			{Project: "acme", Slug: "app", ID: 3, Title: "open", State: "OPEN", Destination: "main", SourceCommit: "sha3", Reviewers: map[string]string{"reviewer": "APPROVED"}},
+			{Project: "acme", Slug: "app", ID: 4, Title: "merged in cloud", State: "MERGED", Destination: "develop", ClosedAt: &merged},
+		},
+		Builds: map[string][]string{"sha3": {"SUCCESSFUL"}},
+	})
+	defer bitbucketServer.Close()
+
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Summary: "merged pr", Status: "In Review", Description: bitbucketServer.DataCenterURL("acme", "app", 1) + "/overview"},
+			{Key: "IPL-2", Summary: "declined pr", Status: "In Review", Description: bitbucketServer.DataCenterURL("acme", "app", 2)},
+			{Key: "IPL-3", Summary: "open pr", Status: "In Review", Description: bitbucketServer.DataCenterURL("acme", "app", 3)},
+			{Key: "IPL-4", Summary: "cloud pr", Status: "In Review", Description: "https://bitbucket.org/acme/app/pull-requests/4"},
+		},
+	})
+	defer jiraServer.Close()
+
+	cases := []struct {
+		name     string
+		list     List
+		expected []string
+	}{
+		{
+			name:     "merged",
+			list:     List{},
+			expected: []string{"IPL-1", "IPL-4"},
+		},
+		{
+			name:     "base branch",
+			list:     List{BaseBranches: []string{"main"}},
+			expected: []string{"IPL-1"},
+		},
+		{
+			name:     "approved with passing checks",
+			list:     List{PRStates: []string{PRStateApproved, PRStateChecksPassing}},
+			expected: []string{"IPL-3"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := tc.list
+			l.Tracker = jira.Project{JiraUrl: jiraServer.URL}
+			l.Hosts = []CodeHost{bitbucketHost{CloudApiUrl: bitbucketServer.CloudApiUrl()}}
+			l.Jql = "project = IPL"
+			l.Linked = true
+
+			keys := listedKeys(captureOutput(t, l.ListJiraTickets))
+			if strings.Join(keys, ",") != strings.Join(tc.expected, ",") {
+				t.Fatalf("expected issues %v to be listed, got %v", tc.expected, keys)
+			}
+		})
+	}
+}
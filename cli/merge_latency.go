@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mergeLatency is how long an issue took to get done after the last of its linked pull requests merged
+type mergeLatency struct {
+	Key      string `json:"key"`
+	URL      string `json:"url"`
+	Type     string `json:"type"`
+	Assignee string `json:"assignee"`
+	Status   string `json:"status"`
+	// PullRequest is the last linked pull request to merge
+	PullRequest string     `json:"pullRequest"`
+	MergedAt    time.Time  `json:"mergedAt"`
+	DoneAt      *time.Time `json:"doneAt,omitempty"`
+	// LatencyHours is from merge to done, or from merge until now for issues that aren't done
+	LatencyHours float64 `json:"latencyHours"`
+	Done         bool    `json:"done"`
+}
+
+type mergeLatencyGroup struct {
+	Name    string `json:"name"`
+	Count   int    `json:"count"`
+	Done    int    `json:"done"`
+	NotDone int    `json:"notDone"`
+	// Latency maps a percentile eg 'p85' to the hours from merge to done of the done issues
+	Latency     map[string]float64 `json:"latencyHours"`
+	MeanLatency *float64           `json:"meanLatencyHours,omitempty"`
+	// LongestWaiting is the most hours an issue that isn't done has been waiting since its pull request merged
+	LongestWaiting *float64 `json:"longestWaitingHours,omitempty"`
+}
+
+// MergeLatency reports how long issues take to get done after their linked pull requests merge
+func (a Analytics) MergeLatency() error {
+	format, err := a.format(FormatText, FormatCSV, FormatJSON)
+	if err != nil {
+		return err
+	}
+	if a.GroupBy != "" && a.GroupBy != GroupByType && a.GroupBy != GroupByAssignee && a.GroupBy != GroupByProject {
+		return fmt.Errorf("unknown grouping %q, expected one of %s, %s or %s", a.GroupBy, GroupByType, GroupByAssignee, GroupByProject)
+	}
+	percentiles, err := a.percentiles()
+	if err != nil {
+		return err
+	}
+	if a.Jql == "" {
+		return fmt.Errorf("a jql query is required")
+	}
+
+	latencies, unmerged, failed, err := a.mergeLatencies(time.Now())
+	if err != nil {
+		return err
+	}
+	groups := a.mergeLatencyGroups(latencies, percentiles)
+	for _, f := range failed {
+		warnf("%s, left out of the report", f)
+	}
+
+	switch format {
+	case FormatJSON:
+		out, err := json.MarshalIndent(map[string]interface{}{"issues": latencies, "groups": groups, "withoutMergedPullRequests": unmerged,
+			"lookupFailures": failed}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding merge latencies: %v", err)
+		}
+		fmt.Println(string(out))
+	case FormatCSV:
+		out, err := mergeLatencyCSV(latencies)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+	default:
+		fmt.Print(mergeLatencyText(latencies, groups, percentiles, unmerged, len(failed)))
+	}
+	return nil
+}
+
+// mergeLatencies finds the merge latency of every issue with a merged pull request, along with the number of issues
+// without one and the issues whose links couldn't be resolved
+func (a Analytics) mergeLatencies(now time.Time) ([]mergeLatency, int, []string, error) {
+	categories, err := a.statusCategories()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	p := a.tracker()
+	issues, err := p.ListIssues(a.Jql)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	latencies := make([]mergeLatency, 0)
+	unmerged := 0
+	failed := make([]string, 0)
+	for _, issue := range issues {
+		issueWithComments, err := p.GetIssue(issue.ID)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+
+		// an issue with a link that couldn't be resolved is left out, its last pull request to merge isn't known
+		var last *LinkedItem
+		var lookupErr error
+		for _, link := range a.findLinks(issue, issueWithComments) {
+			item, _, err := a.resolveLink(link)
+			if err != nil {
+				lookupErr = err
+				break
+			}
+			if item == nil || !item.PullRequest || !item.Merged || item.MergedAt == nil {
+				continue
+			}
+			if last == nil || item.MergedAt.After(*last.MergedAt) {
+				last = item
+			}
+		}
+		if lookupErr != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", issue.Key, lookupErr))
+			continue
+		}
+		if last == nil {
+			unmerged++
+			continue
+		}
+
+		withChangelog, err := p.GetIssueWithChangeLog(issue.ID)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("retrieving issueId %s with changelog: %v", issue.ID, err)
+		}
+		times, err := timesOf(withChangelog, categories, now)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+
+		l := mergeLatency{
+			Key:         issue.Key,
+			URL:         a.getJiraHtmlUrl(issue.Key),
+			Type:        times.Type,
+			Assignee:    times.Assignee,
+			Status:      times.Status,
+			PullRequest: last.URL,
+			MergedAt:    *last.MergedAt,
+			DoneAt:      times.Done,
+			Done:        times.Done != nil,
+		}
+		if l.Done {
+			l.LatencyHours = times.Done.Sub(l.MergedAt).Hours()
+		} else {
+			l.LatencyHours = now.Sub(l.MergedAt).Hours()
+		}
+		latencies = append(latencies, l)
+	}
+	return latencies, unmerged, failed, nil
+}
+
+func (a Analytics) mergeLatencyGroups(latencies []mergeLatency, percentiles []int) []mergeLatencyGroup {
+	byName := make(map[string][]mergeLatency)
+	names := make([]string, 0)
+	for _, l := range latencies {
+		name := a.groupName(l.Key, l.Type, l.Assignee)
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], l)
+	}
+	sort.Strings(names)
+
+	groups := make([]mergeLatencyGroup, 0)
+	for _, name := range names {
+		group := mergeLatencyGroup{Name: name, Count: len(byName[name])}
+		done := make([]float64, 0)
+		for _, l := range byName[name] {
+			if l.Done {
+				group.Done++
+				done = append(done, l.LatencyHours)
+				continue
+			}
+			group.NotDone++
+			if group.LongestWaiting == nil || l.LatencyHours > *group.LongestWaiting {
+				waiting := l.LatencyHours
+				group.LongestWaiting = &waiting
+			}
+		}
+		group.Latency = percentileMap(done, percentiles)
+		if len(done) > 0 {
+			total := 0.0
+			for _, hours := range done {
+				total += hours
+			}
+			mean := total / float64(len(done))
+			group.MeanLatency = &mean
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func mergeLatencyText(latencies []mergeLatency, groups []mergeLatencyGroup, percentiles []int, unmerged int, failed int) string {
+	b := &strings.Builder{}
+
+	sorted := append([]mergeLatency{}, latencies...)
+	sort.SliceStable(sorted, func(i, k int) bool { return sorted[i].LatencyHours > sorted[k].LatencyHours })
+	fmt.Fprintf(b, "\nIssues by merge to done latency\n")
+	for _, l := range sorted {
+		done := "done " + formatDate(l.DoneAt)
+		if !l.Done {
+			done = "not done, " + l.Status
+		}
+		fmt.Fprintf(b, "  %s\t%s\tmerged %s\t%s\t%s\n", formatHours(l.LatencyHours), l.URL, formatDate(&l.MergedAt), done, l.PullRequest)
+	}
+
+	for _, group := range groups {
+		fmt.Fprintf(b, "\n%s (%d issues, %d done, %d not done)\n", group.Name, group.Count, group.Done, group.NotDone)
+		if group.MeanLatency != nil {
+			fmt.Fprintf(b, "  merge to done: mean %s", formatHours(*group.MeanLatency))
+			for _, p := range percentiles {
+				fmt.Fprintf(b, " p%d %s", p, formatHours(group.Latency[fmt.Sprintf("p%d", p)]))
+			}
+			fmt.Fprintln(b)
+		}
+		if group.LongestWaiting != nil {
+			fmt.Fprintf(b, "  longest waiting since merge: %s\n", formatHours(*group.LongestWaiting))
+		}
+	}
+
+	if unmerged > 0 {
+		fmt.Fprintf(b, "\n%d issues have no merged pull requests\n", unmerged)
+	}
+	if failed > 0 {
+		fmt.Fprintf(b, "\n%d issues have links that couldn't be checked and are left out\n", failed)
+	}
+	return b.String()
+}
+
+func mergeLatencyCSV(latencies []mergeLatency) (string, error) {
+	b := &strings.Builder{}
+	w := csv.NewWriter(b)
+	if err := w.Write([]string{"key", "type", "assignee", "status", "pull_request", "merged_at", "done_at", "done", "latency_hours"}); err != nil {
+		return "", fmt.Errorf("writing csv: %v", err)
+	}
+	for _, l := range latencies {
+		doneAt := ""
+		if l.DoneAt != nil {
+			doneAt = l.DoneAt.Format(time.RFC3339)
+		}
+		row := []string{l.Key, l.Type, l.Assignee, l.Status, l.PullRequest, l.MergedAt.Format(time.RFC3339), doneAt,
+			strconv.FormatBool(l.Done), strconv.FormatFloat(l.LatencyHours, 'f', 2, 64)}
+		if err := w.Write(row); err != nil {
+			return "", fmt.Errorf("writing csv: %v", err)
+		}
+	}
+	w.Flush()
+	return b.String(), w.Error()
+}
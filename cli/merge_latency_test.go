@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/gh/ghtest"
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestMergeLatency(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	daysAgo := func(n int) time.Time { return now.AddDate(0, 0, -n) }
+	done := func(at time.Time) []jiratest.History {
+		return []jiratest.History{{Author: "someone", Created: at, Items: []jiratest.HistoryItem{{Field: "status", From: "In Review", To: "Done"}}}}
+	}
+	merged := func(n int) *time.Time {
+		t := daysAgo(n)
+		return &t
+	}
+
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "Done", StatusCategory: "done", Created: daysAgo(40), Changelog: done(daysAgo(20)), Description: "https://github.com/acme/app/pull/1 https://github.com/acme/app/pull/2"},
+			{Key: "IPL-2", Status: "Done", StatusCategory: "done", Created: daysAgo(40), Changelog: done(daysAgo(29)), Description: "https://github.com/acme/app/pull/3"},
+			{Key: "OPS-3", Status: "In Review", StatusCategory: "indeterminate", Created: daysAgo(40), Description: "https://github.com/acme/app/pull/3"},
+			{Key: "OPS-4", Status: "In Review", StatusCategory: "indeterminate", Created: daysAgo(40), Description: "https://github.com/acme/app/pull/4"},
+			{Key: "OPS-5", Status: "Done", StatusCategory: "done", Created: daysAgo(40), Changelog: done(daysAgo(5)), Description: "https://github.com/acme/app/pull/404"},
+		},
+		StatusCategories: map[string]string{"In Review": "indeterminate", "Done": "done"},
+	})
+	defer jiraServer.Close()
+
+	ghServer := ghtest.NewServer(ghtest.Seed{
+		Issues: []ghtest.Issue{
+			{Repo: "acme/app", Number: 1, State: "closed", Pull: &ghtest.Pull{Merged: true, MergedAt: merged(35)}},
+			{Repo: "acme/app", Number: 2, State: "closed", Pull: &ghtest.Pull{Merged: true, MergedAt: merged(30)}},
+			{Repo: "acme/app", Number: 3, State: "closed", Pull: &ghtest.Pull{Merged: true, MergedAt: merged(30)}},
+			{Repo: "acme/app", Number: 4, State: "open", Pull: &ghtest.Pull{}},
+		},
+	})
+	defer ghServer.Close()
+
+	a := Analytics{List: List{JiraUrl: jiraServer.URL, GHApiUrl: ghServer.URL, Jql: "project in (IPL, OPS)"}, GroupBy: GroupByProject}
+	var latencies []mergeLatency
+	var unmerged int
+	var failed []string
+	stdout := captureOutput(t, func() (err error) {
+		latencies, unmerged, failed, err = a.mergeLatencies(now)
+		return err
+	})
+	if stdout != "" {
+		t.Errorf("expected nothing written to stdout while finding latencies, got %q", stdout)
+	}
+	if unmerged != 1 || len(latencies) != 3 {
+		t.Fatalf("expected 3 issues with merged pull requests and 1 without, got %d and %d", len(latencies), unmerged)
+	}
+	if len(failed) != 1 || !strings.HasPrefix(failed[0], "OPS-5: ") {
+		t.Errorf("expected the failed link lookup of OPS-5 to be reported separately, got %v", failed)
+	}
+
+	expected := map[string]float64{"IPL-1": 10 * 24, "IPL-2": 24, "OPS-3": 30 * 24}
+	for _, l := range latencies {
+		if l.LatencyHours != expected[l.Key] {
+			t.Errorf("expected %s to have a latency of %vh, got %v", l.Key, expected[l.Key], l.LatencyHours)
+		}
+	}
+	if latencies[0].PullRequest != "https://github.com/acme/app/pull/2" {
+		t.Errorf("expected the latency of IPL-1 to be from its last pull request to merge, got %s", latencies[0].PullRequest)
+	}
+
+	groups := a.mergeLatencyGroups(latencies, []int{50})
+	if len(groups) != 2 || groups[0].Name != "IPL" || *groups[0].MeanLatency != 132 || groups[0].Latency["p50"] != 24 {
+		t.Errorf("expected project IPL to have a mean latency of 132h and p50 of 24h, got %+v", groups)
+	}
+	if ops := groups[1]; ops.NotDone != 1 || *ops.LongestWaiting != 30*24 {
+		t.Errorf("expected project OPS to have 1 issue waiting for 30 days, got %+v", ops)
+	}
+
+	text := mergeLatencyText(latencies, groups, []int{50}, unmerged, len(failed))
+	if !strings.Contains(text, "30.0d") || !strings.Contains(text, "1 issues have no merged pull requests") || !strings.Contains(text, "1 issues have links that couldn't be checked") {
+		t.Errorf("expected the longest waiting issue and the unmerged count in the report, got %q", text)
+	}
+}
@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	j "github.com/andygrunwald/go-jira"
+	c "github.com/gookit/color"
+)
+
+// the pull request lifecycle events pr-sync maps to jira statuses
+const (
+	PREventOpened           = "opened"
+	PREventReviewRequested  = "review-requested"
+	PREventChangesRequested = "changes-requested"
+	PREventApproved         = "approved"
+	PREventMerged           = "merged"
+	PREventClosed           = "closed"
+)
+
+// prEventOrder is how far along its lifecycle each event puts a pull request. When an issue has several open pull
+// requests the least advanced one decides its status
+var prEventOrder = []string{PREventChangesRequested, PREventOpened, PREventReviewRequested, PREventApproved}
+
+var DefaultPRStatusMap = map[string]string{
+	PREventOpened:           "In Progress",
+	PREventReviewRequested:  "In Review",
+	PREventChangesRequested: "In Progress",
+	PREventApproved:         "In Review",
+	PREventMerged:           "Done",
+	PREventClosed:           "To Do",
+}
+
+// PRSync moves jira issues through their statuses to follow the lifecycle of their linked pull requests.
+// The embedded List provides the link discovery options
+type PRSync struct {
+	List
+	DryRun   bool
+	CheckLog bool
+	// Guard is the policy for protecting manual changes when CheckLog is set
+	Guard       Guard
+	Debug       bool
+	Transitions []string
+	// StatusMap overrides the status for pull request events, in the format 'event=status' eg 'merged=Closed'
+	StatusMap []string
+	// PollInterval is how often to sync, it only syncs once when zero
+	PollInterval time.Duration
+}
+
+func (s PRSync) statusMap() (map[string]string, error) {
+	statuses := make(map[string]string)
+	for event, status := range DefaultPRStatusMap {
+		statuses[event] = status
+	}
+
+	for _, mapping := range s.StatusMap {
+		parts := strings.SplitN(mapping, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("parsing pr status mapping %q, expected the format 'event=status'", mapping)
+		}
+		event := strings.ToLower(strings.TrimSpace(parts[0]))
+		if _, ok := DefaultPRStatusMap[event]; !ok {
+			return nil, fmt.Errorf("unknown pr event %q, expected one of %s, %s, %s, %s, %s or %s", event, PREventOpened, PREventReviewRequested, PREventChangesRequested, PREventApproved, PREventMerged, PREventClosed)
+		}
+		// an empty status turns off syncing for an event
+		statuses[event] = strings.TrimSpace(parts[1])
+	}
+
+	return statuses, nil
+}
+
+func (s PRSync) Sync() error {
+	if s.Jql == "" {
+		return fmt.Errorf("a jql query is required to sync pull requests")
+	}
+
+	statuses, err := s.statusMap()
+	if err != nil {
+		return err
+	}
+
+	for {
+		if err := s.sync(statuses); err != nil {
+			return err
+		}
+		if s.PollInterval <= 0 {
+			return nil
+		}
+		fmt.Printf("waiting %s until the next sync\n", s.PollInterval)
+		time.Sleep(s.PollInterval)
+	}
+}
+
+func (s PRSync) sync(statuses map[string]string) error {
+	p := s.tracker()
+	setStatus := SetStatus{
+		UserName:    s.UserName,
+		Transitions: s.Transitions,
+		Debug:       s.Debug,
+		Guard:       s.Guard,
+		Tracker:     p,
+	}
+
+	if s.CheckLog {
+		guard, err := s.Guard.withDefaultBots(s.UserName, p)
+		if err != nil {
+			return err
+		}
+		setStatus.Guard = guard
+	}
+
+	issues, err := p.ListIssues(s.Jql)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, issue := range issues {
+		issueWithComments, err := p.GetIssue(issue.ID)
+		if err != nil {
+			return err
+		}
+
+		event, pr := s.issueEvent(issue, issueWithComments)
+		if pr == nil {
+			continue
+		}
+		target := statuses[event]
+		if target == "" || issue.Fields.Status == nil || strings.EqualFold(issue.Fields.Status.Name, target) {
+			continue
+		}
+
+		// if the status has recently been changed by hand we should avoid reverting this back, this is checked before
+		// a dry run reports the move so it reports what a real run would do
+		if s.CheckLog {
+			var mergedAt *time.Time
+			if event == PREventMerged {
+				mergedAt = pr.MergedAt
+			}
+			skip, err := setStatus.guarded(issue, target, mergedAt, p)
+			if err != nil {
+				return err
+			}
+			if skip {
+				continue
+			}
+		}
+
+		fmt.Printf("moving issue %s from %s to %s as %s is %s\n", issue.Key, issue.Fields.Status.Name, target, pr.URL, event)
+		if s.DryRun {
+			count++
+			continue
+		}
+		if err := setStatus.transitionIssueTo(issue, target, p); err != nil {
+			return err
+		}
+		count++
+	}
+
+	c.Info.Printf("\nFinished syncing %d issues with their pull requests\n", count)
+
+	return nil
+}
+
+// issueEvent works out the lifecycle event for the pull requests linked to an issue, returning it along with the
+// pull request that decided it, or nil if the issue has no linked pull requests
+func (s PRSync) issueEvent(issue j.Issue, issueWithComments *j.Issue) (string, *LinkedItem) {
+	event := ""
+	var open, merged, closed *LinkedItem
+	openIndex := len(prEventOrder)
+
+	for _, link := range s.findLinks(issue, issueWithComments) {
+		item, host := s.getLinkedItem(link)
+		if item == nil || !item.PullRequest {
+			continue
+		}
+
+		switch {
+		case item.Merged:
+			// the latest merge is the one that finished the work
+			if merged == nil || (item.MergedAt != nil && merged.MergedAt != nil && item.MergedAt.After(*merged.MergedAt)) {
+				merged = item
+			}
+		case item.State == "closed":
+			closed = item
+		default:
+			e := s.openPullRequestEvent(item, host)
+			for i, ordered := range prEventOrder {
+				if ordered == e && i < openIndex {
+					openIndex, event, open = i, e, item
+				}
+			}
+		}
+	}
+
+	// open pull requests mean work is still going on, whatever happened to the others
+	if open != nil {
+		return event, open
+	}
+	if merged != nil {
+		return PREventMerged, merged
+	}
+	if closed != nil {
+		return PREventClosed, closed
+	}
+	return "", nil
+}
+
+func (s PRSync) openPullRequestEvent(item *LinkedItem, host CodeHost) string {
+	if item.Draft {
+		return PREventOpened
+	}
+
+	decision, err := host.ReviewDecision(item)
+	if err != nil {
+		c.Errorf("Error getting review decision for pr %s: %v\n", item.URL, err)
+		decision = ""
+	}
+
+	switch {
+	case decision == ReviewChangesRequested:
+		return PREventChangesRequested
+	case decision == ReviewApproved:
+		return PREventApproved
+	// github clears the requested reviewers once they review, so a pull request with only comments is still in review
+	case item.ReviewRequested || decision == ReviewCommented:
+		return PREventReviewRequested
+	}
+	return PREventOpened
+}
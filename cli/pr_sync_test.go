@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/gh/ghtest"
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestPRSync(t *testing.T) {
+	merged := time.Now().AddDate(0, 0, -1)
+
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "To Do", Description: "https://github.com/acme/app/pull/1"},
+			{Key: "IPL-2", Status: "In Progress", Description: "https://github.com/acme/app/pull/2"},
+			{Key: "IPL-3", Status: "In Review", Description: "https://github.com/acme/app/pull/3"},
+			{Key: "IPL-4", Status: "In Progress", Description: "https://github.com/acme/app/pull/4"},
+			{Key: "IPL-5", Status: "In Review", Description: "https://github.com/acme/app/pull/5"},
+			// one pull request is merged but another is still open, so the work isn't done
+			{Key: "IPL-6", Status: "In Progress", Description: "https://github.com/acme/app/pull/4 https://github.com/acme/app/pull/1"},
+			{Key: "IPL-7", Status: "In Progress", Description: "no pull requests yet"},
+			// github clears the requested reviewers once they comment, which shouldn't move the issue out of review
+			{Key: "IPL-8", Status: "In Review", Description: "https://github.com/acme/app/pull/8"},
+		},
+		Transitions: []jiratest.Transition{
+			{ID: "11", Name: "In Progress", From: []string{"To Do", "In Review"}, To: "In Progress"},
+			{ID: "21", Name: "In Review", From: []string{"In Progress"}, To: "In Review"},
+			{ID: "31", Name: "Done", From: []string{"In Review"}, To: "Done"},
+			{ID: "41", Name: "Back to backlog", To: "To Do"},
+		},
+	})
+	defer jiraServer.Close()
+
+	ghServer := ghtest.NewServer(ghtest.Seed{
+		Issues: []ghtest.Issue{
+			{Repo: "acme/app", Number: 1, State: "open", Pull: &ghtest.Pull{Base: "main"}},
+			{Repo: "acme/app", Number: 2, State: "open", Pull: &ghtest.Pull{Base: "main", RequestedReviewers: []string{"reviewer"}}},
+			{Repo: "acme/app", Number: 3, State: "open", Pull: &ghtest.Pull{Base: "main", Reviews: []ghtest.Review{{User: "reviewer", State: "CHANGES_REQUESTED"}}}},
+			{Repo: "acme/app", Number: 4, State: "closed", ClosedAt: &merged, Pull: &ghtest.Pull{Base: "main", Merged: true, MergedAt: &merged}},
+			{Repo: "acme/app", Number: 5, State: "closed", ClosedAt: &merged, Pull: &ghtest.Pull{Base: "main"}},
+			{Repo: "acme/app", Number: 8, State: "open", Pull: &ghtest.Pull{Base: "main", Reviews: []ghtest.Review{{User: "reviewer", State: "COMMENTED"}}}},
+		},
+	})
+	defer ghServer.Close()
+
+	s := PRSync{
+		List: List{
+			JiraUrl:  jiraServer.URL,
+			GHApiUrl: ghServer.URL,
+			Jql:      "project = IPL",
+		},
+		Transitions: []string{"to do;in progress;in review;done"},
+	}
+	captureOutput(t, s.Sync)
+
+	expected := map[string]string{
+		"IPL-1": "In Progress",
+		"IPL-2": "In Review",
+		"IPL-3": "In Progress",
+		"IPL-4": "Done",
+		"IPL-5": "To Do",
+		"IPL-6": "In Progress",
+		"IPL-7": "In Progress",
+		"IPL-8": "In Review",
+	}
+	for key, status := range expected {
+		if actual := jiraServer.Issue(key).Status; actual != status {
+			t.Errorf("expected %s to have status %q, got %q", key, status, actual)
+		}
+	}
+}
+
+func TestPRSyncStatusMap(t *testing.T) {
+	s := PRSync{StatusMap: []string{"merged=Closed", "closed="}}
+	statuses, err := s.statusMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if statuses[PREventMerged] != "Closed" || statuses[PREventClosed] != "" || statuses[PREventOpened] != "In Progress" {
+		t.Errorf("unexpected status map %v", statuses)
+	}
+
+	s.StatusMap = []string{"reopened=To Do"}
+	if _, err := s.statusMap(); err == nil {
+		t.Errorf("expected an error for an unknown event")
+	}
+}
+
+func TestPRSyncDryRunGuard(t *testing.T) {
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "In Progress", Description: "https://github.com/acme/app/pull/1", Changelog: []jiratest.History{
+				{Author: "someone", Created: time.Now().AddDate(0, 0, -1), Items: []jiratest.HistoryItem{{Field: "status", From: "In Review", To: "In Progress"}}},
+			}},
+		},
+	})
+	defer jiraServer.Close()
+
+	ghServer := ghtest.NewServer(ghtest.Seed{
+		Issues: []ghtest.Issue{
+			{Repo: "acme/app", Number: 1, State: "open", Pull: &ghtest.Pull{Base: "main", RequestedReviewers: []string{"reviewer"}}},
+		},
+	})
+	defer ghServer.Close()
+
+	s := PRSync{
+		List:     List{JiraUrl: jiraServer.URL, GHApiUrl: ghServer.URL, Jql: "project = IPL"},
+		DryRun:   true,
+		CheckLog: true,
+		Guard:    Guard{Within: "7d"},
+	}
+	out := captureOutput(t, s.Sync)
+	if !strings.Contains(out, "NOT updating issue IPL-1") {
+		t.Errorf("expected a dry run to report that the guard skips the move, got %q", out)
+	}
+}
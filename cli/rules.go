@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	j "github.com/andygrunwald/go-jira"
+	"github.com/jirallreadyforthis/lib/rules"
+)
+
+// loadRule parses the rule from either the rule flag or the rule file, returning nil if neither is set.
+// Lines in a rule file starting with '#' are treated as comments and the remaining lines are joined into one rule
+func (l List) loadRule() (*rules.Rule, error) {
+	source := l.Rule
+	if l.RuleFile != "" {
+		if source != "" {
+			return nil, fmt.Errorf("only one of a rule or a rule file can be set")
+		}
+
+		b, err := os.ReadFile(l.RuleFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading rule file %s: %v", l.RuleFile, err)
+		}
+
+		lines := make([]string, 0)
+		for _, line := range strings.Split(string(b), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			lines = append(lines, line)
+		}
+		source = strings.Join(lines, " ")
+	}
+
+	if source == "" {
+		return nil, nil
+	}
+
+	return rules.Parse(source)
+}
+
+// ruleEnv builds the model a rule is evaluated against from a jira issue and the items linked to it,
+// returning it along with a description of each linked item for output
+func (l List) ruleEnv(rule *rules.Rule, issue j.Issue, issueWithComments *j.Issue) (rules.Env, []string, error) {
+	env := rules.Env{
+		Issue: jiraIssueModel(issue, issueWithComments),
+	}
+	linked := make([]string, 0)
+
+	for _, link := range l.findLinks(issue, issueWithComments) {
+		item, host := l.getLinkedItem(link)
+		if item == nil {
+			continue
+		}
+
+		if !item.PullRequest {
+			env.Issues = append(env.Issues, rules.GithubIssue{
+				Number:      item.Number,
+				URL:         item.URL,
+				Repo:        item.Repo,
+				Title:       item.Title,
+				State:       item.State,
+				Closed:      item.State == "closed",
+				ClosedAt:    item.ClosedAt,
+				StateReason: item.StateReason,
+			})
+			linked = append(linked, describeItem(item))
+			continue
+		}
+
+		model := rules.PullRequest{
+			Number:   item.Number,
+			URL:      item.URL,
+			Repo:     item.Repo,
+			Title:    item.Title,
+			State:    item.State,
+			Draft:    item.Draft,
+			Merged:   item.Merged,
+			Base:     item.Base,
+			Head:     item.Head,
+			MergedAt: item.MergedAt,
+			ClosedAt: item.ClosedAt,
+		}
+
+		// reviews and checks cost extra requests so are only fetched if the rule looks at them
+		if rule.References("approved") {
+			decision, err := host.ReviewDecision(item)
+			if err != nil {
+				return env, nil, err
+			}
+			model.Approved = decision == ReviewApproved
+		}
+		if rule.References("checks") {
+			checks, err := host.ChecksState(item)
+			if err != nil {
+				return env, nil, err
+			}
+			model.Checks = checks
+		}
+
+		env.PullRequests = append(env.PullRequests, model)
+
+		linked = append(linked, describeItem(item))
+	}
+
+	return env, linked, nil
+}
+
+func jiraIssueModel(issue j.Issue, issueWithComments *j.Issue) rules.Issue {
+	model := rules.Issue{
+		Key:     issue.Key,
+		Summary: issue.Fields.Summary,
+		Type:    issue.Fields.Type.Name,
+		Labels:  issue.Fields.Labels,
+	}
+
+	if status := issue.Fields.Status; status != nil {
+		model.Status = status.Name
+		model.StatusCategory = status.StatusCategory.Name
+	}
+	if assignee := issue.Fields.Assignee; assignee != nil {
+		model.Assignee = assignee.DisplayName
+	}
+
+	created := time.Time(issue.Fields.Created)
+	if !created.IsZero() {
+		model.Created = &created
+	}
+	updated := time.Time(issue.Fields.Updated)
+	if !updated.IsZero() {
+		model.Updated = &updated
+	}
+
+	if issueWithComments != nil && issueWithComments.Fields.Comments != nil {
+		comments := issueWithComments.Fields.Comments.Comments
+		model.Comments = len(comments)
+		if len(comments) > 0 {
+			if t, err := time.Parse(jiraTimeFormat, comments[len(comments)-1].Created); err == nil {
+				model.LastComment = &t
+			}
+		}
+	}
+
+	return model
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	j "github.com/andygrunwald/go-jira"
 	c "github.com/gookit/color"
@@ -20,14 +21,37 @@ type SetStatus struct {
 	Transitions []string
 	Debug       bool
 	CheckLog    bool
+	// Guard is the policy for protecting manual changes when CheckLog is set
+	Guard Guard
+	// Tracker is where issues are transitioned, defaults to jira
+	Tracker IssueTracker
 }
 
-func (s SetStatus) SetStatus() error {
-	p := jira.Project{
+func (s SetStatus) tracker() IssueTracker {
+	if s.Tracker != nil {
+		return s.Tracker
+	}
+	return jira.Project{
 		Token:    s.JiraToken,
 		UserName: s.UserName,
 		JiraUrl:  s.JiraUrl,
 	}
+}
+
+func (s SetStatus) SetStatus() error {
+	p := s.tracker()
+
+	if s.CheckLog {
+		// set-status isn't told which pull request prompted a change, so there is no merge time to check against
+		if s.Guard.SkipIfTouchedSinceMerge {
+			return fmt.Errorf("--skip-if-touched-since-merge needs the merge time of a linked pull request, use it with pr-sync instead")
+		}
+		guard, err := s.Guard.withDefaultBots(s.UserName, p)
+		if err != nil {
+			return err
+		}
+		s.Guard = guard
+	}
 
 	count := 0
 	if len(s.IssueKeys) > 0 {
@@ -36,25 +60,13 @@ func (s SetStatus) SetStatus() error {
 			if err != nil {
 				return err
 			}
-			if s.DryRun {
-				fmt.Printf("setting issue (key %s id %s) to new status\n", issueKey, issue.ID)
-			} else {
-				if s.CheckLog {
-					transitions := strings.Split(s.Transitions[0], ",")
-					// status to transition to
-					status := transitions[len(transitions)-1]
-
-					// if the status has recently changed from the status we are aiming to transition to we should avoid reverting this back
-					if issueIsRecentlyTransitioned(issue.ID, status, p) {
-						continue
-					}
-				}
-				err = s.transitionIssue(*issue, p)
-				if err != nil {
-					return err
-				}
+			updated, err := s.updateIssue(*issue, p)
+			if err != nil {
+				return err
+			}
+			if updated {
+				count++
 			}
-			count++
 		}
 	} else if s.Jql != "" {
 		issues, err := p.ListIssues(s.Jql)
@@ -63,25 +75,13 @@ func (s SetStatus) SetStatus() error {
 		}
 
 		for _, issue := range issues {
-			if s.DryRun {
-				fmt.Printf("setting issue (key %s id %s) to new status\n", issue.Key, issue.ID)
-			} else {
-				if s.CheckLog {
-					transitions := strings.Split(s.Transitions[0], ",")
-					// status to transition to
-					status := transitions[len(transitions)-1]
-
-					// if the status has recently changed from the status we are aiming to transition to we should avoid reverting this back
-					if issueIsRecentlyTransitioned(issue.ID, status, p) {
-						continue
-					}
-				}
-				err = s.transitionIssue(issue, p)
-				if err != nil {
-					return err
-				}
+			updated, err := s.updateIssue(issue, p)
+			if err != nil {
+				return err
+			}
+			if updated {
+				count++
 			}
-			count++
 		}
 	}
 
@@ -90,7 +90,34 @@ func (s SetStatus) SetStatus() error {
 	return nil
 }
 
-func (s SetStatus) transitionIssue(issue j.Issue, p jira.Project) error {
+// updateIssue moves an issue to its new status unless the guard skips it, a dry run reports the same skips
+func (s SetStatus) updateIssue(issue j.Issue, p IssueTracker) (bool, error) {
+	if s.CheckLog {
+		// if the status has recently been changed by hand we should avoid reverting this back
+		skip, err := s.guarded(issue, s.targetStatus(issue), nil, p)
+		if err != nil || skip {
+			return false, err
+		}
+	}
+
+	if s.DryRun {
+		fmt.Printf("setting issue (key %s id %s) to new status\n", issue.Key, issue.ID)
+		return true, nil
+	}
+	if err := s.transitionIssue(issue, p); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s SetStatus) transitionIssue(issue j.Issue, p IssueTracker) error {
+	return s.transitionIssueTo(issue, "", p)
+}
+
+// transitionIssueTo moves an issue along the first workflow it is in until it reaches the target status, or to the
+// end of the workflow if there is no target. When no workflow leads to the target the issue is moved straight to it
+// if jira allows
+func (s SetStatus) transitionIssueTo(issue j.Issue, target string, p IssueTracker) error {
 	if s.Debug {
 		fmt.Printf("attempting to transition status on issue %s\n", issue.Key)
 	}
@@ -98,11 +125,18 @@ func (s SetStatus) transitionIssue(issue j.Issue, p jira.Project) error {
 	foundWorkflow := false
 	currentStatus := strings.ToLower(issue.Fields.Status.Name)
 	originalStatus := currentStatus
+	target = strings.ToLower(target)
 	transitioned := false
 
 	for _, transition := range s.Transitions {
 		workflow := strings.Split(transition, ";")
+		if target != "" && !workflowLeadsTo(workflow, currentStatus, target) {
+			continue
+		}
 		for i, status := range workflow {
+			if target != "" && currentStatus == target {
+				break
+			}
 			// find where the issue is in the chain and keep transitioning to the next status until we get to the end of the workflow
 			if currentStatus == strings.ToLower(status) {
 				foundWorkflow = true
@@ -148,6 +182,29 @@ func (s SetStatus) transitionIssue(issue j.Issue, p jira.Project) error {
 		}
 	}
 
+	if !foundWorkflow && target != "" && currentStatus != target {
+		possibleTransitions, err := p.GetPossibleIssueTransitions(issue.ID)
+		if err != nil {
+			return err
+		}
+		for _, pt := range possibleTransitions {
+			if strings.ToLower(pt.Name) == target || strings.ToLower(pt.To.Name) == target {
+				if s.Debug {
+					fmt.Printf("transitioning %s from %s straight to status %s\n", issue.Key, currentStatus, target)
+				}
+				if err := p.TransitionIssueStatus(issue.ID, pt.ID); err != nil {
+					return err
+				}
+				currentStatus = target
+				transitioned = true
+				break
+			}
+		}
+		if !transitioned {
+			c.Warn.Printf("no workflow or transition leads from status '%s' to '%s' for issue %s\n", currentStatus, target, issue.Key)
+		}
+	}
+
 	if transitioned {
 		c.Info.Sprintf("Transitioned issue %s from %s to %s", issue.Key, originalStatus, currentStatus)
 	}
@@ -155,7 +212,22 @@ func (s SetStatus) transitionIssue(issue j.Issue, p jira.Project) error {
 	return nil
 }
 
-func getIssueFromKey(key string, p jira.Project) (*j.Issue, error) {
+// workflowLeadsTo reports whether a workflow passes through the current status and later reaches the target
+func workflowLeadsTo(workflow []string, current string, target string) bool {
+	currentIndex := -1
+	for i, status := range workflow {
+		status = strings.ToLower(status)
+		if status == current && currentIndex == -1 {
+			currentIndex = i
+		}
+		if status == target && currentIndex != -1 && i > currentIndex {
+			return true
+		}
+	}
+	return false
+}
+
+func getIssueFromKey(key string, p IssueTracker) (*j.Issue, error) {
 	jql := fmt.Sprintf("issueKey = %s", key)
 	issues, err := p.ListIssues(jql)
 	if err != nil {
@@ -168,33 +240,37 @@ func getIssueFromKey(key string, p jira.Project) (*j.Issue, error) {
 	return &issues[0], nil
 }
 
-func issueIsRecentlyTransitioned(issueId string, status string, p jira.Project) bool {
+// targetStatus is the status at the end of the first workflow the issue is in, or "" if it isn't in one
+func (s SetStatus) targetStatus(issue j.Issue) string {
+	if issue.Fields.Status == nil {
+		return ""
+	}
 
-	issue, err := p.GetIssueWithChangeLog(issueId)
-	if err != nil {
-		c.Errorf("retrieving issueId %s with changelog", issueId)
-		return false
-	}
-
-	if issue != nil {
-		if changelog := issue.Changelog; changelog != nil {
-			if histories := changelog.Histories; histories != nil {
-				for _, history := range histories {
-					if items := history.Items; items != nil {
-						// check only the most recent changelog entry
-						if len(items) > 0 {
-							if items[0].Field == "status" {
-								if strings.ToLower(items[0].FromString) == strings.ToLower(status) {
-									c.Warn.Sprintf("NOT updating issue %s as it was updated from status %q to %q on %s", issue.Key, items[0].FromString, items[0].ToString, history.Created)
-									return true
-								}
-							}
-						}
-					}
-					return false
-				}
+	for _, transition := range s.Transitions {
+		workflow := strings.Split(transition, ";")
+		for _, status := range workflow {
+			if strings.EqualFold(status, issue.Fields.Status.Name) {
+				return workflow[len(workflow)-1]
 			}
 		}
 	}
-	return false
+	return ""
+}
+
+// guarded checks an issue against the guard policy, reporting why it is being skipped if it is. The guard's bot
+// accounts are expected to have been defaulted with withDefaultBots
+func (s SetStatus) guarded(issue j.Issue, target string, mergedAt *time.Time, p IssueTracker) (bool, error) {
+	if target == "" {
+		return false, nil
+	}
+
+	reason, err := s.Guard.Check(issue.ID, target, mergedAt, p)
+	if err != nil {
+		return false, err
+	}
+	if reason != "" {
+		c.Warn.Printf("NOT updating issue %s to %s as %s\n", issue.Key, target, reason)
+		return true, nil
+	}
+	return false, nil
 }
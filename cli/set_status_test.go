@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func setStatusServer(t *testing.T) *jiratest.Server {
+	t.Helper()
+
+	s := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "To Do"},
+			{Key: "IPL-2", Status: "In Review"},
+			{Key: "IPL-3", Status: "Blocked"},
+		},
+		Transitions: []jiratest.Transition{
+			{ID: "11", Name: "In Progress", From: []string{"To Do", "Blocked"}, To: "In Progress"},
+			{ID: "21", Name: "In Review", From: []string{"In Progress"}, To: "In Review"},
+			{ID: "31", Name: "Done", From: []string{"In Review"}, To: "Done"},
+		},
+	})
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func TestSetStatus(t *testing.T) {
+	s := setStatusServer(t)
+
+	setStatus := SetStatus{
+		JiraUrl:     s.URL,
+		Jql:         "project = IPL",
+		Transitions: []string{"to do;in progress;in review;done"},
+	}
+	captureOutput(t, setStatus.SetStatus)
+
+	expected := map[string]string{
+		"IPL-1": "Done",
+		"IPL-2": "Done",
+		// blocked isn't part of the workflow so is left alone
+		"IPL-3": "Blocked",
+	}
+	for key, status := range expected {
+		if actual := s.Issue(key).Status; actual != status {
+			t.Errorf("expected %s to have status %q, got %q", key, status, actual)
+		}
+	}
+}
+
+func TestSetStatusIssueKeys(t *testing.T) {
+	s := setStatusServer(t)
+
+	setStatus := SetStatus{
+		JiraUrl:     s.URL,
+		IssueKeys:   []string{"IPL-3"},
+		Transitions: []string{"to do;in progress;in review;done", "blocked;in progress"},
+	}
+	captureOutput(t, setStatus.SetStatus)
+
+	if actual := s.Issue("IPL-3").Status; actual != "In Progress" {
+		t.Errorf("expected IPL-3 to have status %q, got %q", "In Progress", actual)
+	}
+	if actual := s.Issue("IPL-1").Status; actual != "To Do" {
+		t.Errorf("expected IPL-1 to be left with status %q, got %q", "To Do", actual)
+	}
+}
+
+func TestSetStatusDryRun(t *testing.T) {
+	s := setStatusServer(t)
+
+	setStatus := SetStatus{
+		JiraUrl:     s.URL,
+		Jql:         "project = IPL",
+		Transitions: []string{"to do;in progress;in review;done"},
+		DryRun:      true,
+	}
+	captureOutput(t, setStatus.SetStatus)
+
+	if actual := s.Issue("IPL-1").Status; actual != "To Do" {
+		t.Errorf("expected a dry run to leave IPL-1 with status %q, got %q", "To Do", actual)
+	}
+}
@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	j "github.com/andygrunwald/go-jira"
+	c "github.com/gookit/color"
+	"github.com/jirallreadyforthis/lib/jira"
+)
+
+type Sprints struct {
+	JiraToken  string
+	JiraUrl    string
+	UserName   string
+	ProjectKey string
+	// Board is the id or name of the board, when empty all boards of the project are used
+	Board string
+	// State is a comma separated list of sprint states to list eg 'active,future', empty for all
+	State string
+	// Sprint is the name, id, 'active' or 'next' sprint to start or complete
+	Sprint string
+	Name   string
+	Goal   string
+	// StartDate and EndDate are in the format YYYY-MM-DD
+	StartDate string
+	EndDate   string
+	// CarryOver is where complete moves unfinished issues, 'next' for the next sprint falling back to the backlog,
+	// 'backlog', or the name or id of a sprint
+	CarryOver string
+	DryRun    bool
+}
+
+// defaultSprintLength is how long a started sprint runs for when it has no end date
+const defaultSprintLength = 14 * 24 * time.Hour
+
+func (s Sprints) project() jira.Project {
+	return jira.Project{
+		Token:    s.JiraToken,
+		UserName: s.UserName,
+		JiraUrl:  s.JiraUrl,
+	}
+}
+
+func (s Sprints) List() error {
+	p := s.project()
+
+	boards := make([]j.Board, 0)
+	if s.Board != "" {
+		board, err := p.FindBoard(s.Board, s.ProjectKey)
+		if err != nil {
+			return err
+		}
+		boards = append(boards, *board)
+	} else {
+		var err error
+		boards, err = p.ListBoards("", s.ProjectKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, board := range boards {
+		sprints, err := p.ListSprints(board.ID, s.State)
+		if err != nil {
+			return err
+		}
+
+		c.Info.Printf("\nBoard %s (id %d)\n", board.Name, board.ID)
+		if len(sprints) == 0 {
+			fmt.Println("  no sprints")
+		}
+		for _, sprint := range sprints {
+			dates := ""
+			if sprint.StartDate != nil {
+				dates = fmt.Sprintf(" %s - %s", formatDate(sprint.StartDate), formatDate(sprint.EndDate))
+			}
+			fmt.Printf("  %d %s [%s]%s\n", sprint.ID, sprint.Name, sprint.State, dates)
+			if sprint.Goal != "" {
+				fmt.Printf("    goal: %s\n", strings.TrimSpace(sprint.Goal))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s Sprints) CreateSprint() error {
+	if s.Board == "" || s.Name == "" {
+		return fmt.Errorf("both a board and a sprint name are required to create a sprint")
+	}
+
+	startDate, err := parseDate(s.StartDate)
+	if err != nil {
+		return err
+	}
+	endDate, err := parseDate(s.EndDate)
+	if err != nil {
+		return err
+	}
+
+	p := s.project()
+	board, err := p.FindBoard(s.Board, s.ProjectKey)
+	if err != nil {
+		return err
+	}
+
+	existing, err := p.ListSprints(board.ID, "active,future")
+	if err != nil {
+		return err
+	}
+	for _, sprint := range existing {
+		if strings.EqualFold(sprint.Name, s.Name) {
+			c.Warn.Printf("sprint %s already exists on board %s (id %d)\n", sprint.Name, board.Name, sprint.ID)
+			return nil
+		}
+	}
+
+	fmt.Printf("creating sprint %s on board %s (id %d)\n", s.Name, board.Name, board.ID)
+	if !s.DryRun {
+		sprint, err := p.CreateSprint(board.ID, s.Name, s.Goal, startDate, endDate)
+		if err != nil {
+			return err
+		}
+		c.Info.Printf("\nCreated sprint %s (id %d)\n", sprint.Name, sprint.ID)
+	}
+
+	return nil
+}
+
+// StartSprint starts the next sprint of a board, or the input sprint, from today until two weeks later unless other
+// dates are input or already set on the sprint
+func (s Sprints) StartSprint() error {
+	p := s.project()
+	board, sprint, err := s.resolve(p, "next")
+	if err != nil {
+		return err
+	}
+	if sprint.State != "future" {
+		return fmt.Errorf("sprint %s (id %d) is %s, only future sprints can be started", sprint.Name, sprint.ID, sprint.State)
+	}
+
+	startDate := time.Now()
+	if parsed, err := parseDate(s.StartDate); err != nil {
+		return err
+	} else if parsed != nil {
+		startDate = *parsed
+	} else if sprint.StartDate != nil {
+		startDate = *sprint.StartDate
+	}
+
+	endDate := startDate.Add(defaultSprintLength)
+	if parsed, err := parseDate(s.EndDate); err != nil {
+		return err
+	} else if parsed != nil {
+		endDate = *parsed
+	} else if sprint.EndDate != nil && sprint.EndDate.After(startDate) {
+		endDate = *sprint.EndDate
+	}
+	if !endDate.After(startDate) {
+		return fmt.Errorf("the sprint end date %s must be after its start date %s", formatDate(&endDate), formatDate(&startDate))
+	}
+
+	fmt.Printf("starting sprint %s (id %d) on board %s from %s to %s\n", sprint.Name, sprint.ID, board.Name,
+		formatDate(&startDate), formatDate(&endDate))
+	if !s.DryRun {
+		if err := p.StartSprint(sprint.ID, startDate, endDate); err != nil {
+			return err
+		}
+	}
+
+	c.Info.Printf("\nFinished starting sprint %s\n", sprint.Name)
+	return nil
+}
+
+// CompleteSprint closes the active sprint of a board, or the input sprint, after carrying its issues that aren't in a
+// done status over to the next sprint or the backlog
+func (s Sprints) CompleteSprint() error {
+	p := s.project()
+	board, sprint, err := s.resolve(p, "active")
+	if err != nil {
+		return err
+	}
+	if sprint.State != "active" {
+		return fmt.Errorf("sprint %s (id %d) is %s, only active sprints can be completed", sprint.Name, sprint.ID, sprint.State)
+	}
+
+	target, err := s.carryOverSprint(p, board.ID, sprint.ID)
+	if err != nil {
+		return err
+	}
+	targetName := "the backlog"
+	if target != nil {
+		targetName = fmt.Sprintf("sprint %s (id %d)", target.Name, target.ID)
+	}
+
+	issues, err := p.ListSprintIssues(sprint.ID)
+	if err != nil {
+		return err
+	}
+
+	done := 0
+	carried := make([]string, 0)
+	for _, issue := range issues {
+		if issue.Fields != nil && issue.Fields.Status != nil && issue.Fields.Status.StatusCategory.Key == "done" {
+			done++
+			continue
+		}
+		status := ""
+		if issue.Fields != nil && issue.Fields.Status != nil {
+			status = issue.Fields.Status.Name
+		}
+		fmt.Printf("moving issue (key %s id %s) in status %s to %s\n", issue.Key, issue.ID, status, targetName)
+		carried = append(carried, issue.ID)
+	}
+
+	fmt.Printf("completing sprint %s (id %d) on board %s\n", sprint.Name, sprint.ID, board.Name)
+	if !s.DryRun {
+		if len(carried) > 0 {
+			if target != nil {
+				err = p.AddToSprint(target.ID, carried)
+			} else {
+				err = p.MoveToBacklog(carried)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if err := p.CompleteSprint(sprint.ID); err != nil {
+			return err
+		}
+	}
+
+	c.Info.Printf("\nFinished completing sprint %s: %d of %d issues done, %d carried over to %s\n", sprint.Name, done,
+		len(issues), len(carried), targetName)
+	return nil
+}
+
+// resolve finds the board and the sprint to work on, using fallback when no sprint is input
+func (s Sprints) resolve(p jira.Project, fallback string) (*j.Board, *jira.Sprint, error) {
+	if s.Board == "" {
+		return nil, nil, fmt.Errorf("a board is required")
+	}
+
+	board, err := p.FindBoard(s.Board, s.ProjectKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name := s.Sprint
+	if name == "" {
+		name = fallback
+	}
+	sprint, err := p.ResolveSprint(board.ID, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return board, sprint, nil
+}
+
+// carryOverSprint finds the sprint unfinished issues are moved to on completing a sprint, nil for the backlog
+func (s Sprints) carryOverSprint(p jira.Project, boardId int, completing int) (*jira.Sprint, error) {
+	carryOver := s.CarryOver
+	if carryOver == "" {
+		carryOver = "next"
+	}
+	if strings.EqualFold(carryOver, "backlog") {
+		return nil, nil
+	}
+
+	if strings.EqualFold(carryOver, "next") {
+		future, err := p.ListSprints(boardId, "future")
+		if err != nil {
+			return nil, err
+		}
+		if len(future) == 0 {
+			c.Warn.Printf("board id %d has no future sprint, unfinished issues will be moved to the backlog\n", boardId)
+			return nil, nil
+		}
+		return &future[0], nil
+	}
+
+	target, err := p.ResolveSprint(boardId, carryOver)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == completing {
+		return nil, fmt.Errorf("unfinished issues can't be carried over to the sprint being completed")
+	}
+	if target.State == "closed" {
+		return nil, fmt.Errorf("unfinished issues can't be carried over to sprint %s (id %d) as it is closed", target.Name, target.ID)
+	}
+	return target, nil
+}
+
+func parseDate(date string) (*time.Time, error) {
+	if date == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("parsing date %q, expected format YYYY-MM-DD: %v", date, err)
+	}
+	return &t, nil
+}
+
+// resolveSprintId finds the id of a sprint on a board from its id, name, or 'active' or 'next'
+func resolveSprintId(p IssueTracker, board string, sprint string) (int, error) {
+	found, err := resolveSprint(p, board, sprint)
+	if err != nil {
+		return 0, err
+	}
+
+	fmt.Printf("using sprint %s (id %d)\n", found.Name, found.ID)
+	return found.ID, nil
+}
+
+func resolveSprint(p IssueTracker, board string, sprint string) (*jira.Sprint, error) {
+	if board == "" || sprint == "" {
+		return nil, fmt.Errorf("either a sprint id or both a board and a sprint are required")
+	}
+
+	b, err := p.FindBoard(board, "")
+	if err != nil {
+		return nil, err
+	}
+	return p.ResolveSprint(b.ID, sprint)
+}
@@ -15,16 +15,61 @@ type SprintAdd struct {
 	GHToken   string
 	IssueKeys []string
 	SprintId  int
-	DryRun    bool
-	CheckLog  bool
+	// Board and Sprint find the sprint when SprintId isn't set, Sprint is a name, id, 'active' or 'next'
+	Board    string
+	Sprint   string
+	DryRun   bool
+	CheckLog bool
+	// Guard is the policy for protecting manual sprint changes when CheckLog is set
+	Guard Guard
+	// Capacity is checked against the story points in the sprint when it is enabled
+	Capacity Capacity
+	// Tracker is where issues are moved between sprints, defaults to jira
+	Tracker IssueTracker
 }
 
-func (s SprintAdd) AddIssuesToSprint() error {
-	p := jira.Project{
+func (s SprintAdd) project() jira.Project {
+	return jira.Project{
 		Token:    s.JiraToken,
 		UserName: s.UserName,
 		JiraUrl:  s.JiraUrl,
 	}
+}
+
+func (s SprintAdd) tracker() IssueTracker {
+	if s.Tracker != nil {
+		return s.Tracker
+	}
+	return s.project()
+}
+
+func (s SprintAdd) AddIssuesToSprint() error {
+	p := s.tracker()
+
+	if s.SprintId == 0 {
+		id, err := resolveSprintId(p, s.Board, s.Sprint)
+		if err != nil {
+			return err
+		}
+		s.SprintId = id
+	}
+
+	if s.CheckLog {
+		guard, err := s.Guard.withDefaultBots(s.UserName, p)
+		if err != nil {
+			return err
+		}
+		s.Guard = guard
+	}
+
+	var load *sprintLoad
+	if s.Capacity.enabled() {
+		var err error
+		load, err = s.Capacity.load(s.SprintId, p)
+		if err != nil {
+			return err
+		}
+	}
 
 	issueIds := make([]string, 0)
 	count := 0
@@ -34,8 +79,23 @@ func (s SprintAdd) AddIssuesToSprint() error {
 			if err != nil {
 				return err
 			}
+			skip, err := s.guarded(issueKey, issueId, p)
+			if err != nil {
+				return err
+			}
+			if skip {
+				continue
+			}
+			points := ""
+			if load != nil {
+				issue, err := p.GetIssue(issueId)
+				if err != nil {
+					return err
+				}
+				points = fmt.Sprintf(" %s points", formatPoints(load.add(*issue)))
+			}
 			issueIds = append(issueIds, issueId)
-			fmt.Printf("adding issue (key %s id %s) to sprint (id %d)\n", issueKey, issueId, s.SprintId)
+			fmt.Printf("adding issue (key %s id %s%s) to sprint (id %d)\n", issueKey, issueId, points, s.SprintId)
 			count++
 		}
 	} else if s.Jql != "" {
@@ -45,14 +105,35 @@ func (s SprintAdd) AddIssuesToSprint() error {
 		}
 
 		for _, issue := range issues {
+			skip, err := s.guarded(issue.Key, issue.ID, p)
+			if err != nil {
+				return err
+			}
+			if skip {
+				continue
+			}
+			points := ""
+			if load != nil {
+				points = fmt.Sprintf(" %s points", formatPoints(load.add(issue)))
+			}
 			issueIds = append(issueIds, issue.ID)
-			fmt.Printf("adding issue (key %s id %s) to sprint (id %d)\n", issue.Key, issue.ID, s.SprintId)
+			fmt.Printf("adding issue (key %s id %s%s) to sprint (id %d)\n", issue.Key, issue.ID, points, s.SprintId)
 
 			count++
 		}
 
 	}
 
+	if load != nil {
+		over, err := s.Capacity.check(s.SprintId, load)
+		if err != nil {
+			return err
+		}
+		if over && s.Capacity.Refuse {
+			return fmt.Errorf("not adding %d issues to sprint %d as it would be over capacity", count, s.SprintId)
+		}
+	}
+
 	if !s.DryRun {
 		if len(issueIds) > 0 {
 			err := p.AddToSprint(s.SprintId, issueIds)
@@ -67,7 +148,25 @@ func (s SprintAdd) AddIssuesToSprint() error {
 	return nil
 }
 
-func getIssueIdFromKey(key string, p jira.Project) (string, error) {
+// guarded checks whether someone has recently taken an issue out of the sprint by hand, in which case it is skipped
+// rather than pulled back in
+func (s SprintAdd) guarded(issueKey string, issueId string, p IssueTracker) (bool, error) {
+	if !s.CheckLog {
+		return false, nil
+	}
+
+	reason, err := s.Guard.CheckSprint(issueId, s.SprintId, p)
+	if err != nil {
+		return false, err
+	}
+	if reason != "" {
+		c.Warn.Printf("NOT adding issue %s to sprint %d as %s\n", issueKey, s.SprintId, reason)
+		return true, nil
+	}
+	return false, nil
+}
+
+func getIssueIdFromKey(key string, p IssueTracker) (string, error) {
 	jql := fmt.Sprintf("issueKey = %s", key)
 	issues, err := p.ListIssues(jql)
 	if err != nil {
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/jira"
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestAddIssuesToSprint(t *testing.T) {
+	s := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "To Do"},
+			{Key: "IPL-2", Status: "To Do"},
+			{Key: "IPL-3", Status: "To Do"},
+		},
+		Queries: map[string][]string{
+			"status = 'To Do' AND labels = next": {"IPL-2", "IPL-3"},
+		},
+	})
+	defer s.Close()
+
+	cases := []struct {
+		name     string
+		add      SprintAdd
+		expected map[string]int
+	}{
+		{
+			name:     "keys",
+			add:      SprintAdd{SprintId: 7, IssueKeys: []string{"IPL-1"}},
+			expected: map[string]int{"IPL-1": 7, "IPL-2": 0, "IPL-3": 0},
+		},
+		{
+			name:     "jql",
+			add:      SprintAdd{SprintId: 8, Jql: "status = 'To Do' AND labels = next"},
+			expected: map[string]int{"IPL-1": 7, "IPL-2": 8, "IPL-3": 8},
+		},
+		{
+			name:     "dry run",
+			add:      SprintAdd{SprintId: 9, IssueKeys: []string{"IPL-1", "IPL-2"}, DryRun: true},
+			expected: map[string]int{"IPL-1": 7, "IPL-2": 8, "IPL-3": 8},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			add := tc.add
+			add.JiraUrl = s.URL
+			captureOutput(t, add.AddIssuesToSprint)
+
+			for key, sprint := range tc.expected {
+				if actual := s.Issue(key).SprintID; actual != sprint {
+					t.Errorf("expected %s to be in sprint %d, got %d", key, sprint, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestAddIssuesToSprintGuard(t *testing.T) {
+	removed := func(author string, daysAgo int) []jiratest.History {
+		return []jiratest.History{{
+			Author:  author,
+			Created: time.Now().AddDate(0, 0, -daysAgo),
+			Items:   []jiratest.HistoryItem{{Field: "Sprint", From: "Sprint 8", FromID: "7, 8", To: "Sprint 7", ToID: "7"}},
+		}}
+	}
+
+	s := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "To Do", Changelog: removed("someone", 1)},
+			{Key: "IPL-2", Status: "To Do", Changelog: removed("someone", 30)},
+			{Key: "IPL-3", Status: "To Do", Changelog: removed("automation", 1)},
+			{Key: "IPL-4", Status: "To Do"},
+		},
+	})
+	defer s.Close()
+
+	add := SprintAdd{
+		JiraUrl:  s.URL,
+		UserName: "automation",
+		Jql:      "project = IPL",
+		SprintId: 8,
+		CheckLog: true,
+		Guard:    Guard{Within: "7d"},
+	}
+	out := captureOutput(t, add.AddIssuesToSprint)
+
+	expected := map[string]int{"IPL-1": 0, "IPL-2": 8, "IPL-3": 8, "IPL-4": 8}
+	for key, sprint := range expected {
+		if actual := s.Issue(key).SprintID; actual != sprint {
+			t.Errorf("expected %s to be in sprint %d, got %d", key, sprint, actual)
+		}
+	}
+	if !strings.Contains(out, "NOT adding issue IPL-1 to sprint 8 as someone removed it from sprint 8") {
+		t.Errorf("expected the skip to be reported with its reason, got:\n%s", out)
+	}
+}
+
+func TestAddIssuesToSprintByName(t *testing.T) {
+	s := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{{Key: "IPL-1", Status: "To Do"}},
+		Boards: []jiratest.Board{{ID: 1, Name: "Team X", Type: "scrum", ProjectKey: "IPL"}},
+		Sprints: []jiratest.Sprint{
+			{ID: 7, BoardID: 1, Name: "Sprint 41", State: "active"},
+			{ID: 8, BoardID: 1, Name: "Sprint 42", State: "future"},
+		},
+	})
+	defer s.Close()
+
+	// the sprint is found through the tracker like everything else
+	add := SprintAdd{Tracker: jira.Project{JiraUrl: s.URL}, Board: "Team X", Sprint: "next", IssueKeys: []string{"IPL-1"}}
+	out := captureOutput(t, add.AddIssuesToSprint)
+
+	if actual := s.Issue("IPL-1").SprintID; actual != 8 {
+		t.Errorf("expected IPL-1 to be in the next sprint 8, got %d", actual)
+	}
+	if !strings.Contains(out, "sprint 8") {
+		t.Errorf("expected the resolved sprint id in the output, got %q", out)
+	}
+
+	add = SprintAdd{JiraUrl: s.URL, Board: "Team X", IssueKeys: []string{"IPL-1"}}
+	if err := add.AddIssuesToSprint(); err == nil {
+		t.Errorf("expected an error without a sprint id or sprint")
+	}
+}
+
+func TestAddIssuesToSprintCapacity(t *testing.T) {
+	points := func(p float64) map[string]interface{} {
+		return map[string]interface{}{"customfield_10016": p}
+	}
+
+	newServer := func() *jiratest.Server {
+		return jiratest.NewServer(jiratest.Seed{
+			Issues: []jiratest.Issue{
+				{Key: "IPL-1", Status: "To Do", Assignee: "jane", SprintID: 7, CustomFields: points(5)},
+				{Key: "IPL-2", Status: "To Do", Assignee: "jane", CustomFields: points(3)},
+				{Key: "IPL-3", Status: "To Do", Assignee: "joe", CustomFields: points(2)},
+				{Key: "IPL-4", Status: "To Do"},
+			},
+			Fields: []jiratest.Field{{ID: "customfield_10016", Name: "Story Points"}},
+		})
+	}
+
+	cases := []struct {
+		name     string
+		capacity Capacity
+		refused  bool
+		expected []string
+	}{
+		{
+			name:     "within capacity",
+			capacity: Capacity{Sprint: 10},
+		},
+		{
+			name:     "warn over capacity",
+			capacity: Capacity{Sprint: 8},
+			expected: []string{"sprint 7 is over capacity with 10 of 8 points"},
+		},
+		{
+			name:     "warn over assignee capacity",
+			capacity: Capacity{Assignees: []string{"Jane=6"}},
+			expected: []string{"jane: 8 of 6 points, over capacity"},
+		},
+		{
+			name:     "refuse over capacity",
+			capacity: Capacity{Sprint: 8, Refuse: true},
+			refused:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newServer()
+			defer s.Close()
+
+			// the points in the sprint are loaded through the tracker like everything else
+			add := SprintAdd{Tracker: jira.Project{JiraUrl: s.URL}, SprintId: 7, IssueKeys: []string{"IPL-1", "IPL-2", "IPL-3", "IPL-4"}, Capacity: tc.capacity}
+			if tc.refused {
+				if err := add.AddIssuesToSprint(); err == nil {
+					t.Fatalf("expected adding issues over capacity to be refused")
+				}
+				if actual := s.Issue("IPL-2").SprintID; actual != 0 {
+					t.Errorf("expected no issues to be added when refused, IPL-2 is in sprint %d", actual)
+				}
+				return
+			}
+
+			out := captureOutput(t, add.AddIssuesToSprint)
+			for _, expected := range tc.expected {
+				if !strings.Contains(out, expected) {
+					t.Errorf("expected %q in the output, got %q", expected, out)
+				}
+			}
+			if len(tc.expected) == 0 && strings.Contains(out, "over capacity") {
+				t.Errorf("expected no capacity warning, got %q", out)
+			}
+			if actual := s.Issue("IPL-3").SprintID; actual != 7 {
+				t.Errorf("expected IPL-3 to be added to sprint 7, got %d", actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	j "github.com/andygrunwald/go-jira"
+	"github.com/jirallreadyforthis/lib/jira"
+)
+
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+)
+
+const (
+	// FlagDoneNotMerged marks a done issue whose linked pull requests haven't all landed
+	FlagDoneNotMerged = "done-not-merged"
+	// FlagMergedNotDone marks an issue whose linked pull requests have landed but that isn't done
+	FlagMergedNotDone = "merged-not-done"
+)
+
+// SprintReport summarises the issues in a sprint and whether their code has landed.
+// The embedded List provides the link discovery options
+type SprintReport struct {
+	List
+	// SprintId, or Board and Sprint, is the sprint to report on, defaulting to the board's active sprint
+	SprintId int
+	Board    string
+	Sprint   string
+	// Format is 'text', 'markdown' or 'json'
+	Format string
+}
+
+type sprintReport struct {
+	SprintID   int           `json:"sprintId"`
+	Sprint     string        `json:"sprint"`
+	State      string        `json:"state"`
+	Goal       string        `json:"goal,omitempty"`
+	StartDate  *time.Time    `json:"startDate,omitempty"`
+	EndDate    *time.Time    `json:"endDate,omitempty"`
+	Categories []reportGroup `json:"statusCategories"`
+	Assignees  []reportGroup `json:"assignees"`
+	Issues     []reportIssue `json:"issues"`
+}
+
+type reportGroup struct {
+	Name   string   `json:"name"`
+	Total  int      `json:"total"`
+	Done   int      `json:"done"`
+	Issues []string `json:"issues"`
+}
+
+type reportIssue struct {
+	Key            string       `json:"key"`
+	URL            string       `json:"url"`
+	Summary        string       `json:"summary"`
+	Status         string       `json:"status"`
+	StatusCategory string       `json:"statusCategory"`
+	Assignee       string       `json:"assignee"`
+	Links          []reportLink `json:"links"`
+	Flag           string       `json:"flag,omitempty"`
+	// Errors are the links that couldn't be resolved, a done issue with any is flagged as its code may not have landed
+	Errors []string `json:"errors,omitempty"`
+	done   bool
+}
+
+type reportLink struct {
+	URL         string `json:"url"`
+	PullRequest bool   `json:"pullRequest"`
+	// State is 'open', 'draft', 'merged' or 'closed'
+	State string `json:"state"`
+}
+
+// categoryOrder is the order of the status category keys in a report, any others come after
+var categoryOrder = []string{"new", "indeterminate", "done"}
+
+func (r SprintReport) Report() error {
+	format := r.Format
+	if format == "" {
+		format = FormatText
+	}
+	if format != FormatText && format != FormatMarkdown && format != FormatJSON {
+		return fmt.Errorf("unknown format %q, expected one of %s, %s or %s", format, FormatText, FormatMarkdown, FormatJSON)
+	}
+
+	report, err := r.build()
+	if err != nil {
+		return err
+	}
+	// lookup errors are warned about here rather than in the text or markdown report, json keeps them with each issue
+	for _, issue := range report.Issues {
+		for _, e := range issue.Errors {
+			warnf("%s: %s", issue.Key, e)
+		}
+	}
+
+	out, err := report.render(format)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+func (r SprintReport) build() (*sprintReport, error) {
+	p := jira.Project{Token: r.JiraToken, UserName: r.UserName, JiraUrl: r.JiraUrl}
+
+	var sprint *jira.Sprint
+	var err error
+	if r.SprintId != 0 {
+		sprint, err = p.GetSprint(r.SprintId)
+	} else {
+		name := r.Sprint
+		if name == "" {
+			name = "active"
+		}
+		sprint, err = resolveSprint(p, r.Board, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	issues, err := p.ListSprintIssues(sprint.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &sprintReport{
+		SprintID:  sprint.ID,
+		Sprint:    sprint.Name,
+		State:     sprint.State,
+		Goal:      sprint.Goal,
+		StartDate: sprint.StartDate,
+		EndDate:   sprint.EndDate,
+		Issues:    make([]reportIssue, 0),
+	}
+
+	tracker := r.tracker()
+	categories := make(map[string]*reportGroup)
+	categoryKeys := make(map[string]string)
+	assignees := make(map[string]*reportGroup)
+	for _, issue := range issues {
+		issueWithComments, err := tracker.GetIssue(issue.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		ri := r.reportIssue(issue, issueWithComments)
+		report.Issues = append(report.Issues, ri)
+
+		addToGroup(categories, ri.StatusCategory, ri)
+		addToGroup(assignees, ri.Assignee, ri)
+		if issue.Fields != nil && issue.Fields.Status != nil {
+			categoryKeys[ri.StatusCategory] = issue.Fields.Status.StatusCategory.Key
+		}
+	}
+
+	report.Categories = sortedGroups(categories, func(name string) int {
+		return categoryRank(categoryKeys[name])
+	})
+	report.Assignees = sortedGroups(assignees, func(string) int { return 0 })
+	return report, nil
+}
+
+// reportIssue resolves the links of an issue and flags it when its status and the state of its pull requests disagree
+func (r SprintReport) reportIssue(issue j.Issue, issueWithComments *j.Issue) reportIssue {
+	ri := reportIssue{
+		Key:      issue.Key,
+		URL:      r.getJiraHtmlUrl(issue.Key),
+		Assignee: assigneeName(issue),
+		Links:    make([]reportLink, 0),
+	}
+	if issue.Fields != nil {
+		ri.Summary = issue.Fields.Summary
+		if issue.Fields.Status != nil {
+			ri.Status = issue.Fields.Status.Name
+			ri.StatusCategory = issue.Fields.Status.StatusCategory.Name
+			ri.done = issue.Fields.Status.StatusCategory.Key == "done"
+		}
+	}
+
+	pulls, merged, open := 0, 0, 0
+	for _, link := range r.findLinks(issue, issueWithComments) {
+		item, _, err := r.resolveLink(link)
+		if err != nil {
+			ri.Errors = append(ri.Errors, err.Error())
+			continue
+		}
+		if item == nil {
+			continue
+		}
+
+		state := item.State
+		switch {
+		case item.Merged:
+			state = "merged"
+		case item.State == "open" && item.Draft:
+			state = "draft"
+		}
+		ri.Links = append(ri.Links, reportLink{URL: item.URL, PullRequest: item.PullRequest, State: state})
+
+		if item.PullRequest {
+			pulls++
+			if item.Merged {
+				merged++
+			} else if item.State == "open" {
+				open++
+			}
+		}
+	}
+
+	landed := merged > 0 && open == 0 && len(ri.Errors) == 0
+	switch {
+	case ri.done && (pulls > 0 || len(ri.Errors) > 0) && !landed:
+		ri.Flag = FlagDoneNotMerged
+	case !ri.done && landed:
+		ri.Flag = FlagMergedNotDone
+	}
+	return ri
+}
+
+func categoryRank(key string) int {
+	for i, k := range categoryOrder {
+		if k == key {
+			return i
+		}
+	}
+	return len(categoryOrder)
+}
+
+func addToGroup(groups map[string]*reportGroup, name string, issue reportIssue) {
+	group, ok := groups[name]
+	if !ok {
+		group = &reportGroup{Name: name, Issues: make([]string, 0)}
+		groups[name] = group
+	}
+	group.Total++
+	group.Issues = append(group.Issues, issue.Key)
+	if issue.done {
+		group.Done++
+	}
+}
+
+// sortedGroups orders groups by their rank, then by name
+func sortedGroups(groups map[string]*reportGroup, rank func(name string) int) []reportGroup {
+	sorted := make([]reportGroup, 0)
+	for _, group := range groups {
+		sorted = append(sorted, *group)
+	}
+	sort.Slice(sorted, func(i, k int) bool {
+		if ri, rk := rank(sorted[i].Name), rank(sorted[k].Name); ri != rk {
+			return ri < rk
+		}
+		return sorted[i].Name < sorted[k].Name
+	})
+	return sorted
+}
+
+func (r *sprintReport) flagged(flag string) []reportIssue {
+	flagged := make([]reportIssue, 0)
+	for _, issue := range r.Issues {
+		if issue.Flag == flag {
+			flagged = append(flagged, issue)
+		}
+	}
+	return flagged
+}
+
+func (r *sprintReport) render(format string) (string, error) {
+	switch format {
+	case FormatJSON:
+		out, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("encoding sprint report: %v", err)
+		}
+		return string(out) + "\n", nil
+	case FormatMarkdown:
+		return r.markdown(), nil
+	}
+	return r.text(), nil
+}
+
+func (r *sprintReport) dates() string {
+	if r.StartDate == nil {
+		return ""
+	}
+	return fmt.Sprintf(" %s - %s", formatDate(r.StartDate), formatDate(r.EndDate))
+}
+
+func (r *sprintReport) text() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "\nSprint %s (id %d) [%s]%s\n", r.Sprint, r.SprintID, r.State, r.dates())
+	if r.Goal != "" {
+		fmt.Fprintf(b, "goal: %s\n", r.Goal)
+	}
+
+	fmt.Fprintf(b, "\nBy status category\n")
+	for _, group := range r.Categories {
+		fmt.Fprintf(b, "  %s: %d\n", group.Name, group.Total)
+	}
+	fmt.Fprintf(b, "\nBy assignee\n")
+	for _, group := range r.Assignees {
+		fmt.Fprintf(b, "  %s: %d (%d done)\n", group.Name, group.Total, group.Done)
+	}
+
+	fmt.Fprintf(b, "\nIssues\n")
+	for _, issue := range r.Issues {
+		fmt.Fprintf(b, "  %s\t%s\t%s\t%s\n", issue.Status, issue.Key, issue.Assignee, issue.Summary)
+		for _, link := range issue.Links {
+			fmt.Fprintf(b, "\t%s\t%s\n", link.State, link.URL)
+		}
+	}
+
+	for _, section := range []struct{ title, flag string }{
+		{"Done but not merged", FlagDoneNotMerged},
+		{"Merged but not done", FlagMergedNotDone},
+	} {
+		flagged := r.flagged(section.flag)
+		fmt.Fprintf(b, "\n%s (%d)\n", section.title, len(flagged))
+		for _, issue := range flagged {
+			fmt.Fprintf(b, "  %s\t%s\t%s\n", issue.Status, issue.URL, issue.Summary)
+		}
+	}
+	return b.String()
+}
+
+func (r *sprintReport) markdown() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# %s\n\n", r.Sprint)
+	fmt.Fprintf(b, "Sprint id %d, %s%s\n", r.SprintID, r.State, r.dates())
+	if r.Goal != "" {
+		fmt.Fprintf(b, "\n> %s\n", r.Goal)
+	}
+
+	fmt.Fprintf(b, "\n## By status category\n\n| Status category | Issues |\n| --- | --- |\n")
+	for _, group := range r.Categories {
+		fmt.Fprintf(b, "| %s | %d |\n", markdownCell(group.Name), group.Total)
+	}
+	fmt.Fprintf(b, "\n## By assignee\n\n| Assignee | Issues | Done |\n| --- | --- | --- |\n")
+	for _, group := range r.Assignees {
+		fmt.Fprintf(b, "| %s | %d | %d |\n", markdownCell(group.Name), group.Total, group.Done)
+	}
+
+	fmt.Fprintf(b, "\n## Issues\n\n| Issue | Status | Assignee | Summary | Links |\n| --- | --- | --- | --- | --- |\n")
+	for _, issue := range r.Issues {
+		links := make([]string, 0)
+		for _, link := range issue.Links {
+			links = append(links, fmt.Sprintf("[%s](%s)", link.State, link.URL))
+		}
+		fmt.Fprintf(b, "| [%s](%s) | %s | %s | %s | %s |\n", issue.Key, issue.URL, markdownCell(issue.Status),
+			markdownCell(issue.Assignee), markdownCell(issue.Summary), strings.Join(links, " "))
+	}
+
+	for _, section := range []struct{ title, flag string }{
+		{"Done but not merged", FlagDoneNotMerged},
+		{"Merged but not done", FlagMergedNotDone},
+	} {
+		flagged := r.flagged(section.flag)
+		fmt.Fprintf(b, "\n## %s (%d)\n\n", section.title, len(flagged))
+		for _, issue := range flagged {
+			fmt.Fprintf(b, "- [%s](%s) %s: %s\n", issue.Key, issue.URL, issue.Status, issue.Summary)
+		}
+	}
+	return b.String()
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", "\\|"), "\n", " ")
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/gh/ghtest"
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestSprintReport(t *testing.T) {
+	merged := time.Now().AddDate(0, 0, -2)
+
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Summary: "landed", Status: "Done", StatusCategory: "done", Assignee: "jane", SprintID: 7, Description: "https://github.com/acme/app/pull/1"},
+			{Key: "IPL-2", Summary: "done early", Status: "Done", StatusCategory: "done", Assignee: "jane", SprintID: 7, Description: "https://github.com/acme/app/pull/2"},
+			{Key: "IPL-3", Summary: "forgotten", Status: "In Review", StatusCategory: "indeterminate", Assignee: "joe", SprintID: 7, Description: "https://github.com/acme/app/pull/1"},
+			{Key: "IPL-4", Summary: "not started", Status: "To Do", SprintID: 7},
+			{Key: "IPL-6", Summary: "lookup fails", Status: "Done", StatusCategory: "done", Assignee: "joe", SprintID: 7, Description: "https://github.com/acme/app/pull/99"},
+			{Key: "IPL-5", Summary: "other sprint", Status: "To Do", SprintID: 8, Description: "https://github.com/acme/app/pull/1"},
+		},
+		Boards:  []jiratest.Board{{ID: 1, Name: "Team X", Type: "scrum", ProjectKey: "IPL"}},
+		Sprints: []jiratest.Sprint{{ID: 7, BoardID: 1, Name: "Sprint 41", State: "active", Goal: "ship it"}},
+	})
+	defer jiraServer.Close()
+
+	ghServer := ghtest.NewServer(ghtest.Seed{
+		Issues: []ghtest.Issue{
+			{Repo: "acme/app", Number: 1, State: "closed", ClosedAt: &merged, Pull: &ghtest.Pull{Merged: true, MergedAt: &merged, Base: "main"}},
+			{Repo: "acme/app", Number: 2, State: "open", Pull: &ghtest.Pull{Base: "main"}},
+		},
+	})
+	defer ghServer.Close()
+
+	r := SprintReport{List: List{JiraUrl: jiraServer.URL, GHApiUrl: ghServer.URL}, Board: "Team X"}
+	var report *sprintReport
+	stdout := captureOutput(t, func() (err error) {
+		report, err = r.build()
+		return err
+	})
+	if stdout != "" {
+		t.Errorf("expected nothing written to stdout while building the report, got %q", stdout)
+	}
+
+	if report.SprintID != 7 || report.Goal != "ship it" || len(report.Issues) != 5 {
+		t.Fatalf("expected the 5 issues of active sprint 7, got %+v", report)
+	}
+
+	categories := make([]string, 0)
+	for _, group := range report.Categories {
+		categories = append(categories, group.Name)
+	}
+	if actual := strings.Join(categories, ","); actual != "To Do,In Progress,Done" {
+		t.Errorf("expected the status categories in workflow order, got %s", actual)
+	}
+	if jane := report.Assignees[0]; jane.Name != "jane" || jane.Total != 2 || jane.Done != 2 {
+		t.Errorf("expected jane to have 2 done issues, got %+v", jane)
+	}
+
+	flags := make(map[string]string)
+	for _, issue := range report.Issues {
+		flags[issue.Key] = issue.Flag
+	}
+	expected := map[string]string{"IPL-1": "", "IPL-2": FlagDoneNotMerged, "IPL-3": FlagMergedNotDone, "IPL-4": "", "IPL-6": FlagDoneNotMerged}
+	for key, flag := range expected {
+		if flags[key] != flag {
+			t.Errorf("expected %s to be flagged %q, got %q", key, flag, flags[key])
+		}
+	}
+
+	text, _ := report.render(FormatText)
+	if !strings.Contains(text, "Done but not merged (2)") || !strings.Contains(text, "Merged but not done (1)") {
+		t.Errorf("expected the flagged issues in the text report, got %q", text)
+	}
+	if strings.Contains(text, "pull/99") {
+		t.Errorf("expected lookup errors to only be warned about, got %q", text)
+	}
+	markdown, _ := report.render(FormatMarkdown)
+	if !strings.Contains(markdown, "| jane | 2 | 2 |") || !strings.Contains(markdown, "## Merged but not done (1)") {
+		t.Errorf("expected markdown tables and sections, got %q", markdown)
+	}
+	out, _ := report.render(FormatJSON)
+	decoded := sprintReport{}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil || len(decoded.Issues) != 5 {
+		t.Errorf("expected the json report to decode with 5 issues, got %v: %s", err, out)
+	}
+	if failed := decoded.Issues[4]; failed.Key != "IPL-6" || len(failed.Errors) != 1 || !strings.Contains(failed.Errors[0], "pull/99") {
+		t.Errorf("expected the failed link lookup in the json report, got %+v", failed)
+	}
+
+	if err := (SprintReport{List: List{JiraUrl: jiraServer.URL}, SprintId: 7, Format: "html"}).Report(); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestListSprints(t *testing.T) {
+	s := jiratest.NewServer(jiratest.Seed{
+		Boards: []jiratest.Board{
+			{ID: 1, Name: "Team X", Type: "scrum", ProjectKey: "IPL"},
+			{ID: 2, Name: "Team Y", Type: "scrum", ProjectKey: "IPL"},
+			{ID: 3, Name: "Team Z", Type: "scrum", ProjectKey: "OTHER"},
+			{ID: 4, Name: "Team K", Type: "kanban", ProjectKey: "IPL"},
+		},
+		Sprints: []jiratest.Sprint{{ID: 7, BoardID: 1, Name: "Sprint 41", State: "active"}},
+	})
+	defer s.Close()
+
+	out := captureOutput(t, Sprints{JiraUrl: s.URL, ProjectKey: "IPL", State: "active,future"}.List)
+	for _, expected := range []string{"Board Team X (id 1)", "Board Team Y (id 2)"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in the output, got %q", expected, out)
+		}
+	}
+	if strings.Contains(out, "Team Z") || strings.Contains(out, "Team K") {
+		t.Errorf("expected only the scrum boards of project IPL, got %q", out)
+	}
+
+	out = captureOutput(t, Sprints{JiraUrl: s.URL, Board: "team y"}.List)
+	if !strings.Contains(out, "Board Team Y (id 2)") || strings.Contains(out, "Team X") {
+		t.Errorf("expected only board Team Y, got %q", out)
+	}
+}
+
+func sprintLifecycleServer() *jiratest.Server {
+	return jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "Done", StatusCategory: "done", SprintID: 7},
+			{Key: "IPL-2", Status: "In Progress", StatusCategory: "indeterminate", SprintID: 7},
+			{Key: "IPL-3", Status: "To Do", SprintID: 7},
+			{Key: "IPL-4", Status: "To Do", SprintID: 8},
+		},
+		Boards: []jiratest.Board{{ID: 1, Name: "Team X", Type: "scrum", ProjectKey: "IPL"}},
+		Sprints: []jiratest.Sprint{
+			{ID: 7, BoardID: 1, Name: "Sprint 41", State: "active"},
+			{ID: 8, BoardID: 1, Name: "Sprint 42", State: "future"},
+		},
+	})
+}
+
+func TestCreateAndStartSprint(t *testing.T) {
+	s := sprintLifecycleServer()
+	defer s.Close()
+
+	captureOutput(t, Sprints{JiraUrl: s.URL, Board: "Team X", Name: "Sprint 43", Goal: "more", DryRun: true}.CreateSprint)
+	if len(s.Sprints(1)) != 2 {
+		t.Fatalf("expected a dry run not to create a sprint")
+	}
+
+	captureOutput(t, Sprints{JiraUrl: s.URL, Board: "Team X", Name: "Sprint 43", Goal: "more"}.CreateSprint)
+	sprints := s.Sprints(1)
+	if len(sprints) != 3 || sprints[2].Name != "Sprint 43" || sprints[2].Goal != "more" || sprints[2].State != "future" {
+		t.Fatalf("expected a future sprint 43 to be created, got %+v", sprints)
+	}
+
+	out := captureOutput(t, Sprints{JiraUrl: s.URL, Board: "Team X", Name: "sprint 43"}.CreateSprint)
+	if !strings.Contains(out, "already exists") || len(s.Sprints(1)) != 3 {
+		t.Errorf("expected an existing sprint not to be created again, got %q", out)
+	}
+
+	captureOutput(t, Sprints{JiraUrl: s.URL, Board: "Team X", StartDate: "2026-03-02"}.StartSprint)
+	started := s.Sprint(8)
+	if started.State != "active" || started.StartDate == nil || started.EndDate == nil {
+		t.Fatalf("expected the next sprint 8 to be started, got %+v", started)
+	}
+	if days := started.EndDate.Sub(*started.StartDate).Hours() / 24; days != 14 {
+		t.Errorf("expected the sprint to run for 14 days by default, got %v", days)
+	}
+
+	err := Sprints{JiraUrl: s.URL, Board: "Team X", Sprint: "Sprint 41"}.StartSprint()
+	if err == nil {
+		t.Errorf("expected an error starting an active sprint")
+	}
+}
+
+func TestCompleteSprint(t *testing.T) {
+	cases := []struct {
+		name     string
+		sprints  Sprints
+		expected map[string]int
+		closed   bool
+	}{
+		{
+			name:     "dry run",
+			sprints:  Sprints{DryRun: true},
+			expected: map[string]int{"IPL-1": 7, "IPL-2": 7, "IPL-3": 7, "IPL-4": 8},
+		},
+		{
+			name:     "next sprint",
+			sprints:  Sprints{},
+			expected: map[string]int{"IPL-1": 7, "IPL-2": 8, "IPL-3": 8, "IPL-4": 8},
+			closed:   true,
+		},
+		{
+			name:     "backlog",
+			sprints:  Sprints{CarryOver: "backlog"},
+			expected: map[string]int{"IPL-1": 7, "IPL-2": 0, "IPL-3": 0, "IPL-4": 8},
+			closed:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := sprintLifecycleServer()
+			defer s.Close()
+
+			sprints := tc.sprints
+			sprints.JiraUrl = s.URL
+			sprints.Board = "Team X"
+			out := captureOutput(t, sprints.CompleteSprint)
+
+			for key, sprint := range tc.expected {
+				if actual := s.Issue(key).SprintID; actual != sprint {
+					t.Errorf("expected %s to be in sprint %d, got %d", key, sprint, actual)
+				}
+			}
+			if closed := s.Sprint(7).State == "closed"; closed != tc.closed {
+				t.Errorf("expected sprint 7 closed to be %v, got state %s", tc.closed, s.Sprint(7).State)
+			}
+			if !strings.Contains(out, "1 of 3 issues done, 2 carried over") {
+				t.Errorf("expected a summary of the carried over issues, got %q", out)
+			}
+		})
+	}
+}
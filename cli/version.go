@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	j "github.com/andygrunwald/go-jira"
+	c "github.com/gookit/color"
+	"github.com/jirallreadyforthis/lib/gh"
+	"github.com/jirallreadyforthis/lib/jira"
+)
+
+type Version struct {
+	JiraToken   string
+	JiraUrl     string
+	UserName    string
+	Jql         string
+	GHToken     string
+	IssueKeys   []string
+	DryRun      bool
+	ProjectKey  string
+	Name        string
+	Description string
+	ReleaseDate string
+	Repo        string
+	Tag         string
+	GHApiUrl    string
+}
+
+func (v Version) project() jira.Project {
+	return jira.Project{
+		Token:    v.JiraToken,
+		UserName: v.UserName,
+		JiraUrl:  v.JiraUrl,
+	}
+}
+
+func (v Version) CreateVersion() error {
+	if v.ProjectKey == "" || v.Name == "" {
+		return fmt.Errorf("both a project key and a version name are required to create a version")
+	}
+
+	existing, err := v.ensureVersion(v.project(), v.ProjectKey)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		c.Warn.Printf("version %s already exists in project %s (id %s)\n", existing.Name, v.ProjectKey, existing.ID)
+	}
+
+	return nil
+}
+
+// ensureVersion creates the version in the project unless it already exists, returning the existing version if so
+func (v Version) ensureVersion(p jira.Project, projectKey string) (*j.Version, error) {
+	existing, err := p.GetVersion(projectKey, v.Name)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	fmt.Printf("creating version %s in project %s\n", v.Name, projectKey)
+	if !v.DryRun {
+		version, err := p.CreateVersion(projectKey, v.Name, v.Description)
+		if err != nil {
+			return nil, err
+		}
+		c.Info.Printf("\nCreated version %s (id %s)\n", version.Name, version.ID)
+	}
+
+	return nil, nil
+}
+
+func (v Version) SetFixVersion() error {
+	if v.Name == "" {
+		return fmt.Errorf("a version name is required to set fix versions")
+	}
+
+	p := v.project()
+
+	count := 0
+	if len(v.IssueKeys) > 0 {
+		for _, issueKey := range v.IssueKeys {
+			issueId, err := getIssueIdFromKey(issueKey, p)
+			if err != nil {
+				return err
+			}
+			if err := v.addFixVersion(issueKey, issueId, p); err != nil {
+				return err
+			}
+			count++
+		}
+	} else if v.Jql != "" {
+		issues, err := p.ListIssues(v.Jql)
+		if err != nil {
+			return err
+		}
+
+		for _, issue := range issues {
+			if err := v.addFixVersion(issue.Key, issue.ID, p); err != nil {
+				return err
+			}
+			count++
+		}
+	}
+
+	c.Info.Printf("\nFinished setting fix version %s on %d issues\n", v.Name, count)
+
+	return nil
+}
+
+func (v Version) ReleaseVersion() error {
+	if v.ProjectKey == "" || v.Name == "" {
+		return fmt.Errorf("both a project key and a version name are required to release a version")
+	}
+
+	releaseDate := v.ReleaseDate
+	if releaseDate == "" {
+		releaseDate = time.Now().Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", releaseDate); err != nil {
+		return fmt.Errorf("parsing release date %q, expected format YYYY-MM-DD: %v", releaseDate, err)
+	}
+
+	p := v.project()
+
+	version, err := p.GetVersion(v.ProjectKey, v.Name)
+	if err != nil {
+		return err
+	}
+	if version == nil {
+		return fmt.Errorf("version %s not found in project %s", v.Name, v.ProjectKey)
+	}
+
+	fmt.Printf("releasing version %s (id %s) on %s\n", version.Name, version.ID, releaseDate)
+	if !v.DryRun {
+		if err := p.ReleaseVersion(version.ID, releaseDate); err != nil {
+			return err
+		}
+	}
+
+	c.Info.Printf("\nFinished releasing version %s\n", version.Name)
+
+	return nil
+}
+
+// SetFixVersionFromRelease finds the pull requests merged between the input release tag and the release before it,
+// and sets the fix version on any jira issues referenced by them
+func (v Version) SetFixVersionFromRelease() error {
+	if v.Repo == "" || v.Tag == "" {
+		return fmt.Errorf("both a github repo and a release tag are required to set fix versions from a release")
+	}
+	if !strings.Contains(v.Repo, "/") {
+		return fmt.Errorf("github repo %q should be in the format 'owner/name'", v.Repo)
+	}
+
+	name := v.Name
+	if name == "" {
+		name = v.Tag
+	}
+
+	repo := gh.NewRepo(v.Repo, v.GHToken)
+	repo.BaseURL = v.GHApiUrl
+	previousTag, err := repo.GetPreviousReleaseTag(v.Tag)
+	if err != nil {
+		return err
+	}
+	if previousTag == "" {
+		return fmt.Errorf("release %s is the first release in repo %s, there is no previous release to compare against", v.Tag, v.Repo)
+	}
+
+	fmt.Printf("finding pull requests between %s and %s in %s\n", previousTag, v.Tag, v.Repo)
+	pulls, err := repo.ListPullRequestsBetween(previousTag, v.Tag)
+	if err != nil {
+		return err
+	}
+
+	candidates := make([]string, 0)
+	for _, pr := range pulls {
+		keys := findJiraKeys(strings.Join([]string{pr.GetTitle(), pr.GetBody(), pr.GetHead().GetRef()}, "\n"))
+		for _, key := range keys {
+			if v.ProjectKey != "" && !strings.HasPrefix(key, v.ProjectKey+"-") {
+				continue
+			}
+			candidates = append(candidates, key)
+		}
+		if len(keys) > 0 {
+			fmt.Printf("found %s in %s\n", strings.Join(keys, ", "), pr.GetHTMLURL())
+		}
+	}
+
+	// text like UTF-8 or CVE-2024-1234 looks like an issue key, so only keys of issues that exist are kept
+	p := v.project()
+	issueKeys := make([]string, 0)
+	for _, key := range removeDuplicates(candidates) {
+		if _, err := p.GetIssue(key); err != nil {
+			warnf("skipping %s as no jira issue with that key was found: %v", key, err)
+			continue
+		}
+		issueKeys = append(issueKeys, key)
+	}
+
+	v.Name = name
+	v.IssueKeys = issueKeys
+	v.Jql = ""
+
+	// the version is created in each project with an issue in the release when it doesn't exist yet
+	projectKeys := []string{v.ProjectKey}
+	if v.ProjectKey == "" {
+		projectKeys = make([]string, 0)
+		for _, key := range issueKeys {
+			projectKeys = append(projectKeys, strings.Split(key, "-")[0])
+		}
+		projectKeys = removeDuplicates(projectKeys)
+	}
+	for _, projectKey := range projectKeys {
+		if _, err := v.ensureVersion(p, projectKey); err != nil {
+			return err
+		}
+	}
+
+	return v.SetFixVersion()
+}
+
+func (v Version) addFixVersion(issueKey string, issueId string, p jira.Project) error {
+	fmt.Printf("setting fix version %s on issue (key %s id %s)\n", v.Name, issueKey, issueId)
+	if v.DryRun {
+		return nil
+	}
+
+	return p.AddFixVersion(issueId, v.Name)
+}
+
+func findJiraKeys(text string) []string {
+	re := regexp.MustCompile("\\b[A-Z][A-Z0-9]+-\\d+\\b")
+	return removeDuplicates(re.FindAllString(text, -1))
+}
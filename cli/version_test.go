@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/gh/ghtest"
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestVersion(t *testing.T) {
+	s := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "Done", FixVersions: []string{"v0.9.0"}},
+			{Key: "IPL-2", Status: "Done"},
+		},
+		Projects: []jiratest.Project{
+			{Key: "IPL", Name: "Ready"},
+		},
+	})
+	defer s.Close()
+
+	v := Version{
+		JiraUrl:    s.URL,
+		ProjectKey: "IPL",
+		Name:       "v1.0.0",
+		IssueKeys:  []string{"IPL-1", "IPL-2"},
+	}
+
+	captureOutput(t, v.CreateVersion)
+	project := s.Project("IPL")
+	if len(project.Versions) != 1 || project.Versions[0].Name != "v1.0.0" {
+		t.Fatalf("expected version v1.0.0 to be created, got %+v", project.Versions)
+	}
+
+	captureOutput(t, v.SetFixVersion)
+	if actual := strings.Join(s.Issue("IPL-1").FixVersions, ","); actual != "v0.9.0,v1.0.0" {
+		t.Errorf("expected IPL-1 to have fix versions v0.9.0,v1.0.0, got %s", actual)
+	}
+	if actual := strings.Join(s.Issue("IPL-2").FixVersions, ","); actual != "v1.0.0" {
+		t.Errorf("expected IPL-2 to have fix version v1.0.0, got %s", actual)
+	}
+
+	v.ReleaseDate = "2024-05-01"
+	captureOutput(t, v.ReleaseVersion)
+	released := s.Project("IPL").Versions[0]
+	if !released.Released || released.ReleaseDate != "2024-05-01" {
+		t.Errorf("expected version v1.0.0 to be released on 2024-05-01, got %+v", released)
+	}
+}
+
+func TestVersionFromRelease(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2026, 3, day, 12, 0, 0, 0, time.UTC) }
+	merged := func(number int, title string, sha string, day int) ghtest.Issue {
+		mergedAt := at(day)
+		return ghtest.Issue{Repo: "acme/app", Number: number, Title: title, State: "closed", ClosedAt: &mergedAt,
+			Pull: &ghtest.Pull{Merged: true, MergedAt: &mergedAt, Base: "main", MergeCommitSHA: sha}}
+	}
+
+	ghServer := ghtest.NewServer(ghtest.Seed{
+		Commits: []ghtest.Commit{
+			{SHA: "a", Date: at(1)},
+			{SHA: "b", Parents: []string{"a"}, Date: at(2)},
+			{SHA: "c", Parents: []string{"b"}, Date: at(3)},
+		},
+		Issues: []ghtest.Issue{
+			merged(1, "IPL-1 released before", "a", 1),
+			merged(2, "IPL-2 and OPS-3", "b", 2),
+			merged(3, "IPL-2 follow up, read files as UTF-8", "c", 3),
+		},
+		Releases: []ghtest.Release{
+			{Repo: "acme/app", Tag: "v1.1.0", Commit: "c", PublishedAt: at(4)},
+			{Repo: "acme/app", Tag: "v1.0.0", Commit: "a", PublishedAt: at(1)},
+		},
+	})
+	defer ghServer.Close()
+
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Status: "Done"},
+			{Key: "IPL-2", Status: "Done"},
+			{Key: "OPS-3", Status: "Done"},
+		},
+		Projects: []jiratest.Project{
+			{ID: "1", Key: "IPL", Name: "Ready", Versions: []jiratest.Version{{ID: "1", Name: "v1.0.0"}}},
+			{ID: "2", Key: "OPS", Name: "Ops"},
+		},
+	})
+	defer jiraServer.Close()
+
+	v := Version{JiraUrl: jiraServer.URL, GHApiUrl: ghServer.URL, Repo: "acme/app", Tag: "v1.1.0"}
+	out := captureOutput(t, v.SetFixVersionFromRelease)
+	for _, key := range []string{"IPL", "OPS"} {
+		if versions := jiraServer.Project(key).Versions; versions[len(versions)-1].Name != "v1.1.0" {
+			t.Errorf("expected version v1.1.0 to be created in %s, got %+v", key, versions)
+		}
+	}
+	if len(jiraServer.Issue("IPL-1").FixVersions) != 0 {
+		t.Errorf("expected IPL-1 released before v1.0.0 to be left alone")
+	}
+	for _, key := range []string{"IPL-2", "OPS-3"} {
+		if actual := strings.Join(jiraServer.Issue(key).FixVersions, ","); actual != "v1.1.0" {
+			t.Errorf("expected %s to have fix version v1.1.0, got %q", key, actual)
+		}
+	}
+	if !strings.Contains(out, "Finished setting fix version v1.1.0 on 2 issues") {
+		t.Errorf("expected a summary of the issues updated, got %q", out)
+	}
+}
+
+func TestFindJiraKeys(t *testing.T) {
+	keys := findJiraKeys("IPL-12: fix the thing\n\nAlso fixes IPL-13 and ipl-14, see ABC-1.\nbranch IPL-12-fix")
+	if actual := strings.Join(keys, ","); actual != "IPL-12,IPL-13,ABC-1" {
+		t.Errorf("expected keys IPL-12,IPL-13,ABC-1, got %s", actual)
+	}
+}
@@ -5,14 +5,23 @@ import (
 	"os"
 
 	"github.com/jirallreadyforthis/cli"
+	"github.com/jirallreadyforthis/lib/replay"
 	"github.com/spf13/cobra"
 )
 
 func Make() (*cobra.Command, error) {
+	// cobra only runs the closest persistent pre run by default, so a subcommand with its own would skip
+	// configuring record and replay below
+	cobra.EnableTraverseRunHooks = true
+
 	root := &cobra.Command{
 		Use:   "jirallreadyforthis",
 		Short: "A cli tool for working with Jira issues",
 		Long:  ``, // TODO
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			f := GetFlags()
+			return replay.Configure(f.Record, f.Replay)
+		},
 	}
 
 	root.AddCommand(&cobra.Command{
@@ -22,18 +31,7 @@ func Make() (*cobra.Command, error) {
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Println("Listing issues...")
 
-			f := GetFlags()
-			l := cli.List{
-				JiraToken:    f.JiraToken,
-				JiraUrl:      f.JiraUrl,
-				CustomFields: f.CustomFields,
-				UserName:     f.UserName,
-				Jql:          f.Jql,
-				Linked:       f.Linked,
-				GHToken:      f.GHToken,
-				NotCommented: f.NotCommented,
-				ClosedWithin: f.ClosedWithin,
-			}
+			l := newList(GetFlags())
 			err := l.ListJiraTickets()
 			if err != nil {
 				fmt.Printf("Error listing jira tickets: %v\n", err)
@@ -45,7 +43,11 @@ func Make() (*cobra.Command, error) {
 	root.AddCommand(&cobra.Command{
 		Use:   "set-status",
 		Short: "Change the status on issues",
-		Long:  ``,
+		Long: `Move issues to the last status of the first --transitions workflow they are in.
+
+With --check-log, issues whose status was recently changed by hand are skipped and the reason printed, including on
+a dry run. --skip-if-touched-since-merge isn't supported as set-status doesn't know which pull request prompted the
+change, use pr-sync for that.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Println("Setting statuses....")
 
@@ -61,6 +63,7 @@ func Make() (*cobra.Command, error) {
 				DryRun:      f.DryRun,
 				Transitions: f.Transitions,
 				CheckLog:    f.CheckLog,
+				Guard:       newGuard(f),
 			}
 			err := s.SetStatus()
 			if err != nil {
@@ -73,11 +76,15 @@ func Make() (*cobra.Command, error) {
 	root.AddCommand(&cobra.Command{
 		Use:   "sprint-add",
 		Short: "Add issues to a sprint",
-		Long:  `Add issues to a sprint based on input issue keys (eg 'IPL-000') or issues found with an input jql query`,
+		Long:  `Add issues to a sprint, given by --sprint-id or by --board and --sprint, based on input issue keys (eg 'IPL-000') or issues found with an input jql query`,
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Println("Adding issues to sprint...")
 
 			f := GetFlags()
+			if f.OverCapacity != "warn" && f.OverCapacity != "refuse" {
+				fmt.Printf("error adding issues to sprint: --over-capacity should be 'warn' or 'refuse', got %q\n\n", f.OverCapacity)
+				os.Exit(1)
+			}
 			s := cli.SprintAdd{
 				JiraToken: f.JiraToken,
 				JiraUrl:   f.JiraUrl,
@@ -85,9 +92,18 @@ func Make() (*cobra.Command, error) {
 				Jql:       f.Jql,
 				GHToken:   f.GHToken,
 				SprintId:  f.SprintId,
+				Board:     f.Board,
+				Sprint:    f.Sprint,
 				IssueKeys: f.IssueKeys,
 				DryRun:    f.DryRun,
 				CheckLog:  f.CheckLog,
+				Guard:     newGuard(f),
+				Capacity: cli.Capacity{
+					PointsField: f.PointsField,
+					Sprint:      f.Capacity,
+					Assignees:   f.AssigneeCap,
+					Refuse:      f.OverCapacity == "refuse",
+				},
 			}
 
 			err := s.AddIssuesToSprint()
@@ -98,9 +114,501 @@ func Make() (*cobra.Command, error) {
 		},
 	})
 
+	root.AddCommand(&cobra.Command{
+		Use:   "sprint-remove",
+		Short: "Remove issues from a sprint",
+		Long:  `Move issues out of a sprint, given by --sprint-id or by --board and --sprint, to the backlog based on input issue keys (eg 'IPL-000') or issues found with an input jql query`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Removing issues from sprint...")
+
+			err := newBacklog(GetFlags()).RemoveFromSprint()
+			if err != nil {
+				fmt.Printf("error removing issues from sprint: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:   "backlog",
+		Short: "Move issues to the backlog",
+		Long:  `Move issues out of whichever sprint they are in to the backlog based on input issue keys (eg 'IPL-000') or issues found with an input jql query`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Moving issues to the backlog...")
+
+			err := newBacklog(GetFlags()).MoveToBacklog()
+			if err != nil {
+				fmt.Printf("error moving issues to the backlog: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:   "rank",
+		Short: "Rank issues before or after another issue",
+		Long:  `Rank issues based on input issue keys (eg 'IPL-000') or issues found with an input jql query, in that order, directly before --rank-before or after --rank-after`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Ranking issues...")
+
+			err := newBacklog(GetFlags()).Rank()
+			if err != nil {
+				fmt.Printf("error ranking issues: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	sprint := &cobra.Command{
+		Use:   "sprint",
+		Short: "Manage jira sprints",
+		Long:  `List, create, start, complete and report on the sprints of jira boards`,
+	}
+
+	sprint.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the sprints of boards",
+		Long:  `List the sprints of a board, or of every board in a project, with their id, state, dates and goal`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Listing sprints...")
+
+			err := newSprints(GetFlags()).List()
+			if err != nil {
+				fmt.Printf("error listing sprints: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	sprint.AddCommand(&cobra.Command{
+		Use:   "create",
+		Short: "Create a sprint on a board",
+		Long:  ``,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Creating sprint...")
+
+			err := newSprints(GetFlags()).CreateSprint()
+			if err != nil {
+				fmt.Printf("error creating sprint: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	sprint.AddCommand(&cobra.Command{
+		Use:   "start",
+		Short: "Start the next sprint of a board",
+		Long:  `Start the next future sprint of a board, or the sprint given by --sprint`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Starting sprint...")
+
+			err := newSprints(GetFlags()).StartSprint()
+			if err != nil {
+				fmt.Printf("error starting sprint: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	sprint.AddCommand(&cobra.Command{
+		Use:   "complete",
+		Short: "Complete the active sprint of a board",
+		Long:  `Complete the active sprint of a board, or the sprint given by --sprint, moving the issues in it that aren't done to the sprint or backlog given by --carry-over`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Completing sprint...")
+
+			err := newSprints(GetFlags()).CompleteSprint()
+			if err != nil {
+				fmt.Printf("error completing sprint: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	sprint.AddCommand(&cobra.Command{
+		Use:   "report",
+		Short: "Report on the issues in a sprint and whether their code has landed",
+		Long:  `Group the issues in a sprint, given by --sprint-id or by --board and --sprint (defaulting to the active sprint), by status category and assignee, and flag done issues whose linked pull requests aren't merged and issues whose pull requests are merged but that aren't done`,
+		Run: func(cmd *cobra.Command, args []string) {
+			f := GetFlags()
+			r := cli.SprintReport{
+				List:     newList(f),
+				SprintId: f.SprintId,
+				Board:    f.Board,
+				Sprint:   f.Sprint,
+				Format:   f.Format,
+			}
+			err := r.Report()
+			if err != nil {
+				fmt.Printf("error reporting on sprint: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	root.AddCommand(sprint)
+
+	root.AddCommand(&cobra.Command{
+		Use:   "gh-sync",
+		Short: "Update linked github issues from jira",
+		Long:  `Close, label or comment on the github issues linked from jira issues found with an input jql query, to reflect the status of the jira issues`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Syncing github issues...")
+
+			f := GetFlags()
+			g := cli.GithubSync{
+				List:        newList(f),
+				DryRun:      f.DryRun,
+				Actions:     f.GHActions,
+				LabelPrefix: f.LabelPrefix,
+			}
+			err := g.Sync()
+			if err != nil {
+				fmt.Printf("error syncing github issues: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:   "pr-sync",
+		Short: "Move issues through statuses to follow their pull requests",
+		Long:  `Set the status of issues found with an input jql query from the state of their linked pull requests, eg opened -> In Progress, review requested -> In Review, changes requested -> In Progress, merged -> Done and closed without merging -> To Do`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Syncing pull requests...")
+
+			f := GetFlags()
+			s := cli.PRSync{
+				List:         newList(f),
+				DryRun:       f.DryRun,
+				CheckLog:     f.CheckLog,
+				Guard:        newGuard(f),
+				Transitions:  f.Transitions,
+				StatusMap:    f.PRStatusMap,
+				PollInterval: f.PollInterval,
+			}
+			err := s.Sync()
+			if err != nil {
+				fmt.Printf("error syncing pull requests: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	version := &cobra.Command{
+		Use:   "version",
+		Short: "Manage jira versions (fixVersions)",
+		Long:  `Create and release jira project versions and set them as the fixVersion on issues`,
+	}
+
+	version.AddCommand(&cobra.Command{
+		Use:   "create",
+		Short: "Create a version in a jira project",
+		Long:  ``,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Creating version...")
+
+			err := newVersion(GetFlags()).CreateVersion()
+			if err != nil {
+				fmt.Printf("error creating version: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	version.AddCommand(&cobra.Command{
+		Use:   "set",
+		Short: "Set the fixVersion on issues",
+		Long:  `Add a fixVersion to issues based on input issue keys (eg 'IPL-000') or issues found with an input jql query`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Setting fix versions...")
+
+			err := newVersion(GetFlags()).SetFixVersion()
+			if err != nil {
+				fmt.Printf("error setting fix versions: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	version.AddCommand(&cobra.Command{
+		Use:   "release",
+		Short: "Mark a version as released",
+		Long:  ``,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Releasing version...")
+
+			err := newVersion(GetFlags()).ReleaseVersion()
+			if err != nil {
+				fmt.Printf("error releasing version: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	version.AddCommand(&cobra.Command{
+		Use:   "from-release",
+		Short: "Set the fixVersion on issues referenced by pull requests in a github release",
+		Long:  `Find the pull requests merged between a github release tag and the previous release, extract the jira issue keys from them and set the fixVersion on those issues, creating the version in their projects when it does not exist yet`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Setting fix versions from release...")
+
+			err := newVersion(GetFlags()).SetFixVersionFromRelease()
+			if err != nil {
+				fmt.Printf("error setting fix versions from release: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	root.AddCommand(version)
+
+	analytics := &cobra.Command{
+		Use:   "analytics",
+		Short: "Report metrics computed from issue changelogs",
+		Long:  ``,
+	}
+
+	analytics.AddCommand(&cobra.Command{
+		Use:   "cycle-time",
+		Short: "Report the time issues spend in each status, their lead time and their cycle time",
+		Long:  `For issues found with an input jql query, report the time spent in each status, the lead time (created to done) and the cycle time (first in progress to done) as percentiles, optionally grouped by --group-by, as text or exported as csv or json with --format`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := newAnalytics(GetFlags()).CycleTime()
+			if err != nil {
+				fmt.Printf("error reporting cycle times: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	analytics.AddCommand(&cobra.Command{
+		Use:   "merge-latency",
+		Short: "Report how long issues take to get done after their pull requests merge",
+		Long:  `For issues found with an input jql query, report the time between the last linked pull request merging and the issue reaching a done status, per issue and as percentiles, optionally grouped by --group-by ('type', 'assignee' or 'project'), as text or exported as csv or json with --format`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := newAnalytics(GetFlags()).MergeLatency()
+			if err != nil {
+				fmt.Printf("error reporting merge latency: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	analytics.AddCommand(&cobra.Command{
+		Use:   "burndown",
+		Short: "Rebuild the burndown of a sprint",
+		Long:  `Rebuild the remaining issues, or current story points with --measure points, at the end of each day of the sprint given by --sprint-id or --board and --sprint (defaulting to the active sprint) from issue status changelogs and sprint membership history, as text (with an ascii chart with --chart) or exported as csv or json with --format. Issues since removed from the sprint can be included with --jql`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := newFlow(GetFlags()).Burndown()
+			if err != nil {
+				fmt.Printf("error building burndown: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	analytics.AddCommand(&cobra.Command{
+		Use:   "cumulative-flow",
+		Short: "Rebuild the number of issues in each status per day",
+		Long:  `For issues found with an input jql query, rebuild the number of issues in each status at the end of each day from --start-date to --end-date (defaulting to the last 30 days) from issue changelogs, as text (with an ascii chart with --chart) or exported as csv or json with --format`,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := newFlow(GetFlags()).CumulativeFlow()
+			if err != nil {
+				fmt.Printf("error building cumulative flow: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	root.AddCommand(analytics)
+
+	audit := &cobra.Command{
+		Use:   "audit",
+		Short: "Print a timeline of changes to issues",
+		Long:  `For an input jql query or list of issue keys, print the changelog entries of the issues oldest first with the field, from and to values, author and time, filtered by --fields, --authors, --start-date and --end-date, as text or exported as csv or json with --format. Changes by --bot-accounts (defaulting to the jira user) are marked as automated`,
+		Run: func(cmd *cobra.Command, args []string) {
+			f := GetFlags()
+			a := cli.Audit{
+				JiraToken: f.JiraToken,
+				JiraUrl:   f.JiraUrl,
+				UserName:  f.UserName,
+				Jql:       f.Jql,
+				IssueKeys: f.IssueKeys,
+				Fields:    f.AuditFields,
+				Authors:   f.Authors,
+				StartDate: f.StartDate,
+				EndDate:   f.EndDate,
+				Format:    f.Format,
+				Guard:     newGuard(f),
+			}
+			err := a.Audit()
+			if err != nil {
+				fmt.Printf("error auditing issues: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+	root.AddCommand(audit)
+
+	index := &cobra.Command{
+		Use:   "index",
+		Short: "Maintain a local index of issues and their linked github items",
+		Long:  `Sync jira issues, the github links found in them and the state of those links to a local index, so large projects can be queried quickly and offline`,
+	}
+
+	index.AddCommand(&cobra.Command{
+		Use:   "sync",
+		Short: "Sync issues updated since the last sync into the index",
+		Long:  `Sync the issues matching --jql that were updated since the last sync into the index, and re-check their linked items that aren't closed or merged yet. Once a day a sync also lists every issue matching --jql, to remove issues that no longer match it from the index`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Syncing index...")
+
+			err := newIndex(GetFlags()).Sync()
+			if err != nil {
+				fmt.Printf("error syncing index: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	index.AddCommand(&cobra.Command{
+		Use:   "query",
+		Short: "List issues from the index whose linked github items are closed or merged",
+		Long:  `List issues from the index whose linked github items are closed or merged, filtered like list by --require, --repos, --base-branches, --close-reason and --closed-within. The index doesn't hold reviews, checks or releases so --pr-state, --require-release and --rule are rejected, use list for those`,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Querying index...")
+
+			err := newIndex(GetFlags()).Query()
+			if err != nil {
+				fmt.Printf("error querying index: %v\n\n", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	root.AddCommand(index)
+
 	if err := configureFlags(root); err != nil {
 		return nil, fmt.Errorf("unable to configure flags: %w", err)
 	}
 
 	return root, nil
 }
+
+func newList(f FlagData) cli.List {
+	return cli.List{
+		JiraToken:      f.JiraToken,
+		JiraUrl:        f.JiraUrl,
+		CustomFields:   f.CustomFields,
+		UserName:       f.UserName,
+		Jql:            f.Jql,
+		Linked:         f.Linked,
+		GHToken:        f.GHToken,
+		NotCommented:   f.NotCommented,
+		ClosedWithin:   f.ClosedWithin,
+		BaseBranches:   f.BaseBranches,
+		RequireRelease: f.RequireRelease,
+		PRStates:       f.PRStates,
+		Rule:           f.Rule,
+		RuleFile:       f.RuleFile,
+		Require:        f.Require,
+		Repos:          f.Repos,
+		CloseReason:    f.CloseReason,
+		GHApiUrl:       f.GHApiUrl,
+		GitLabToken:    f.GitLabToken,
+		BitbucketUser:  f.BitbucketUser,
+		BitbucketToken: f.BitbucketToken,
+	}
+}
+
+func newIndex(f FlagData) cli.Index {
+	return cli.Index{
+		List: newList(f),
+		Path: f.IndexPath,
+	}
+}
+
+func newVersion(f FlagData) cli.Version {
+	return cli.Version{
+		JiraToken:   f.JiraToken,
+		JiraUrl:     f.JiraUrl,
+		UserName:    f.UserName,
+		Jql:         f.Jql,
+		GHToken:     f.GHToken,
+		IssueKeys:   f.IssueKeys,
+		DryRun:      f.DryRun,
+		ProjectKey:  f.ProjectKey,
+		Name:        f.VersionName,
+		Description: f.VersionDesc,
+		ReleaseDate: f.ReleaseDate,
+		Repo:        f.Repo,
+		Tag:         f.Tag,
+		GHApiUrl:    f.GHApiUrl,
+	}
+}
+
+func newSprints(f FlagData) cli.Sprints {
+	return cli.Sprints{
+		JiraToken:  f.JiraToken,
+		JiraUrl:    f.JiraUrl,
+		UserName:   f.UserName,
+		ProjectKey: f.ProjectKey,
+		Board:      f.Board,
+		State:      f.SprintState,
+		Sprint:     f.Sprint,
+		Name:       f.SprintName,
+		Goal:       f.SprintGoal,
+		StartDate:  f.StartDate,
+		EndDate:    f.EndDate,
+		CarryOver:  f.CarryOver,
+		DryRun:     f.DryRun,
+	}
+}
+
+func newBacklog(f FlagData) cli.Backlog {
+	return cli.Backlog{
+		JiraToken:  f.JiraToken,
+		JiraUrl:    f.JiraUrl,
+		UserName:   f.UserName,
+		Jql:        f.Jql,
+		IssueKeys:  f.IssueKeys,
+		DryRun:     f.DryRun,
+		SprintId:   f.SprintId,
+		Board:      f.Board,
+		Sprint:     f.Sprint,
+		RankBefore: f.RankBefore,
+		RankAfter:  f.RankAfter,
+	}
+}
+
+func newAnalytics(f FlagData) cli.Analytics {
+	return cli.Analytics{
+		List:        newList(f),
+		GroupBy:     f.GroupBy,
+		Format:      f.Format,
+		Percentiles: f.Percentiles,
+	}
+}
+
+func newFlow(f FlagData) cli.Flow {
+	return cli.Flow{
+		Analytics:   newAnalytics(f),
+		SprintId:    f.SprintId,
+		Board:       f.Board,
+		Sprint:      f.Sprint,
+		StartDate:   f.StartDate,
+		EndDate:     f.EndDate,
+		Measure:     f.Measure,
+		PointsField: f.PointsField,
+		Chart:       f.Chart,
+	}
+}
+
+func newGuard(f FlagData) cli.Guard {
+	return cli.Guard{
+		Within:                  f.RespectWithin,
+		BotAccounts:             f.BotAccounts,
+		SkipIfTouchedSinceMerge: f.SkipIfTouched,
+	}
+}
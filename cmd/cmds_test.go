@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	c "github.com/gookit/color"
+	"github.com/jirallreadyforthis/lib/gh/ghtest"
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+	"github.com/jirallreadyforthis/lib/replay"
+	"github.com/spf13/cobra"
+)
+
+func TestRecordConfiguredForSubcommandsWithTheirOwnPreRun(t *testing.T) {
+	root, err := Make()
+	if err != nil {
+		t.Fatalf("making commands: %v", err)
+	}
+	t.Cleanup(func() { replay.Configure("", "") })
+
+	enabled := false
+	root.AddCommand(&cobra.Command{
+		Use:              "probe",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+			enabled = replay.Enabled()
+		},
+	})
+	root.SetArgs([]string{"probe", "--record", t.TempDir()})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("executing: %v", err)
+	}
+
+	if !enabled {
+		t.Errorf("expected recording to be configured before the subcommand ran")
+	}
+}
+
+func TestListRecordAndReplay(t *testing.T) {
+	merged := time.Now().AddDate(0, 0, -2)
+	jiraServer := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{
+			{Key: "IPL-1", Summary: "merged", Status: "In Review", Description: "https://github.com/acme/app/pull/1"},
+			{Key: "IPL-2", Summary: "open", Status: "In Review", Description: "https://github.com/acme/app/pull/2"},
+		},
+	})
+	ghServer := ghtest.NewServer(ghtest.Seed{
+		Issues: []ghtest.Issue{
+			{Repo: "acme/app", Number: 1, State: "closed", ClosedAt: &merged, Pull: &ghtest.Pull{Merged: true, MergedAt: &merged, Base: "main"}},
+			{Repo: "acme/app", Number: 2, State: "open", Pull: &ghtest.Pull{Base: "main"}},
+		},
+	})
+	t.Cleanup(func() { replay.Configure("", "") })
+
+	list := func(args ...string) string {
+		t.Helper()
+		root, err := Make()
+		if err != nil {
+			t.Fatalf("making commands: %v", err)
+		}
+
+		var buf bytes.Buffer
+		c.SetOutput(&buf)
+		enabled := c.Enable
+		c.Enable = false
+		defer func() {
+			c.ResetOutput()
+			c.Enable = enabled
+		}()
+
+		root.SetArgs(append([]string{"list", "--jira-url", jiraServer.URL, "--github-api-url", ghServer.URL, "--jql", "project = IPL"}, args...))
+		if err := root.Execute(); err != nil {
+			t.Fatalf("executing: %v", err)
+		}
+		return buf.String()
+	}
+
+	dir := t.TempDir()
+	recorded := list("--record", dir)
+	if !strings.Contains(recorded, "IPL-1") || strings.Contains(recorded, "IPL-2") {
+		t.Fatalf("expected only the issue with a merged pull request to be listed, got %q", recorded)
+	}
+
+	// replaying needs nothing from the servers
+	jiraServer.Close()
+	ghServer.Close()
+	if replayed := list("--replay", dir); replayed != recorded {
+		t.Errorf("expected replaying to list the same issues as recording, got %q and %q", replayed, recorded)
+	}
+}
@@ -2,27 +2,77 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 type FlagData struct {
-	JiraToken    string
-	JiraUrl      string
-	UserName     string
-	Jql          string
-	GHToken      string
-	IssueKeys    []string
-	DryRun       bool
-	Transitions  []string
-	Debug        bool
-	SprintId     int
-	CustomFields []string
-	Linked       bool
-	NotCommented int
-	ClosedWithin int
-	CheckLog     bool
+	JiraToken      string
+	JiraUrl        string
+	UserName       string
+	Jql            string
+	GHToken        string
+	IssueKeys      []string
+	DryRun         bool
+	Transitions    []string
+	Debug          bool
+	SprintId       int
+	CustomFields   []string
+	Linked         bool
+	NotCommented   int
+	ClosedWithin   int
+	CheckLog       bool
+	ProjectKey     string
+	VersionName    string
+	VersionDesc    string
+	ReleaseDate    string
+	Repo           string
+	Tag            string
+	BaseBranches   []string
+	RequireRelease bool
+	PRStates       []string
+	Rule           string
+	RuleFile       string
+	Require        string
+	Repos          []string
+	CloseReason    string
+	IndexPath      string
+	Record         string
+	Replay         string
+	GHApiUrl       string
+	GitLabToken    string
+	BitbucketUser  string
+	BitbucketToken string
+	GHActions      []string
+	LabelPrefix    string
+	PRStatusMap    []string
+	PollInterval   time.Duration
+	RespectWithin  string
+	BotAccounts    []string
+	SkipIfTouched  bool
+	Board          string
+	Sprint         string
+	SprintState    string
+	SprintName     string
+	SprintGoal     string
+	StartDate      string
+	EndDate        string
+	CarryOver      string
+	RankBefore     string
+	RankAfter      string
+	PointsField    string
+	Capacity       float64
+	AssigneeCap    []string
+	OverCapacity   string
+	Format         string
+	GroupBy        string
+	Measure        string
+	Chart          bool
+	AuditFields    []string
+	Authors        []string
+	Percentiles    []int
 }
 
 func configureFlags(root *cobra.Command) error {
@@ -31,8 +81,8 @@ func configureFlags(root *cobra.Command) error {
 
 	pflags.StringVarP(&flags.JiraUrl, "jira-url", "j", "", "The base jira url eg 'https://readyforthis.atlassian.net/'")
 	pflags.StringVarP(&flags.UserName, "jira-user", "u", "", "User name associated with the jira token")
-	pflags.StringVarP(&flags.UserName, "token-jira", "", "", "Jira API token")
-	pflags.StringVarP(&flags.UserName, "token-gh", "", "", "Github API token")
+	pflags.StringVarP(&flags.JiraToken, "token-jira", "", "", "Jira API token")
+	pflags.StringVarP(&flags.GHToken, "token-gh", "", "", "Github API token")
 	pflags.StringVarP(&flags.Jql, "jql", "", "", "Jql query string to filter issues on")
 	pflags.BoolVarP(&flags.DryRun, "dry-run", "", true, "Print a simulation of what is expected without making actual changes. Defaults to true.")
 
@@ -44,25 +94,124 @@ func configureFlags(root *cobra.Command) error {
 
 	pflags.IntVarP(&flags.NotCommented, "not-commented", "", 0, "Filter issues based on whether they have been commented on in a specified number of days.")
 	pflags.BoolVarP(&flags.Linked, "linked", "", true, "Only list jira issues with either github issues that are closed or pull requests that are merged. Defaults to true.")
-	pflags.IntVarP(&flags.NotCommented, "closed-within", "", 0, "Filter issues based on whether they have a linked github issue/pr that has been closed within a specified number of days.")
-	pflags.BoolVarP(&flags.Linked, "check-log", "", true, "Setting this to true checks the changelog for the latest sprint/status updates and avoids reverting them. Defaults to true.")
+	pflags.IntVarP(&flags.ClosedWithin, "closed-within", "", 0, "Filter issues based on whether they have a linked github issue/pr that has been closed within a specified number of days.")
+	pflags.BoolVarP(&flags.CheckLog, "check-log", "", true, "Setting this to true checks the changelog for the latest sprint/status updates and avoids reverting them. Defaults to true.")
+
+	pflags.StringVarP(&flags.ProjectKey, "project", "p", "", "The key of the jira project eg 'IPL'")
+	pflags.StringVarP(&flags.VersionName, "version-name", "", "", "The name of the jira version (fixVersion). When setting fix versions from a release this defaults to the release tag")
+	pflags.StringVarP(&flags.VersionDesc, "version-description", "", "", "The description of the jira version to create")
+	pflags.StringVarP(&flags.ReleaseDate, "release-date", "", "", "The date to release the jira version on in the format YYYY-MM-DD. Defaults to today.")
+	pflags.StringVarP(&flags.Repo, "repo", "", "", "The github repo in the format 'owner/name'")
+	pflags.StringVarP(&flags.Tag, "tag", "", "", "The github release tag")
+	pflags.StringSliceVarP(&flags.BaseBranches, "base-branches", "", []string{}, "Only count pull requests merged into these base branches, patterns are supported eg 'main,release/*'")
+	pflags.BoolVarP(&flags.RequireRelease, "require-release", "", false, "Only count pull requests whose merge commit is part of a github release")
+	pflags.StringSliceVarP(&flags.PRStates, "pr-state", "", []string{}, "The states a linked pull request must all be in to count, any of 'merged', 'approved' or 'checks-passing'. Defaults to 'merged'.")
+	pflags.StringVarP(&flags.Rule, "rule", "", "", "A readiness rule expression to list issues with eg 'count(prs) > 0 && all(prs, .merged && .base == \"main\") && issue.status != \"Done\"', all() is true for an empty list so check count() to require linked items")
+	pflags.StringVarP(&flags.RuleFile, "rule-file", "", "", "A file containing a readiness rule expression, lines starting with '#' are ignored")
+	pflags.StringVarP(&flags.Require, "require", "", "any", "Whether 'any' or 'all' of the linked github issues/prs need to be closed or merged for an issue to be listed. Defaults to 'any'.")
+	pflags.StringSliceVarP(&flags.Repos, "repos", "", []string{}, "Only follow github, gitlab and bitbucket links to these repos, patterns are supported eg 'owner/name,owner/*'")
+	pflags.StringVarP(&flags.CloseReason, "close-reason", "", "any", "Only count github issues closed for this reason, one of 'completed', 'not_planned' or 'any'. Defaults to 'any'.")
+	pflags.StringVarP(&flags.IndexPath, "index-path", "", "", "The file to store the local index in. Defaults to a file in the user cache dir.")
+	pflags.StringVarP(&flags.Record, "record", "", "", "Record the jira and github http requests made to this dir, with credentials removed")
+	pflags.StringVarP(&flags.Replay, "replay", "", "", "Replay jira and github http responses from a dir created with --record instead of making requests")
+	pflags.StringVarP(&flags.GHApiUrl, "github-api-url", "", "", "The github api url to use instead of https://api.github.com/, eg for github enterprise")
+	pflags.StringVarP(&flags.GitLabToken, "token-gitlab", "", "", "Gitlab API token, used for gitlab.com and self-hosted gitlab links")
+	pflags.StringVarP(&flags.BitbucketUser, "bitbucket-user", "", "", "User name associated with the bitbucket token, when set the token is used as an app password")
+	pflags.StringVarP(&flags.BitbucketToken, "token-bitbucket", "", "", "Bitbucket API token, used for bitbucket cloud and data center links")
+	pflags.StringSliceVarP(&flags.GHActions, "gh-actions", "", []string{}, "The changes gh-sync makes to linked github issues, any of 'close' (when the jira issue is done), 'labels' (mirroring the jira status) or 'comment' (with the jira status and assignee)")
+	pflags.StringVarP(&flags.LabelPrefix, "status-label-prefix", "", "jira: ", "The prefix of the github labels mirroring the jira status. Defaults to 'jira: '.")
+	pflags.StringSliceVarP(&flags.PRStatusMap, "pr-status-map", "", []string{}, "Override the jira status pr-sync moves issues to for a pull request event, in the format 'event=status' eg 'merged=Closed'. Events are 'opened', 'review-requested', 'changes-requested', 'approved', 'merged' and 'closed'")
+	pflags.DurationVarP(&flags.PollInterval, "poll-interval", "", 0, "How often pr-sync polls pull requests eg '5m'. Defaults to syncing once.")
+	pflags.StringVarP(&flags.RespectWithin, "respect-manual-changes-within", "", "7d", "With --check-log, how far back a manual change away from the target status stops an issue being moved eg '7d' or '12h'. Defaults to '7d'.")
+	pflags.StringSliceVarP(&flags.BotAccounts, "bot-accounts", "", []string{}, "The jira accounts this tool runs as, changes made by them are not treated as manual changes. Defaults to the jira user, matched by its login and account id.")
+	pflags.BoolVarP(&flags.SkipIfTouched, "skip-if-touched-since-merge", "", false, "With --check-log, leave issues alone that anyone changed after their linked pull request merged. Only pr-sync knows the merge time, set-status rejects this.")
+	pflags.StringVarP(&flags.Board, "board", "", "", "The id or name of the jira board the sprint is on")
+	pflags.StringVarP(&flags.Sprint, "sprint", "", "", "The sprint to use instead of --sprint-id, by name, id, 'active' for the board's active sprint or 'next' for its next future sprint")
+	pflags.StringVarP(&flags.SprintState, "sprint-state", "", "active,future", "The states of the sprints to list, any of 'future', 'active' or 'closed'. Defaults to 'active,future'.")
+	pflags.StringVarP(&flags.SprintName, "sprint-name", "", "", "The name of the sprint to create")
+	pflags.StringVarP(&flags.SprintGoal, "sprint-goal", "", "", "The goal of the sprint to create")
+	pflags.StringVarP(&flags.StartDate, "start-date", "", "", "The date a sprint starts on in the format YYYY-MM-DD, or the first day of a cumulative flow or audit. When starting a sprint this defaults to today.")
+	pflags.StringVarP(&flags.EndDate, "end-date", "", "", "The date a sprint ends on in the format YYYY-MM-DD, or the last day of a cumulative flow or audit. When starting a sprint this defaults to two weeks after it starts.")
+	pflags.StringVarP(&flags.CarryOver, "carry-over", "", "next", "Where completing a sprint moves its unfinished issues, 'next' for the next sprint (or the backlog if there isn't one), 'backlog', or a sprint name or id. Defaults to 'next'.")
+	pflags.StringVarP(&flags.RankBefore, "rank-before", "", "", "The key of the issue to rank issues directly before")
+	pflags.StringVarP(&flags.RankAfter, "rank-after", "", "", "The key of the issue to rank issues directly after")
+	pflags.StringVarP(&flags.PointsField, "story-points-field", "", "", "The id or name of the story points field eg 'customfield_10016'. Defaults to the field named 'Story Points' or 'Story point estimate' when checking capacity or burning down points.")
+	pflags.Float64VarP(&flags.Capacity, "capacity", "", 0, "The most story points a sprint should hold, sprint-add checks the points in the sprint against this when set")
+	pflags.StringSliceVarP(&flags.AssigneeCap, "assignee-capacity", "", []string{}, "The most story points each assignee should have in a sprint in the format 'name=points' eg 'Jane Doe=8'")
+	pflags.StringVarP(&flags.OverCapacity, "over-capacity", "", "warn", "What sprint-add does when a sprint would be over capacity, 'warn' or 'refuse'. Defaults to 'warn'.")
+	pflags.StringVarP(&flags.Format, "format", "", "text", "The output format of reports, 'text', 'markdown', 'csv' or 'json' depending on the report. Defaults to 'text'.")
+	pflags.StringVarP(&flags.GroupBy, "group-by", "", "", "Report analytics for each issue 'type', 'assignee' or 'project' separately. Defaults to all issues together.")
+	pflags.IntSliceVarP(&flags.Percentiles, "percentiles", "", []int{50, 85, 95}, "The percentiles of times to report. Defaults to 50,85,95.")
+	pflags.StringVarP(&flags.Measure, "measure", "", "issues", "What a burndown counts, 'issues' or story 'points'. Points are each issue's current story points, estimate changes during the sprint aren't replayed. Defaults to 'issues'.")
+	pflags.BoolVarP(&flags.Chart, "chart", "", false, "Add an ascii chart to text output of burndowns and cumulative flows")
+	pflags.StringSliceVarP(&flags.AuditFields, "fields", "", []string{}, "The changed fields to audit eg 'status' or 'Sprint'. Defaults to all fields.")
+	pflags.StringSliceVarP(&flags.Authors, "authors", "", []string{}, "The accounts whose changes to audit, by name, email, account id or display name. Defaults to everyone.")
 
 	// binding map for viper/pflag -> env
 	m := map[string]string{
-		"jira-url":      "JIRA_URL",
-		"jira-user":     "JIRA_USER",
-		"token-jira":    "JIRA_TOKEN",
-		"token-gh":      "GITHUB_TOKEN",
-		"jql":           "",
-		"dry-run":       "",
-		"issue-keys":    "",
-		"transitions":   "",
-		"sprint-id":     "",
-		"custom-fields": "",
-		"not-commented": "",
-		"linked":        "",
-		"closed-within": "",
-		"check-log":     "",
+		"jira-url":                      "JIRA_URL",
+		"jira-user":                     "JIRA_USER",
+		"token-jira":                    "JIRA_TOKEN",
+		"token-gh":                      "GITHUB_TOKEN",
+		"jql":                           "",
+		"dry-run":                       "",
+		"issue-keys":                    "",
+		"transitions":                   "",
+		"sprint-id":                     "",
+		"custom-fields":                 "",
+		"not-commented":                 "",
+		"linked":                        "",
+		"closed-within":                 "",
+		"check-log":                     "",
+		"project":                       "",
+		"version-name":                  "",
+		"version-description":           "",
+		"release-date":                  "",
+		"repo":                          "",
+		"tag":                           "",
+		"base-branches":                 "",
+		"require-release":               "",
+		"pr-state":                      "",
+		"rule":                          "",
+		"rule-file":                     "",
+		"require":                       "",
+		"repos":                         "",
+		"close-reason":                  "",
+		"index-path":                    "",
+		"record":                        "",
+		"replay":                        "",
+		"github-api-url":                "GITHUB_API_URL",
+		"token-gitlab":                  "GITLAB_TOKEN",
+		"bitbucket-user":                "BITBUCKET_USER",
+		"token-bitbucket":               "BITBUCKET_TOKEN",
+		"gh-actions":                    "",
+		"status-label-prefix":           "",
+		"pr-status-map":                 "",
+		"poll-interval":                 "",
+		"respect-manual-changes-within": "",
+		"bot-accounts":                  "",
+		"skip-if-touched-since-merge":   "",
+		"board":                         "JIRA_BOARD",
+		"sprint":                        "",
+		"sprint-state":                  "",
+		"sprint-name":                   "",
+		"sprint-goal":                   "",
+		"start-date":                    "",
+		"end-date":                      "",
+		"carry-over":                    "",
+		"rank-before":                   "",
+		"rank-after":                    "",
+		"story-points-field":            "JIRA_STORY_POINTS_FIELD",
+		"capacity":                      "",
+		"assignee-capacity":             "",
+		"over-capacity":                 "",
+		"format":                        "",
+		"group-by":                      "",
+		"percentiles":                   "",
+		"measure":                       "",
+		"chart":                         "",
+		"fields":                        "",
+		"authors":                       "",
 	}
 
 	for name, env := range m {
@@ -81,20 +230,69 @@ func configureFlags(root *cobra.Command) error {
 
 func GetFlags() FlagData {
 	return FlagData{
-		JiraToken:    viper.GetString("token-jira"),
-		JiraUrl:      viper.GetString("jira-url"),
-		UserName:     viper.GetString("jira-user"),
-		Jql:          viper.GetString("jql"),
-		GHToken:      viper.GetString("token-gh"),
-		IssueKeys:    viper.GetStringSlice("issue-keys"),
-		DryRun:       viper.GetBool("dry-run"),
-		Transitions:  viper.GetStringSlice("transitions"),
-		Debug:        viper.GetBool("debug"),
-		SprintId:     viper.GetInt("sprint-id"),
-		CustomFields: viper.GetStringSlice("custom-fields"),
-		Linked:       viper.GetBool("linked"),
-		NotCommented: viper.GetInt("not-commented"),
-		ClosedWithin: viper.GetInt("closed-within"),
-		CheckLog:     viper.GetBool("check-log"),
+		JiraToken:      viper.GetString("token-jira"),
+		JiraUrl:        viper.GetString("jira-url"),
+		UserName:       viper.GetString("jira-user"),
+		Jql:            viper.GetString("jql"),
+		GHToken:        viper.GetString("token-gh"),
+		IssueKeys:      viper.GetStringSlice("issue-keys"),
+		DryRun:         viper.GetBool("dry-run"),
+		Transitions:    viper.GetStringSlice("transitions"),
+		Debug:          viper.GetBool("debug"),
+		SprintId:       viper.GetInt("sprint-id"),
+		CustomFields:   viper.GetStringSlice("custom-fields"),
+		Linked:         viper.GetBool("linked"),
+		NotCommented:   viper.GetInt("not-commented"),
+		ClosedWithin:   viper.GetInt("closed-within"),
+		CheckLog:       viper.GetBool("check-log"),
+		ProjectKey:     viper.GetString("project"),
+		VersionName:    viper.GetString("version-name"),
+		VersionDesc:    viper.GetString("version-description"),
+		ReleaseDate:    viper.GetString("release-date"),
+		Repo:           viper.GetString("repo"),
+		Tag:            viper.GetString("tag"),
+		BaseBranches:   viper.GetStringSlice("base-branches"),
+		RequireRelease: viper.GetBool("require-release"),
+		PRStates:       viper.GetStringSlice("pr-state"),
+		Rule:           viper.GetString("rule"),
+		RuleFile:       viper.GetString("rule-file"),
+		Require:        viper.GetString("require"),
+		Repos:          viper.GetStringSlice("repos"),
+		CloseReason:    viper.GetString("close-reason"),
+		IndexPath:      viper.GetString("index-path"),
+		Record:         viper.GetString("record"),
+		Replay:         viper.GetString("replay"),
+		GHApiUrl:       viper.GetString("github-api-url"),
+		GitLabToken:    viper.GetString("token-gitlab"),
+		BitbucketUser:  viper.GetString("bitbucket-user"),
+		BitbucketToken: viper.GetString("token-bitbucket"),
+		GHActions:      viper.GetStringSlice("gh-actions"),
+		LabelPrefix:    viper.GetString("status-label-prefix"),
+		PRStatusMap:    viper.GetStringSlice("pr-status-map"),
+		PollInterval:   viper.GetDuration("poll-interval"),
+		RespectWithin:  viper.GetString("respect-manual-changes-within"),
+		BotAccounts:    viper.GetStringSlice("bot-accounts"),
+		SkipIfTouched:  viper.GetBool("skip-if-touched-since-merge"),
+		Board:          viper.GetString("board"),
+		Sprint:         viper.GetString("sprint"),
+		SprintState:    viper.GetString("sprint-state"),
+		SprintName:     viper.GetString("sprint-name"),
+		SprintGoal:     viper.GetString("sprint-goal"),
+		StartDate:      viper.GetString("start-date"),
+		EndDate:        viper.GetString("end-date"),
+		CarryOver:      viper.GetString("carry-over"),
+		RankBefore:     viper.GetString("rank-before"),
+		RankAfter:      viper.GetString("rank-after"),
+		PointsField:    viper.GetString("story-points-field"),
+		Capacity:       viper.GetFloat64("capacity"),
+		AssigneeCap:    viper.GetStringSlice("assignee-capacity"),
+		OverCapacity:   viper.GetString("over-capacity"),
+		Format:         viper.GetString("format"),
+		GroupBy:        viper.GetString("group-by"),
+		Percentiles:    viper.GetIntSlice("percentiles"),
+		Measure:        viper.GetString("measure"),
+		Chart:          viper.GetBool("chart"),
+		AuditFields:    viper.GetStringSlice("fields"),
+		Authors:        viper.GetStringSlice("authors"),
 	}
 }
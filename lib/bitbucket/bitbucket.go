@@ -0,0 +1,93 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jirallreadyforthis/lib/replay"
+)
+
+const CloudApiUrl = "https://api.bitbucket.org/2.0"
+
+// Repo is a repository in either bitbucket cloud or a bitbucket data center (server) instance
+type Repo struct {
+	// BaseURL is the cloud api url, or the url of the data center instance eg 'https://bitbucket.example.com'
+	BaseURL string
+	// Project is the workspace of a cloud repo or the project key of a data center repo
+	Project string
+	Slug    string
+	// DataCenter is set for repos on a self-hosted instance, which has a different api to bitbucket cloud
+	DataCenter bool
+	// UserName is used with Token for basic auth eg with an app password, the token is sent as a bearer token without it
+	UserName string
+	Token    string
+}
+
+func NewCloudRepo(workspace, slug, userName, token string) Repo {
+	return Repo{
+		BaseURL:  CloudApiUrl,
+		Project:  workspace,
+		Slug:     slug,
+		UserName: userName,
+		Token:    token,
+	}
+}
+
+func NewDataCenterRepo(baseURL, project, slug, userName, token string) Repo {
+	return Repo{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Project:    project,
+		Slug:       slug,
+		DataCenter: true,
+		UserName:   userName,
+		Token:      token,
+	}
+}
+
+func (r Repo) NewClient() *http.Client {
+	return &http.Client{
+		// records or replays requests when enabled, otherwise this is the default transport
+		Transport: replay.Transport(nil),
+	}
+}
+
+// repoURL is the api url of the repo, endpoints of the repo are added to it
+func (r Repo) repoURL() string {
+	if r.DataCenter {
+		return fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s", r.BaseURL, r.Project, r.Slug)
+	}
+	return fmt.Sprintf("%s/repositories/%s/%s", r.BaseURL, r.Project, r.Slug)
+}
+
+// get requests an api url and decodes the response into v
+func (r Repo) get(u string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("creating bitbucket request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if r.UserName != "" {
+		req.SetBasicAuth(r.UserName, r.Token)
+	} else if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+
+	resp, err := r.NewClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting %s: %v", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("requesting %s: %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response from %s: %v", u, err)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+// Package bitbuckettest provides an in-memory fake of the parts of the bitbucket cloud and data center apis used by
+// bitbucket.Repo, for testing code that works with bitbucket without a real bitbucket account.
+package bitbuckettest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type PullRequest struct {
+	// Project is the workspace of a cloud repo or the project key of a data center one
+	Project string
+	Slug    string
+	ID      int
+	Title   string
+	// State is 'OPEN', 'MERGED', 'DECLINED' or 'SUPERSEDED'
+	State        string
+	Source       string
+	Destination  string
+	SourceCommit string
+	MergeCommit  string
+	ClosedAt     *time.Time
+	// Reviewers maps reviewer names to 'APPROVED', 'NEEDS_WORK' or 'UNAPPROVED'
+	Reviewers map[string]string
+}
+
+type Seed struct {
+	PullRequests []PullRequest
+	// Builds maps a commit hash to the states of the builds reported against it
+	Builds map[string][]string
+}
+
+// Server serves both the cloud api under CloudApiUrl, and a data center instance at URL
+type Server struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	pullRequests []PullRequest
+	builds       map[string][]string
+	requests     []string
+}
+
+var (
+	cloudPullPath      = regexp.MustCompile(`^/2\.0/repositories/([^/]+)/([^/]+)/pullrequests/(\d+)(/activity)?$`)
+	cloudStatusesPath  = regexp.MustCompile(`^/2\.0/repositories/[^/]+/[^/]+/commit/([^/]+)/statuses$`)
+	dataCenterPullPath = regexp.MustCompile(`^/rest/api/1\.0/projects/([^/]+)/repos/([^/]+)/pull-requests/(\d+)$`)
+	dataCenterBuilds   = regexp.MustCompile(`^/rest/build-status/1\.0/commits/([^/]+)$`)
+)
+
+func NewServer(seed Seed) *Server {
+	s := &Server{
+		pullRequests: seed.PullRequests,
+		builds:       seed.Builds,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// CloudApiUrl is the url to use in place of the bitbucket cloud api
+func (s *Server) CloudApiUrl() string {
+	return s.URL + "/2.0"
+}
+
+// DataCenterURL is the link to a pull request on the fake data center instance
+func (s *Server) DataCenterURL(project, slug string, id int) string {
+	return fmt.Sprintf("%s/projects/%s/repos/%s/pull-requests/%d", s.URL, project, slug, id)
+}
+
+// Requests returns the method and path of every request the server has received
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.requests...)
+}
+
+func (s *Server) findPullRequest(project, slug, id string) *PullRequest {
+	n, _ := strconv.Atoi(id)
+	for i := range s.pullRequests {
+		pr := &s.pullRequests[i]
+		if pr.Project == project && pr.Slug == slug && pr.ID == n {
+			return pr
+		}
+	}
+	return nil
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s is not supported by bitbuckettest", r.Method))
+		return
+	}
+
+	if m := cloudPullPath.FindStringSubmatch(r.URL.Path); m != nil {
+		pr := s.findPullRequest(m[1], m[2], m[3])
+		if pr == nil {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		if m[4] == "/activity" {
+			values := make([]interface{}, 0)
+			if pr.ClosedAt != nil {
+				values = append(values, map[string]interface{}{
+					"update": map[string]interface{}{"state": pr.State, "date": pr.ClosedAt.Format(time.RFC3339)},
+				})
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"values": values})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.cloudJSON(pr))
+		return
+	}
+
+	if m := cloudStatusesPath.FindStringSubmatch(r.URL.Path); m != nil {
+		values := make([]interface{}, 0)
+		for _, state := range s.builds[m[1]] {
+			values = append(values, map[string]interface{}{"state": state})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"values": values})
+		return
+	}
+
+	if m := dataCenterPullPath.FindStringSubmatch(r.URL.Path); m != nil {
+		pr := s.findPullRequest(m[1], m[2], m[3])
+		if pr == nil {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, s.dataCenterJSON(pr))
+		return
+	}
+
+	if m := dataCenterBuilds.FindStringSubmatch(r.URL.Path); m != nil {
+		values := make([]interface{}, 0)
+		for _, state := range s.builds[m[1]] {
+			values = append(values, map[string]interface{}{"state": state})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"values": values, "isLastPage": true})
+		return
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s is not supported by bitbuckettest", r.Method, r.URL.Path))
+}
+
+func (s *Server) cloudJSON(pr *PullRequest) map[string]interface{} {
+	participants := make([]interface{}, 0)
+	for name, status := range pr.Reviewers {
+		state := interface{}(nil)
+		if status == "NEEDS_WORK" {
+			state = "changes_requested"
+		}
+		participants = append(participants, map[string]interface{}{
+			"role":     "REVIEWER",
+			"approved": status == "APPROVED",
+			"state":    state,
+			"user":     map[string]interface{}{"display_name": name},
+		})
+	}
+
+	resp := map[string]interface{}{
+		"id":           pr.ID,
+		"title":        pr.Title,
+		"state":        pr.State,
+		"source":       map[string]interface{}{"branch": map[string]interface{}{"name": pr.Source}, "commit": map[string]interface{}{"hash": pr.SourceCommit}},
+		"destination":  map[string]interface{}{"branch": map[string]interface{}{"name": pr.Destination}},
+		"links":        map[string]interface{}{"html": map[string]interface{}{"href": fmt.Sprintf("https://bitbucket.org/%s/%s/pull-requests/%d", pr.Project, pr.Slug, pr.ID)}},
+		"participants": participants,
+	}
+	if pr.MergeCommit != "" {
+		resp["merge_commit"] = map[string]interface{}{"hash": pr.MergeCommit}
+	}
+	return resp
+}
+
+func (s *Server) dataCenterJSON(pr *PullRequest) map[string]interface{} {
+	reviewers := make([]interface{}, 0)
+	for name, status := range pr.Reviewers {
+		reviewers = append(reviewers, map[string]interface{}{
+			"status": status,
+			"user":   map[string]interface{}{"displayName": name},
+		})
+	}
+
+	resp := map[string]interface{}{
+		"id":        pr.ID,
+		"title":     pr.Title,
+		"state":     pr.State,
+		"fromRef":   map[string]interface{}{"displayId": pr.Source, "latestCommit": pr.SourceCommit},
+		"toRef":     map[string]interface{}{"displayId": pr.Destination},
+		"links":     map[string]interface{}{"self": []interface{}{map[string]interface{}{"href": s.DataCenterURL(pr.Project, pr.Slug, pr.ID)}}},
+		"reviewers": reviewers,
+	}
+	if pr.ClosedAt != nil {
+		resp["closedDate"] = pr.ClosedAt.UnixMilli()
+	}
+	if pr.MergeCommit != "" {
+		resp["properties"] = map[string]interface{}{"mergeCommit": map[string]interface{}{"id": pr.MergeCommit}}
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]interface{}{"error": map[string]interface{}{"message": msg}})
+}
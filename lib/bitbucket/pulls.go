@@ -0,0 +1,288 @@
+package bitbucket
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	StateOpen       = "OPEN"
+	StateMerged     = "MERGED"
+	StateDeclined   = "DECLINED"
+	StateSuperseded = "SUPERSEDED"
+
+	ReviewerApproved   = "APPROVED"
+	ReviewerNeedsWork  = "NEEDS_WORK"
+	ReviewerUnapproved = "UNAPPROVED"
+
+	BuildSuccessful = "SUCCESSFUL"
+	BuildFailed     = "FAILED"
+	BuildInProgress = "INPROGRESS"
+	BuildStopped    = "STOPPED"
+)
+
+// PullRequest is a pull request from either bitbucket api, in the same form for both
+type PullRequest struct {
+	ID    int
+	Title string
+	// State is one of OPEN, MERGED, DECLINED or SUPERSEDED (cloud only)
+	State        string
+	URL          string
+	Draft        bool
+	Source       string
+	Destination  string
+	SourceCommit string
+	MergeCommit  string
+	// MergedAt is set for merged pull requests, ClosedAt for merged and declined ones
+	MergedAt  *time.Time
+	ClosedAt  *time.Time
+	Reviewers []Reviewer
+}
+
+type Reviewer struct {
+	Name string
+	// Status is one of APPROVED, NEEDS_WORK or UNAPPROVED
+	Status string
+}
+
+func (r Repo) GetPullRequest(id int) (*PullRequest, error) {
+	if r.DataCenter {
+		return r.getDataCenterPullRequest(id)
+	}
+	return r.getCloudPullRequest(id)
+}
+
+type cloudRef struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+}
+
+type cloudPullRequest struct {
+	ID          int      `json:"id"`
+	Title       string   `json:"title"`
+	State       string   `json:"state"`
+	Draft       bool     `json:"draft"`
+	Source      cloudRef `json:"source"`
+	Destination cloudRef `json:"destination"`
+	MergeCommit *struct {
+		Hash string `json:"hash"`
+	} `json:"merge_commit"`
+	UpdatedOn *time.Time `json:"updated_on"`
+	Links     struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+	Participants []struct {
+		Role     string `json:"role"`
+		Approved bool   `json:"approved"`
+		State    string `json:"state"`
+		User     struct {
+			DisplayName string `json:"display_name"`
+		} `json:"user"`
+	} `json:"participants"`
+}
+
+func (r Repo) getCloudPullRequest(id int) (*PullRequest, error) {
+	cpr := cloudPullRequest{}
+	if err := r.get(fmt.Sprintf("%s/pullrequests/%d", r.repoURL(), id), &cpr); err != nil {
+		return nil, fmt.Errorf("getting pull request #%d in repo %s/%s: %v", id, r.Project, r.Slug, err)
+	}
+
+	pr := &PullRequest{
+		ID:           cpr.ID,
+		Title:        cpr.Title,
+		State:        cpr.State,
+		URL:          cpr.Links.HTML.Href,
+		Draft:        cpr.Draft,
+		Source:       cpr.Source.Branch.Name,
+		Destination:  cpr.Destination.Branch.Name,
+		SourceCommit: cpr.Source.Commit.Hash,
+	}
+	if cpr.MergeCommit != nil {
+		pr.MergeCommit = cpr.MergeCommit.Hash
+	}
+
+	for _, p := range cpr.Participants {
+		status := ReviewerUnapproved
+		if p.Approved {
+			status = ReviewerApproved
+		} else if p.State == "changes_requested" {
+			status = ReviewerNeedsWork
+		}
+		// participants who only commented aren't reviewers unless they've given a verdict
+		if p.Role != "REVIEWER" && status == ReviewerUnapproved {
+			continue
+		}
+		pr.Reviewers = append(pr.Reviewers, Reviewer{Name: p.User.DisplayName, Status: status})
+	}
+
+	if pr.State == StateOpen {
+		return pr, nil
+	}
+
+	// cloud doesn't return when a pull request was merged or declined, it is found in the activity log instead
+	closedAt, err := r.cloudStateChangedAt(id, pr.State)
+	if err != nil {
+		return nil, err
+	}
+	if closedAt == nil {
+		closedAt = cpr.UpdatedOn
+	}
+	pr.ClosedAt = closedAt
+	if pr.State == StateMerged {
+		pr.MergedAt = closedAt
+	}
+
+	return pr, nil
+}
+
+// cloudStateChangedAt finds when a cloud pull request moved to a state from its activity, nil if it isn't there
+func (r Repo) cloudStateChangedAt(id int, state string) (*time.Time, error) {
+	u := fmt.Sprintf("%s/pullrequests/%d/activity?pagelen=50", r.repoURL(), id)
+	for u != "" {
+		page := struct {
+			Values []struct {
+				Update *struct {
+					State string    `json:"state"`
+					Date  time.Time `json:"date"`
+				} `json:"update"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}{}
+		if err := r.get(u, &page); err != nil {
+			return nil, fmt.Errorf("getting activity for pull request #%d in repo %s/%s: %v", id, r.Project, r.Slug, err)
+		}
+
+		// activity is listed newest first
+		for _, activity := range page.Values {
+			if activity.Update != nil && activity.Update.State == state {
+				date := activity.Update.Date
+				return &date, nil
+			}
+		}
+		u = page.Next
+	}
+	return nil, nil
+}
+
+type dataCenterRef struct {
+	DisplayID    string `json:"displayId"`
+	LatestCommit string `json:"latestCommit"`
+}
+
+type dataCenterPullRequest struct {
+	ID         int           `json:"id"`
+	Title      string        `json:"title"`
+	State      string        `json:"state"`
+	Draft      bool          `json:"draft"`
+	FromRef    dataCenterRef `json:"fromRef"`
+	ToRef      dataCenterRef `json:"toRef"`
+	ClosedDate int64         `json:"closedDate"`
+	Properties struct {
+		MergeCommit *struct {
+			ID string `json:"id"`
+		} `json:"mergeCommit"`
+	} `json:"properties"`
+	Links struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+	Reviewers []struct {
+		Status string `json:"status"`
+		User   struct {
+			DisplayName string `json:"displayName"`
+		} `json:"user"`
+	} `json:"reviewers"`
+}
+
+func (r Repo) getDataCenterPullRequest(id int) (*PullRequest, error) {
+	dpr := dataCenterPullRequest{}
+	if err := r.get(fmt.Sprintf("%s/pull-requests/%d", r.repoURL(), id), &dpr); err != nil {
+		return nil, fmt.Errorf("getting pull request #%d in repo %s/%s: %v", id, r.Project, r.Slug, err)
+	}
+
+	pr := &PullRequest{
+		ID:           dpr.ID,
+		Title:        dpr.Title,
+		State:        dpr.State,
+		Draft:        dpr.Draft,
+		Source:       dpr.FromRef.DisplayID,
+		Destination:  dpr.ToRef.DisplayID,
+		SourceCommit: dpr.FromRef.LatestCommit,
+	}
+	if len(dpr.Links.Self) > 0 {
+		pr.URL = dpr.Links.Self[0].Href
+	}
+	if dpr.Properties.MergeCommit != nil {
+		pr.MergeCommit = dpr.Properties.MergeCommit.ID
+	}
+	for _, reviewer := range dpr.Reviewers {
+		pr.Reviewers = append(pr.Reviewers, Reviewer{Name: reviewer.User.DisplayName, Status: strings.ToUpper(reviewer.Status)})
+	}
+
+	// closedDate is in milliseconds since the epoch and only set once a pull request is merged or declined
+	if dpr.ClosedDate > 0 {
+		closedAt := time.UnixMilli(dpr.ClosedDate).UTC()
+		pr.ClosedAt = &closedAt
+		if pr.State == StateMerged {
+			pr.MergedAt = &closedAt
+		}
+	}
+
+	return pr, nil
+}
+
+// GetBuildStates returns the state of each build reported against a commit, one of SUCCESSFUL, FAILED, INPROGRESS
+// or STOPPED
+func (r Repo) GetBuildStates(commit string) ([]string, error) {
+	states := make([]string, 0)
+
+	if r.DataCenter {
+		start := 0
+		for {
+			page := struct {
+				Values []struct {
+					State string `json:"state"`
+				} `json:"values"`
+				IsLastPage    bool `json:"isLastPage"`
+				NextPageStart int  `json:"nextPageStart"`
+			}{}
+			u := fmt.Sprintf("%s/rest/build-status/1.0/commits/%s?start=%d", r.BaseURL, commit, start)
+			if err := r.get(u, &page); err != nil {
+				return nil, fmt.Errorf("getting build statuses for %s: %v", commit, err)
+			}
+			for _, status := range page.Values {
+				states = append(states, status.State)
+			}
+			if page.IsLastPage {
+				return states, nil
+			}
+			start = page.NextPageStart
+		}
+	}
+
+	u := fmt.Sprintf("%s/commit/%s/statuses?pagelen=100", r.repoURL(), commit)
+	for u != "" {
+		page := struct {
+			Values []struct {
+				State string `json:"state"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}{}
+		if err := r.get(u, &page); err != nil {
+			return nil, fmt.Errorf("getting build statuses for %s: %v", commit, err)
+		}
+		for _, status := range page.Values {
+			states = append(states, status.State)
+		}
+		u = page.Next
+	}
+	return states, nil
+}
@@ -0,0 +1,57 @@
+package bitbucket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/bitbucket/bitbuckettest"
+)
+
+func TestGetPullRequest(t *testing.T) {
+	merged := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+	s := bitbuckettest.NewServer(bitbuckettest.Seed{
+		PullRequests: []bitbuckettest.PullRequest{
+			{Project: "acme", Slug: "app", ID: 1, Title: "merged", State: StateMerged, Destination: "main", SourceCommit: "abc", MergeCommit: "def", ClosedAt: &merged, Reviewers: map[string]string{"reviewer": ReviewerApproved}},
+			{Project: "acme", Slug: "app", ID: 2, Title: "open", State: StateOpen, Destination: "main", Reviewers: map[string]string{"reviewer": ReviewerNeedsWork}},
+		},
+	})
+	defer s.Close()
+
+	repos := map[string]Repo{
+		"cloud":       NewCloudRepo("acme", "app", "", "token"),
+		"data center": NewDataCenterRepo(s.URL, "acme", "app", "", "token"),
+	}
+
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			if !repo.DataCenter {
+				repo.BaseURL = s.CloudApiUrl()
+			}
+
+			pr, err := repo.GetPullRequest(1)
+			if err != nil {
+				t.Fatalf("getting pull request: %v", err)
+			}
+			if pr.State != StateMerged || pr.Destination != "main" || pr.MergeCommit != "def" || pr.SourceCommit != "abc" {
+				t.Errorf("unexpected pull request %+v", pr)
+			}
+			if pr.MergedAt == nil || !pr.MergedAt.Equal(merged) {
+				t.Errorf("expected the pull request to be merged at %s, got %v", merged, pr.MergedAt)
+			}
+			if len(pr.Reviewers) != 1 || pr.Reviewers[0].Status != ReviewerApproved {
+				t.Errorf("expected one approving reviewer, got %+v", pr.Reviewers)
+			}
+
+			pr, err = repo.GetPullRequest(2)
+			if err != nil {
+				t.Fatalf("getting pull request: %v", err)
+			}
+			if pr.State != StateOpen || pr.MergedAt != nil || pr.ClosedAt != nil {
+				t.Errorf("expected an open pull request, got %+v", pr)
+			}
+			if len(pr.Reviewers) != 1 || pr.Reviewers[0].Status != ReviewerNeedsWork {
+				t.Errorf("expected one reviewer requesting changes, got %+v", pr.Reviewers)
+			}
+		})
+	}
+}
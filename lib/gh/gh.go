@@ -2,6 +2,7 @@ package gh
 
 import (
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,11 +10,14 @@ import (
 	"github.com/google/go-github/v52/github"
 	"github.com/gregjones/httpcache"
 	"github.com/gregjones/httpcache/diskcache"
+	"github.com/jirallreadyforthis/lib/replay"
 	"golang.org/x/oauth2"
 )
 
 type Token struct {
 	Token *string
+	// BaseURL is the github api url to use instead of the public api, eg for github enterprise
+	BaseURL string
 }
 
 type Repo struct {
@@ -43,10 +47,15 @@ func (t Token) NewClient() *github.Client {
 	userCacheDir, _ := os.UserCacheDir()
 	cache := diskcache.New(filepath.Join(userCacheDir, "autoReviewCache"))
 
+	var base http.RoundTripper = httpcache.NewTransport(cache)
+	if replay.Enabled() {
+		// skip the cache so every request is recorded, or answered from the recording
+		base = replay.Transport(nil)
+	}
+
+	// unauthenticated requests go straight through, an oauth2 transport without a token source fails every request
 	tc := &http.Client{
-		Transport: &oauth2.Transport{
-			Base: httpcache.NewTransport(cache),
-		},
+		Transport: base,
 	}
 
 	if t.Token != nil {
@@ -55,11 +64,18 @@ func (t Token) NewClient() *github.Client {
 		)
 		tc = &http.Client{
 			Transport: &oauth2.Transport{
-				Base:   httpcache.NewTransport(cache),
+				Base:   base,
 				Source: ts,
 			},
 		}
 	}
 
-	return github.NewClient(tc)
+	client := github.NewClient(tc)
+	if t.BaseURL != "" {
+		if baseURL, err := url.Parse(strings.TrimSuffix(t.BaseURL, "/") + "/"); err == nil {
+			client.BaseURL = baseURL
+		}
+	}
+
+	return client
 }
@@ -0,0 +1,608 @@
+// Package ghtest provides an in-memory fake of the parts of the github rest api used by gh.Repo, for testing
+// code that works with github without a real github account.
+package ghtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Issue is a github issue, or a pull request when Pull is set
+type Issue struct {
+	// Repo is the full name of the repo the issue is in eg 'owner/name'
+	Repo   string
+	Number int
+	Title  string
+	Body   string
+	// State is 'open' or 'closed'
+	State string
+	// StateReason is why a closed issue was closed eg 'completed' or 'not_planned'
+	StateReason string
+	ClosedAt    *time.Time
+	Labels      []string
+	Comments    []string
+	Pull        *Pull
+}
+
+type Pull struct {
+	Merged         bool
+	MergedAt       *time.Time
+	Draft          bool
+	Base           string
+	Head           string
+	HeadSHA        string
+	MergeCommitSHA string
+	Reviews        []Review
+	// RequestedReviewers are the logins of reviewers asked for a review that haven't given one yet
+	RequestedReviewers []string
+}
+
+type Review struct {
+	User string
+	// State is 'APPROVED', 'CHANGES_REQUESTED', 'COMMENTED' or 'DISMISSED'
+	State string
+}
+
+// Status is a commit status or check run result for a commit
+type Status struct {
+	Context string
+	// State is 'success', 'pending' or 'failure'
+	State string
+	// CheckRun reports the status through the checks api rather than the commit status api
+	CheckRun bool
+}
+
+// Commit is a commit in the history of every repo, the commits between two refs are worked out from their parents
+type Commit struct {
+	SHA     string
+	Parents []string
+	Date    time.Time
+}
+
+// Release is a published release, or a draft, of a tag pointing at Commit
+type Release struct {
+	Repo        string
+	Tag         string
+	Commit      string
+	Draft       bool
+	PublishedAt time.Time
+}
+
+type Seed struct {
+	Issues []Issue
+	// Statuses maps a commit sha to its statuses and check runs
+	Statuses map[string][]Status
+	Commits  []Commit
+	// Releases are listed newest published first, like github does
+	Releases []Release
+	// Branches maps a branch name to the sha of its latest commit
+	Branches map[string]string
+	// PageSize is the most items returned for a page of a list, defaulting to 100
+	PageSize int
+}
+
+// Server is a fake github api, its URL can be used as the BaseURL of a gh.Token
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	issues   []*Issue
+	statuses map[string][]Status
+	commits  map[string]Commit
+	releases []Release
+	branches map[string]string
+	pageSize int
+	requests []string
+}
+
+var (
+	issuePath    = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/issues/(\d+)(/comments|/labels(?:/([^/]+))?)?$`)
+	pullPath     = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/pulls/(\d+)(/merge|/reviews)?$`)
+	commitsPath  = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/commits/([^/]+)/(status|check-runs)$`)
+	pullsPath    = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/pulls$`)
+	releasesPath = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/releases$`)
+	comparePath  = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/compare/(.+)\.\.\.(.+)$`)
+)
+
+func NewServer(seed Seed) *Server {
+	s := &Server{
+		statuses: seed.Statuses,
+		commits:  make(map[string]Commit),
+		releases: seed.Releases,
+		branches: seed.Branches,
+		pageSize: seed.PageSize,
+	}
+	if s.pageSize == 0 {
+		s.pageSize = 100
+	}
+	for i := range seed.Issues {
+		issue := seed.Issues[i]
+		s.issues = append(s.issues, &issue)
+	}
+	for _, commit := range seed.Commits {
+		s.commits[commit.SHA] = commit
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Issue returns a copy of the current state of an issue, or nil if there is no such issue
+func (s *Server) Issue(repo string, number int) *Issue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if issue := s.findIssue(repo, number); issue != nil {
+		i := *issue
+		i.Labels = append([]string{}, issue.Labels...)
+		i.Comments = append([]string{}, issue.Comments...)
+		return &i
+	}
+	return nil
+}
+
+// Requests returns the method and path of every request the server has received
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.requests...)
+}
+
+func (s *Server) findIssue(repo string, number int) *Issue {
+	for _, issue := range s.issues {
+		if strings.EqualFold(issue.Repo, repo) && issue.Number == number {
+			return issue
+		}
+	}
+	return nil
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	// stop the gh client's disk cache keeping responses between tests
+	w.Header().Set("Cache-Control", "no-store")
+
+	if m := issuePath.FindStringSubmatch(r.URL.Path); m != nil {
+		number, _ := strconv.Atoi(m[2])
+		issue := s.findIssue(m[1], number)
+		if issue == nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		s.handleIssue(w, r, issue, m[3], m[4])
+		return
+	}
+
+	if m := pullPath.FindStringSubmatch(r.URL.Path); m != nil && r.Method == http.MethodGet {
+		number, _ := strconv.Atoi(m[2])
+		issue := s.findIssue(m[1], number)
+		if issue == nil || issue.Pull == nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+
+		switch m[3] {
+		case "/merge":
+			if issue.Pull.Merged {
+				w.WriteHeader(http.StatusNoContent)
+			} else {
+				writeError(w, http.StatusNotFound, "Not Found")
+			}
+		case "/reviews":
+			reviews := make([]interface{}, 0)
+			for i, review := range issue.Pull.Reviews {
+				reviews = append(reviews, map[string]interface{}{
+					"id":    i + 1,
+					"user":  map[string]interface{}{"login": review.User},
+					"state": review.State,
+				})
+			}
+			writeJSON(w, http.StatusOK, reviews)
+		default:
+			writeJSON(w, http.StatusOK, s.pullJSON(issue))
+		}
+		return
+	}
+
+	if m := pullsPath.FindStringSubmatch(r.URL.Path); m != nil && r.Method == http.MethodGet {
+		s.handlePulls(w, r, m[1])
+		return
+	}
+
+	if m := releasesPath.FindStringSubmatch(r.URL.Path); m != nil && r.Method == http.MethodGet {
+		releases := make([]interface{}, 0)
+		for i, release := range s.releases {
+			if strings.EqualFold(release.Repo, m[1]) {
+				releases = append(releases, map[string]interface{}{
+					"id":           i + 1,
+					"tag_name":     release.Tag,
+					"draft":        release.Draft,
+					"published_at": release.PublishedAt.Format(time.RFC3339),
+				})
+			}
+		}
+		s.writePage(w, r, releases)
+		return
+	}
+
+	if m := comparePath.FindStringSubmatch(r.URL.Path); m != nil && r.Method == http.MethodGet {
+		s.handleCompare(w, r, m[1], m[2], m[3])
+		return
+	}
+
+	if m := commitsPath.FindStringSubmatch(r.URL.Path); m != nil && r.Method == http.MethodGet {
+		statuses := make([]interface{}, 0)
+		checkRuns := make([]interface{}, 0)
+		combined := "success"
+		for _, status := range s.statuses[m[2]] {
+			if status.CheckRun {
+				run := map[string]interface{}{"name": status.Context, "status": "completed"}
+				switch status.State {
+				case "pending":
+					run["status"] = "in_progress"
+				default:
+					run["conclusion"] = status.State
+				}
+				checkRuns = append(checkRuns, run)
+				continue
+			}
+
+			statuses = append(statuses, map[string]interface{}{"context": status.Context, "state": status.State})
+			if status.State == "failure" || (status.State == "pending" && combined == "success") {
+				combined = status.State
+			}
+		}
+		if len(statuses) == 0 {
+			combined = "pending"
+		}
+
+		if m[3] == "status" {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"state":       combined,
+				"sha":         m[2],
+				"total_count": len(statuses),
+				"statuses":    statuses,
+			})
+		} else {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"total_count": len(checkRuns),
+				"check_runs":  checkRuns,
+			})
+		}
+		return
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s is not supported by ghtest", r.Method, r.URL.Path))
+}
+
+// handlePulls lists the pull requests of a repo in a state, most recently updated first
+func (s *Server) handlePulls(w http.ResponseWriter, r *http.Request, repo string) {
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		state = "open"
+	}
+
+	pulls := make([]*Issue, 0)
+	for _, issue := range s.issues {
+		if issue.Pull != nil && strings.EqualFold(issue.Repo, repo) && (state == "all" || issue.State == state) {
+			pulls = append(pulls, issue)
+		}
+	}
+	sort.SliceStable(pulls, func(i, k int) bool { return updatedAt(pulls[i]).After(updatedAt(pulls[k])) })
+
+	list := make([]interface{}, 0)
+	for _, pull := range pulls {
+		list = append(list, s.pullJSON(pull))
+	}
+	s.writePage(w, r, list)
+}
+
+// handleCompare lists the commits reachable from head but not base, oldest first, like git log base..head
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request, repo string, base string, head string) {
+	baseSHA, ok := s.resolve(repo, base)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No commit found for %s", base))
+		return
+	}
+	headSHA, ok := s.resolve(repo, head)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No commit found for %s", head))
+		return
+	}
+
+	fromBase := s.ancestors(baseSHA)
+	fromHead := s.ancestors(headSHA)
+	ahead := make([]Commit, 0)
+	for sha := range fromHead {
+		if !fromBase[sha] {
+			ahead = append(ahead, s.commits[sha])
+		}
+	}
+	sort.Slice(ahead, func(i, k int) bool { return ahead[i].Date.Before(ahead[k].Date) })
+
+	behind := 0
+	var mergeBase *Commit
+	for sha := range fromBase {
+		if !fromHead[sha] {
+			behind++
+		} else if commit := s.commits[sha]; mergeBase == nil || commit.Date.After(mergeBase.Date) {
+			mergeBase = &commit
+		}
+	}
+
+	status := "diverged"
+	switch {
+	case len(ahead) == 0 && behind == 0:
+		status = "identical"
+	case behind == 0:
+		status = "ahead"
+	case len(ahead) == 0:
+		status = "behind"
+	}
+
+	commits := make([]interface{}, 0)
+	for _, commit := range ahead {
+		commits = append(commits, commitJSON(commit))
+	}
+	comparison := map[string]interface{}{
+		"status":        status,
+		"ahead_by":      len(ahead),
+		"behind_by":     behind,
+		"total_commits": len(ahead),
+		"base_commit":   commitJSON(s.commits[baseSHA]),
+		"commits":       commits,
+	}
+	if mergeBase != nil {
+		comparison["merge_base_commit"] = commitJSON(*mergeBase)
+	}
+	writeJSON(w, http.StatusOK, comparison)
+}
+
+// resolve finds the commit a release tag, branch or sha of a repo points at
+func (s *Server) resolve(repo string, ref string) (string, bool) {
+	for _, release := range s.releases {
+		if strings.EqualFold(release.Repo, repo) && release.Tag == ref {
+			return release.Commit, true
+		}
+	}
+	if sha, ok := s.branches[ref]; ok {
+		return sha, true
+	}
+	_, ok := s.commits[ref]
+	return ref, ok
+}
+
+func (s *Server) ancestors(sha string) map[string]bool {
+	seen := make(map[string]bool)
+	pending := []string{sha}
+	for len(pending) > 0 {
+		sha, pending = pending[0], pending[1:]
+		if seen[sha] {
+			continue
+		}
+		seen[sha] = true
+		pending = append(pending, s.commits[sha].Parents...)
+	}
+	return seen
+}
+
+func commitJSON(commit Commit) map[string]interface{} {
+	parents := make([]interface{}, 0)
+	for _, parent := range commit.Parents {
+		parents = append(parents, map[string]interface{}{"sha": parent})
+	}
+	return map[string]interface{}{
+		"sha":     commit.SHA,
+		"parents": parents,
+		"commit": map[string]interface{}{
+			"committer": map[string]interface{}{"date": commit.Date.Format(time.RFC3339)},
+		},
+	}
+}
+
+// writePage writes the page of items asked for with the page query parameter, linking to the next page like github
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	start := (page - 1) * s.pageSize
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + s.pageSize
+	if end >= len(items) {
+		end = len(items)
+	} else {
+		next := *r.URL
+		query := next.Query()
+		query.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="next"`, s.URL, next.String()))
+	}
+	writeJSON(w, http.StatusOK, items[start:end])
+}
+
+func updatedAt(issue *Issue) time.Time {
+	if issue.ClosedAt != nil {
+		return *issue.ClosedAt
+	}
+	if issue.Pull != nil && issue.Pull.MergedAt != nil {
+		return *issue.Pull.MergedAt
+	}
+	return time.Time{}
+}
+
+func (s *Server) handleIssue(w http.ResponseWriter, r *http.Request, issue *Issue, sub string, label string) {
+	switch {
+	case sub == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.issueJSON(issue))
+	case sub == "" && r.Method == http.MethodPatch:
+		payload := struct {
+			State       *string `json:"state"`
+			StateReason *string `json:"state_reason"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if payload.State != nil {
+			issue.State = *payload.State
+			if issue.State == "closed" {
+				now := time.Now()
+				issue.ClosedAt = &now
+			} else {
+				issue.ClosedAt = nil
+			}
+		}
+		if payload.StateReason != nil {
+			issue.StateReason = *payload.StateReason
+		}
+		writeJSON(w, http.StatusOK, s.issueJSON(issue))
+	case sub == "/comments" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, commentsJSON(issue))
+	case sub == "/comments" && r.Method == http.MethodPost:
+		payload := struct {
+			Body string `json:"body"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		issue.Comments = append(issue.Comments, payload.Body)
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"id": len(issue.Comments), "body": payload.Body})
+	case sub == "/labels" && r.Method == http.MethodPost:
+		labels := make([]string, 0)
+		if err := json.NewDecoder(r.Body).Decode(&labels); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		for _, label := range labels {
+			if !containsLabel(issue.Labels, label) {
+				issue.Labels = append(issue.Labels, label)
+			}
+		}
+		writeJSON(w, http.StatusOK, labelsJSON(issue.Labels))
+	case label != "" && r.Method == http.MethodDelete:
+		if !containsLabel(issue.Labels, label) {
+			writeError(w, http.StatusNotFound, "Label does not exist")
+			return
+		}
+		labels := make([]string, 0)
+		for _, l := range issue.Labels {
+			if !strings.EqualFold(l, label) {
+				labels = append(labels, l)
+			}
+		}
+		issue.Labels = labels
+		writeJSON(w, http.StatusOK, labelsJSON(issue.Labels))
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s is not supported by ghtest", r.Method, r.URL.Path))
+	}
+}
+
+func containsLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if strings.EqualFold(l, label) {
+			return true
+		}
+	}
+	return false
+}
+
+func labelsJSON(labels []string) []interface{} {
+	l := make([]interface{}, 0)
+	for _, label := range labels {
+		l = append(l, map[string]interface{}{"name": label})
+	}
+	return l
+}
+
+func commentsJSON(issue *Issue) []interface{} {
+	comments := make([]interface{}, 0)
+	for i, body := range issue.Comments {
+		comments = append(comments, map[string]interface{}{"id": i + 1, "body": body})
+	}
+	return comments
+}
+
+func (s *Server) htmlURL(issue *Issue) string {
+	kind := "issues"
+	if issue.Pull != nil {
+		kind = "pull"
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/%d", issue.Repo, kind, issue.Number)
+}
+
+func (s *Server) issueJSON(issue *Issue) map[string]interface{} {
+	i := map[string]interface{}{
+		"number":   issue.Number,
+		"title":    issue.Title,
+		"body":     issue.Body,
+		"state":    issue.State,
+		"html_url": s.htmlURL(issue),
+		"labels":   labelsJSON(issue.Labels),
+	}
+	if issue.StateReason != "" {
+		i["state_reason"] = issue.StateReason
+	}
+	if issue.ClosedAt != nil {
+		i["closed_at"] = issue.ClosedAt.Format(time.RFC3339)
+	}
+	if updated := updatedAt(issue); !updated.IsZero() {
+		i["updated_at"] = updated.Format(time.RFC3339)
+	}
+	if issue.Pull != nil {
+		i["pull_request"] = map[string]interface{}{
+			"url":      fmt.Sprintf("%s/repos/%s/pulls/%d", s.URL, issue.Repo, issue.Number),
+			"html_url": s.htmlURL(issue),
+		}
+	}
+	return i
+}
+
+func (s *Server) pullJSON(issue *Issue) map[string]interface{} {
+	pr := s.issueJSON(issue)
+	delete(pr, "pull_request")
+	delete(pr, "state_reason")
+
+	requested := make([]interface{}, 0)
+	for _, login := range issue.Pull.RequestedReviewers {
+		requested = append(requested, map[string]interface{}{"login": login})
+	}
+	pr["requested_reviewers"] = requested
+	pr["merged"] = issue.Pull.Merged
+	pr["draft"] = issue.Pull.Draft
+	pr["base"] = map[string]interface{}{"ref": issue.Pull.Base}
+	pr["head"] = map[string]interface{}{"ref": issue.Pull.Head, "sha": issue.Pull.HeadSHA}
+	if issue.Pull.MergeCommitSHA != "" {
+		pr["merge_commit_sha"] = issue.Pull.MergeCommitSHA
+	}
+	if issue.Pull.MergedAt != nil {
+		pr["merged_at"] = issue.Pull.MergedAt.Format(time.RFC3339)
+	}
+	return pr
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]interface{}{"message": msg})
+}
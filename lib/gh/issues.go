@@ -16,3 +16,84 @@ func (r Repo) GetIssue(issueNumber int) (*github.Issue, error) {
 	}
 	return issue, nil
 }
+
+const (
+	CloseReasonCompleted  = "completed"
+	CloseReasonNotPlanned = "not_planned"
+)
+
+// CloseReason returns why a closed issue was closed. Issues closed before github recorded a reason have none,
+// these are treated as completed as that was the only way to close an issue at the time
+func CloseReason(issue *github.Issue) string {
+	if issue.GetState() != "closed" {
+		return ""
+	}
+	if reason := issue.GetStateReason(); reason != "" {
+		return reason
+	}
+	return CloseReasonCompleted
+}
+
+// CloseIssue closes an issue with a reason, one of 'completed' or 'not_planned'
+func (r Repo) CloseIssue(issueNumber int, reason string) error {
+	client := r.NewClient()
+
+	req := &github.IssueRequest{
+		State:       github.String("closed"),
+		StateReason: github.String(reason),
+	}
+	_, _, err := client.Issues.Edit(context.Background(), r.Owner, r.Name, issueNumber, req)
+	if err != nil {
+		return fmt.Errorf("closing issue %d in repo %s/%s: %v", issueNumber, r.Owner, r.Name, err)
+	}
+	return nil
+}
+
+func (r Repo) AddLabels(issueNumber int, labels []string) error {
+	client := r.NewClient()
+
+	_, _, err := client.Issues.AddLabelsToIssue(context.Background(), r.Owner, r.Name, issueNumber, labels)
+	if err != nil {
+		return fmt.Errorf("adding labels %v to issue %d in repo %s/%s: %v", labels, issueNumber, r.Owner, r.Name, err)
+	}
+	return nil
+}
+
+func (r Repo) RemoveLabel(issueNumber int, label string) error {
+	client := r.NewClient()
+
+	_, err := client.Issues.RemoveLabelForIssue(context.Background(), r.Owner, r.Name, issueNumber, label)
+	if err != nil {
+		return fmt.Errorf("removing label %s from issue %d in repo %s/%s: %v", label, issueNumber, r.Owner, r.Name, err)
+	}
+	return nil
+}
+
+func (r Repo) ListComments(issueNumber int) ([]*github.IssueComment, error) {
+	client := r.NewClient()
+
+	comments := make([]*github.IssueComment, 0)
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := client.Issues.ListComments(context.Background(), r.Owner, r.Name, issueNumber, opts)
+		if err != nil {
+			return nil, fmt.Errorf("listing comments on issue %d in repo %s/%s: %v", issueNumber, r.Owner, r.Name, err)
+		}
+		comments = append(comments, page...)
+
+		if resp.NextPage == 0 {
+			return comments, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func (r Repo) CreateComment(issueNumber int, body string) error {
+	client := r.NewClient()
+
+	_, _, err := client.Issues.CreateComment(context.Background(), r.Owner, r.Name, issueNumber, &github.IssueComment{Body: github.String(body)})
+	if err != nil {
+		return fmt.Errorf("commenting on issue %d in repo %s/%s: %v", issueNumber, r.Owner, r.Name, err)
+	}
+	return nil
+}
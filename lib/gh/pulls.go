@@ -24,3 +24,110 @@ func (r Repo) GetPullRequest(prNumber int) (*github.PullRequest, error) {
 	}
 	return pr, nil
 }
+
+// The review decisions use github's own names, REVIEW_REQUIRED and the review states, and the check states are those of
+// a combined commit status
+const (
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+	ReviewRequired         = "REVIEW_REQUIRED"
+	// ReviewCommented is reported when the only reviews left comments
+	ReviewCommented = "COMMENTED"
+
+	ChecksSuccess = "success"
+	ChecksPending = "pending"
+	ChecksFailure = "failure"
+)
+
+// GetReviewDecision works out the overall review state of a pull request from the latest review of each reviewer
+func (r Repo) GetReviewDecision(prNumber int) (string, error) {
+	client := r.NewClient()
+
+	reviewed := false
+	latest := make(map[string]string)
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		reviews, resp, err := client.PullRequests.ListReviews(context.Background(), r.Owner, r.Name, prNumber, opts)
+		if err != nil {
+			return "", fmt.Errorf("error listing reviews for pull request #%d: %v", prNumber, err)
+		}
+
+		// reviews are listed oldest first, and comments don't change a reviewer's decision
+		for _, review := range reviews {
+			reviewed = true
+			state := review.GetState()
+			if state == ReviewApproved || state == ReviewChangesRequested || state == "DISMISSED" {
+				latest[review.GetUser().GetLogin()] = state
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	approved := false
+	for _, state := range latest {
+		if state == ReviewChangesRequested {
+			return ReviewChangesRequested, nil
+		}
+		if state == ReviewApproved {
+			approved = true
+		}
+	}
+
+	if approved {
+		return ReviewApproved, nil
+	}
+	if reviewed {
+		return ReviewCommented, nil
+	}
+	return ReviewRequired, nil
+}
+
+// GetChecksState combines the commit statuses and check runs for a ref into a single success, pending or failure state
+func (r Repo) GetChecksState(ref string) (string, error) {
+	client := r.NewClient()
+
+	combined, _, err := client.Repositories.GetCombinedStatus(context.Background(), r.Owner, r.Name, ref, nil)
+	if err != nil {
+		return "", fmt.Errorf("error getting combined status for %s: %v", ref, err)
+	}
+
+	state := ChecksSuccess
+	// a combined state is reported as pending when there are no statuses at all
+	if combined.GetTotalCount() > 0 {
+		state = combined.GetState()
+	}
+	if state == ChecksFailure {
+		return state, nil
+	}
+
+	opts := &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		results, resp, err := client.Checks.ListCheckRunsForRef(context.Background(), r.Owner, r.Name, ref, opts)
+		if err != nil {
+			return "", fmt.Errorf("error listing check runs for %s: %v", ref, err)
+		}
+
+		for _, run := range results.CheckRuns {
+			if run.GetStatus() != "completed" {
+				state = ChecksPending
+				continue
+			}
+			switch run.GetConclusion() {
+			case "success", "neutral", "skipped":
+			default:
+				return ChecksFailure, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return state, nil
+}
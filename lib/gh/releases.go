@@ -0,0 +1,176 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v52/github"
+)
+
+// GetPreviousReleaseTag returns the tag of the release published before the release with the given tag,
+// or an empty string if it is the first release of the repo
+func (r Repo) GetPreviousReleaseTag(tag string) (string, error) {
+	client := r.NewClient()
+
+	opts := &github.ListOptions{PerPage: 100}
+	found := false
+	for {
+		releases, resp, err := client.Repositories.ListReleases(context.Background(), r.Owner, r.Name, opts)
+		if err != nil {
+			return "", fmt.Errorf("listing releases in repo %s/%s: %v", r.Owner, r.Name, err)
+		}
+
+		// releases are listed newest first, so the previous release is the one following the input tag
+		for _, release := range releases {
+			if found && !release.GetDraft() {
+				return release.GetTagName(), nil
+			}
+			if release.GetTagName() == tag {
+				found = true
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	if !found {
+		return "", fmt.Errorf("no release with tag %s found in repo %s/%s", tag, r.Owner, r.Name)
+	}
+
+	return "", nil
+}
+
+// ListPullRequestsBetween returns the merged pull requests whose merge commit is one of the commits between base and
+// head. Rather than looking up the pull requests of every commit, closed pull requests are listed most recently
+// updated first until reaching those last updated before the commit base and head have in common
+func (r Repo) ListPullRequestsBetween(base string, head string) ([]*github.PullRequest, error) {
+	client := r.NewClient()
+
+	commits := make(map[string]bool)
+	var since time.Time
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		comparison, resp, err := client.Repositories.CompareCommits(context.Background(), r.Owner, r.Name, base, head, opts)
+		if err != nil {
+			return nil, fmt.Errorf("comparing %s...%s in repo %s/%s: %v", base, head, r.Owner, r.Name, err)
+		}
+		for _, commit := range comparison.Commits {
+			commits[commit.GetSHA()] = true
+		}
+		since = comparison.GetMergeBaseCommit().GetCommit().GetCommitter().GetDate().Time
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	pulls := make([]*github.PullRequest, 0)
+	if len(commits) == 0 {
+		return pulls, nil
+	}
+
+	listOpts := &github.PullRequestListOptions{
+		State:       "closed",
+		Sort:        "updated",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		prs, resp, err := client.PullRequests.List(context.Background(), r.Owner, r.Name, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("listing closed pull requests in repo %s/%s: %v", r.Owner, r.Name, err)
+		}
+
+		done := false
+		for _, pr := range prs {
+			// a pull request is updated when it is merged, so anything older was merged before base
+			if pr.GetUpdatedAt().Before(since) {
+				done = true
+				break
+			}
+			if pr.MergedAt != nil && commits[pr.GetMergeCommitSHA()] {
+				pulls = append(pulls, pr)
+			}
+		}
+
+		if done || resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	return pulls, nil
+}
+
+// FindReleaseContainingCommit returns the tag of the earliest published release that includes the given commit and
+// whose tag is reachable from branch, or an empty string if the commit has not been released from the branch yet.
+// Releases from other branches eg release/* are skipped rather than assuming a linear history, and only releases
+// published after mergedAt are compared as earlier ones can't include the commit
+func (r Repo) FindReleaseContainingCommit(sha string, branch string, mergedAt *time.Time) (string, error) {
+	client := r.NewClient()
+
+	candidates := make([]*github.RepositoryRelease, 0)
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		releases, resp, err := client.Repositories.ListReleases(context.Background(), r.Owner, r.Name, opts)
+		if err != nil {
+			return "", fmt.Errorf("listing releases in repo %s/%s: %v", r.Owner, r.Name, err)
+		}
+
+		for _, release := range releases {
+			if release.GetDraft() || (mergedAt != nil && release.GetPublishedAt().Before(*mergedAt)) {
+				continue
+			}
+			candidates = append(candidates, release)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	sort.SliceStable(candidates, func(i, k int) bool {
+		return candidates[i].GetPublishedAt().Before(candidates[k].GetPublishedAt().Time)
+	})
+	for _, release := range candidates {
+		tag := release.GetTagName()
+		contains, err := r.isAncestor(client, sha, tag)
+		if err != nil {
+			return "", err
+		}
+		if !contains {
+			continue
+		}
+
+		if branch != "" {
+			onBranch, err := r.isAncestor(client, tag, branch)
+			if err != nil {
+				return "", err
+			}
+			if !onBranch {
+				continue
+			}
+		}
+		return tag, nil
+	}
+
+	return "", nil
+}
+
+// isAncestor reports whether ref is reachable from head, ie head is ahead of or the same as ref
+func (r Repo) isAncestor(client *github.Client, ref string, head string) (bool, error) {
+	comparison, _, err := client.Repositories.CompareCommits(context.Background(), r.Owner, r.Name, ref, head, &github.ListOptions{PerPage: 1})
+	if err != nil {
+		return false, fmt.Errorf("comparing %s...%s in repo %s/%s: %v", ref, head, r.Owner, r.Name, err)
+	}
+
+	status := comparison.GetStatus()
+	return status == "ahead" || status == "identical", nil
+}
@@ -0,0 +1,157 @@
+package gh
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/gh/ghtest"
+)
+
+func day(d float64) *time.Time {
+	t := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(d * float64(24*time.Hour)))
+	return &t
+}
+
+// releaseServer has a main branch a-b-c-d-e released as v1.0.0 at b and v1.1.0 at e, with a pull request merged
+// as each commit and a few closed without being merged
+func releaseServer() *ghtest.Server {
+	commits := make([]ghtest.Commit, 0)
+	parent := []string{}
+	for i, sha := range []string{"a", "b", "c", "d", "e"} {
+		commits = append(commits, ghtest.Commit{SHA: sha, Parents: parent, Date: *day(float64(i + 1))})
+		parent = []string{sha}
+	}
+
+	merged := func(number int, title string, sha string, at float64) ghtest.Issue {
+		return ghtest.Issue{Repo: "acme/app", Number: number, Title: title, State: "closed", ClosedAt: day(at),
+			Pull: &ghtest.Pull{Merged: true, MergedAt: day(at), Base: "main", MergeCommitSHA: sha}}
+	}
+
+	return ghtest.NewServer(ghtest.Seed{
+		Commits: commits,
+		Issues: []ghtest.Issue{
+			merged(1, "IPL-1 first", "a", 1),
+			merged(2, "IPL-2 second", "b", 2),
+			merged(3, "IPL-3 third", "c", 3),
+			merged(4, "IPL-4 fourth", "d", 4),
+			merged(5, "IPL-5 fifth", "e", 5),
+			{Repo: "acme/app", Number: 6, Title: "IPL-6 abandoned", State: "closed", ClosedAt: day(4.5), Pull: &ghtest.Pull{Base: "main"}},
+			{Repo: "acme/app", Number: 7, Title: "IPL-7 open", State: "open", Pull: &ghtest.Pull{Base: "main"}},
+			{Repo: "acme/app", Number: 8, Title: "IPL-8 abandoned long ago", State: "closed", ClosedAt: day(0.5), Pull: &ghtest.Pull{Base: "main"}},
+		},
+		Releases: []ghtest.Release{
+			{Repo: "acme/app", Tag: "v1.2.0-rc", Commit: "e", Draft: true, PublishedAt: *day(6)},
+			{Repo: "acme/app", Tag: "v1.1.0", Commit: "e", PublishedAt: *day(5.5)},
+			{Repo: "acme/app", Tag: "v1.0.0", Commit: "b", PublishedAt: *day(2.5)},
+		},
+		Branches: map[string]string{"main": "e"},
+		PageSize: 2,
+	})
+}
+
+func TestGetPreviousReleaseTag(t *testing.T) {
+	s := releaseServer()
+	defer s.Close()
+
+	repo := NewRepo("acme/app", "")
+	repo.BaseURL = s.URL
+	cases := map[string]string{"v1.2.0-rc": "v1.1.0", "v1.1.0": "v1.0.0", "v1.0.0": ""}
+	for tag, expected := range cases {
+		previous, err := repo.GetPreviousReleaseTag(tag)
+		if err != nil {
+			t.Fatalf("getting the release before %s: %v", tag, err)
+		}
+		if previous != expected {
+			t.Errorf("expected the release before %s to be %q, got %q", tag, expected, previous)
+		}
+	}
+
+	if _, err := repo.GetPreviousReleaseTag("v0.1.0"); err == nil {
+		t.Errorf("expected an error for a release that doesn't exist")
+	}
+}
+
+func TestListPullRequestsBetween(t *testing.T) {
+	s := releaseServer()
+	defer s.Close()
+
+	repo := NewRepo("acme/app", "")
+	repo.BaseURL = s.URL
+	pulls, err := repo.ListPullRequestsBetween("v1.0.0", "v1.1.0")
+	if err != nil {
+		t.Fatalf("listing pull requests: %v", err)
+	}
+
+	titles := make([]string, 0)
+	for _, pr := range pulls {
+		titles = append(titles, pr.GetTitle())
+	}
+	sort.Strings(titles)
+	if strings.Join(titles, ",") != "IPL-3 third,IPL-4 fourth,IPL-5 fifth" {
+		t.Errorf("expected the pull requests merged after v1.0.0, got %v", titles)
+	}
+
+	lists := 0
+	for _, request := range s.Requests() {
+		if strings.Contains(request, "/commits/") {
+			t.Errorf("expected no requests per commit, got %s", request)
+		}
+		if request == "GET /repos/acme/app/pulls" {
+			lists++
+		}
+	}
+	// the fourth page only has pull requests from before v1.0.0
+	if lists != 3 {
+		t.Errorf("expected listing to stop at pull requests updated before v1.0.0 after 3 pages, got %d", lists)
+	}
+}
+
+func TestFindReleaseContainingCommit(t *testing.T) {
+	// main is a-b-c-d-e-f and release/1.0 branches from b with a fix b1, its patch release is the latest release
+	commits := []ghtest.Commit{{SHA: "b1", Parents: []string{"b"}, Date: *day(3.5)}}
+	parent := []string{}
+	for i, sha := range []string{"a", "b", "c", "d", "e", "f"} {
+		commits = append(commits, ghtest.Commit{SHA: sha, Parents: parent, Date: *day(float64(i + 1))})
+		parent = []string{sha}
+	}
+
+	s := ghtest.NewServer(ghtest.Seed{
+		Commits: commits,
+		Releases: []ghtest.Release{
+			{Repo: "acme/app", Tag: "v1.2.0-rc", Commit: "f", Draft: true, PublishedAt: *day(6)},
+			{Repo: "acme/app", Tag: "v1.0.1", Commit: "b1", PublishedAt: *day(5.7)},
+			{Repo: "acme/app", Tag: "v1.1.0", Commit: "e", PublishedAt: *day(5.5)},
+			{Repo: "acme/app", Tag: "v1.0.0", Commit: "b", PublishedAt: *day(2.5)},
+		},
+		Branches: map[string]string{"main": "f", "release/1.0": "b1"},
+	})
+	defer s.Close()
+
+	repo := NewRepo("acme/app", "")
+	repo.BaseURL = s.URL
+	cases := []struct {
+		sha      string
+		branch   string
+		mergedAt *time.Time
+		expected string
+	}{
+		{"c", "main", day(3), "v1.1.0"},
+		{"b", "main", day(2), "v1.0.0"},
+		{"e", "main", day(5), "v1.1.0"},
+		{"f", "main", day(6), ""},
+		{"b1", "release/1.0", day(3.5), "v1.0.1"},
+		// the fix is only in a release made from another branch
+		{"b1", "main", day(3.5), ""},
+	}
+	for _, tc := range cases {
+		tag, err := repo.FindReleaseContainingCommit(tc.sha, tc.branch, tc.mergedAt)
+		if err != nil {
+			t.Fatalf("finding the release containing %s: %v", tc.sha, err)
+		}
+		if tag != tc.expected {
+			t.Errorf("expected %s merged into %s to be released in %q, got %q", tc.sha, tc.branch, tc.expected, tag)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jirallreadyforthis/lib/replay"
+)
+
+// Project is a gitlab project, on gitlab.com or a self-hosted instance
+type Project struct {
+	// BaseURL is the url of the gitlab instance eg 'https://gitlab.com'
+	BaseURL string
+	// Path is the full path of the project eg 'group/subgroup/name'
+	Path  string
+	Token string
+}
+
+func NewProject(baseURL, path, token string) Project {
+	return Project{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Path:    path,
+		Token:   token,
+	}
+}
+
+func (p Project) NewClient() *http.Client {
+	return &http.Client{
+		// records or replays requests when enabled, otherwise this is the default transport
+		Transport: replay.Transport(nil),
+	}
+}
+
+// get requests an endpoint of the project from the v4 api and decodes the response into v, returning the next
+// page number for paginated endpoints or 0 if there are no more pages
+func (p Project) get(endpoint string, query url.Values, v interface{}) (int, error) {
+	u := fmt.Sprintf("%s/api/v4/projects/%s%s", p.BaseURL, url.PathEscape(p.Path), endpoint)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return 0, fmt.Errorf("creating gitlab request: %v", err)
+	}
+	if p.Token != "" {
+		req.Header.Set("PRIVATE-TOKEN", p.Token)
+	}
+
+	resp, err := p.NewClient().Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("requesting %s: %v", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return 0, fmt.Errorf("requesting %s: %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return 0, fmt.Errorf("decoding response from %s: %v", u, err)
+	}
+
+	next := 0
+	fmt.Sscanf(resp.Header.Get("X-Next-Page"), "%d", &next)
+	return next, nil
+}
@@ -0,0 +1,159 @@
+// Package gitlabtest provides an in-memory fake of the parts of the gitlab api used by gitlab.Project, for testing
+// code that works with gitlab without a real gitlab instance.
+package gitlabtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type MergeRequest struct {
+	// Project is the full path of the project the merge request is in eg 'group/name'
+	Project string
+	IID     int
+	Title   string
+	// State is 'opened', 'closed', 'merged' or 'locked'
+	State          string
+	Draft          bool
+	TargetBranch   string
+	SourceBranch   string
+	SHA            string
+	MergeCommitSHA string
+	MergedAt       *time.Time
+	ClosedAt       *time.Time
+	Approved       bool
+	Reviewers      []string
+	// PipelineStatus is the status of the head pipeline eg 'success' or 'failed', there is no pipeline when empty
+	PipelineStatus string
+}
+
+type Issue struct {
+	Project string
+	IID     int
+	Title   string
+	// State is 'opened' or 'closed'
+	State    string
+	ClosedAt *time.Time
+}
+
+type Seed struct {
+	MergeRequests []MergeRequest
+	Issues        []Issue
+}
+
+// Server is a fake gitlab instance, links to it are made with MergeRequestURL and IssueURL
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	mergeRequests []MergeRequest
+	issues        []Issue
+	requests      []string
+}
+
+var itemPath = regexp.MustCompile(`^/api/v4/projects/([^/]+)/(merge_requests|issues)/(\d+)(/approvals)?$`)
+
+func NewServer(seed Seed) *Server {
+	s := &Server{
+		mergeRequests: seed.MergeRequests,
+		issues:        seed.Issues,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *Server) MergeRequestURL(project string, iid int) string {
+	return fmt.Sprintf("%s/%s/-/merge_requests/%d", s.URL, project, iid)
+}
+
+func (s *Server) IssueURL(project string, iid int) string {
+	return fmt.Sprintf("%s/%s/-/issues/%d", s.URL, project, iid)
+}
+
+// Requests returns the method and path of every request the server has received
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.requests...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r.Method+" "+r.URL.EscapedPath())
+
+	m := itemPath.FindStringSubmatch(r.URL.EscapedPath())
+	if m == nil || r.Method != http.MethodGet {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"message": fmt.Sprintf("%s %s is not supported by gitlabtest", r.Method, r.URL.Path)})
+		return
+	}
+	project, _ := url.PathUnescape(m[1])
+	iid, _ := strconv.Atoi(m[3])
+
+	if m[2] == "issues" {
+		for _, issue := range s.issues {
+			if issue.Project == project && issue.IID == iid {
+				writeJSON(w, http.StatusOK, map[string]interface{}{
+					"iid":       issue.IID,
+					"title":     issue.Title,
+					"state":     issue.State,
+					"web_url":   s.IssueURL(project, iid),
+					"closed_at": issue.ClosedAt,
+				})
+				return
+			}
+		}
+	} else {
+		for _, mr := range s.mergeRequests {
+			if mr.Project != project || mr.IID != iid {
+				continue
+			}
+			if m[4] == "/approvals" {
+				writeJSON(w, http.StatusOK, map[string]interface{}{"approved": mr.Approved})
+				return
+			}
+
+			resp := map[string]interface{}{
+				"iid":              mr.IID,
+				"title":            mr.Title,
+				"state":            mr.State,
+				"web_url":          s.MergeRequestURL(project, iid),
+				"draft":            mr.Draft,
+				"target_branch":    mr.TargetBranch,
+				"source_branch":    mr.SourceBranch,
+				"sha":              mr.SHA,
+				"merge_commit_sha": mr.MergeCommitSHA,
+				"merged_at":        mr.MergedAt,
+				"closed_at":        mr.ClosedAt,
+				"head_pipeline":    nil,
+			}
+			reviewers := make([]interface{}, 0)
+			for _, username := range mr.Reviewers {
+				reviewers = append(reviewers, map[string]interface{}{"username": username, "name": username})
+			}
+			resp["reviewers"] = reviewers
+			if mr.PipelineStatus != "" {
+				resp["head_pipeline"] = map[string]interface{}{"id": 1, "status": mr.PipelineStatus}
+			}
+			writeJSON(w, http.StatusOK, resp)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusNotFound, map[string]interface{}{"message": "404 Not found"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
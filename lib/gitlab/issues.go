@@ -0,0 +1,22 @@
+package gitlab
+
+import (
+	"fmt"
+	"time"
+)
+
+type Issue struct {
+	IID      int        `json:"iid"`
+	Title    string     `json:"title"`
+	State    string     `json:"state"`
+	WebURL   string     `json:"web_url"`
+	ClosedAt *time.Time `json:"closed_at"`
+}
+
+func (p Project) GetIssue(iid int) (*Issue, error) {
+	issue := &Issue{}
+	if _, err := p.get(fmt.Sprintf("/issues/%d", iid), nil, issue); err != nil {
+		return nil, fmt.Errorf("getting issue #%d in project %s: %v", iid, p.Path, err)
+	}
+	return issue, nil
+}
@@ -0,0 +1,61 @@
+package gitlab
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	StateOpened = "opened"
+	StateClosed = "closed"
+	StateMerged = "merged"
+	StateLocked = "locked"
+)
+
+type MergeRequest struct {
+	IID            int    `json:"iid"`
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	State          string `json:"state"`
+	WebURL         string `json:"web_url"`
+	Draft          bool   `json:"draft"`
+	TargetBranch   string `json:"target_branch"`
+	SourceBranch   string `json:"source_branch"`
+	SHA            string `json:"sha"`
+	MergeCommitSHA string `json:"merge_commit_sha"`
+	// SquashCommitSHA is set instead of MergeCommitSHA when a merge request is squashed without a merge commit
+	SquashCommitSHA string     `json:"squash_commit_sha"`
+	MergedAt        *time.Time `json:"merged_at"`
+	ClosedAt        *time.Time `json:"closed_at"`
+	HeadPipeline    *Pipeline  `json:"head_pipeline"`
+	Reviewers       []User     `json:"reviewers"`
+}
+
+type User struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+type Pipeline struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+}
+
+func (p Project) GetMergeRequest(iid int) (*MergeRequest, error) {
+	mr := &MergeRequest{}
+	if _, err := p.get(fmt.Sprintf("/merge_requests/%d", iid), nil, mr); err != nil {
+		return nil, fmt.Errorf("getting merge request !%d in project %s: %v", iid, p.Path, err)
+	}
+	return mr, nil
+}
+
+// MergeRequestApproved reports whether a merge request has all the approvals it requires
+func (p Project) MergeRequestApproved(iid int) (bool, error) {
+	approvals := struct {
+		Approved bool `json:"approved"`
+	}{}
+	if _, err := p.get(fmt.Sprintf("/merge_requests/%d/approvals", iid), nil, &approvals); err != nil {
+		return false, fmt.Errorf("getting approvals for merge request !%d in project %s: %v", iid, p.Path, err)
+	}
+	return approvals.Approved, nil
+}
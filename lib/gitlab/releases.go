@@ -0,0 +1,58 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// FindReleaseContainingCommit returns the tag of the earliest release that includes the given commit,
+// or an empty string if the commit has not been released yet
+func (p Project) FindReleaseContainingCommit(sha string) (string, error) {
+	tags := make(map[string]bool)
+	page := 1
+	for page != 0 {
+		refs := make([]struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+		}, 0)
+		query := url.Values{"type": {"tag"}, "per_page": {"100"}, "page": {strconv.Itoa(page)}}
+
+		next, err := p.get(fmt.Sprintf("/repository/commits/%s/refs", url.PathEscape(sha)), query, &refs)
+		if err != nil {
+			return "", fmt.Errorf("listing tags containing %s in project %s: %v", sha, p.Path, err)
+		}
+		for _, ref := range refs {
+			tags[ref.Name] = true
+		}
+		page = next
+	}
+	if len(tags) == 0 {
+		return "", nil
+	}
+
+	tag := ""
+	var releasedAt time.Time
+	page = 1
+	for page != 0 {
+		releases := make([]struct {
+			TagName    string    `json:"tag_name"`
+			ReleasedAt time.Time `json:"released_at"`
+		}, 0)
+		query := url.Values{"per_page": {"100"}, "page": {strconv.Itoa(page)}}
+
+		next, err := p.get("/releases", query, &releases)
+		if err != nil {
+			return "", fmt.Errorf("listing releases in project %s: %v", p.Path, err)
+		}
+		for _, release := range releases {
+			if tags[release.TagName] && (tag == "" || release.ReleasedAt.Before(releasedAt)) {
+				tag, releasedAt = release.TagName, release.ReleasedAt
+			}
+		}
+		page = next
+	}
+
+	return tag, nil
+}
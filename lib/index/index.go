@@ -0,0 +1,118 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Index is a local store of jira issues, the github links found in them and the last known state of those links
+type Index struct {
+	JiraUrl  string    `json:"jira_url"`
+	Jql      string    `json:"jql"`
+	LastSync time.Time `json:"last_sync"`
+	// LastSweep is when the keys of every issue matching the jql were last listed to drop issues that no longer match
+	LastSweep time.Time        `json:"last_sweep"`
+	Issues    map[string]Issue `json:"issues"`
+	Links     map[string]Link  `json:"links"`
+}
+
+type Issue struct {
+	Key            string    `json:"key"`
+	ID             string    `json:"id"`
+	Summary        string    `json:"summary"`
+	Status         string    `json:"status"`
+	StatusCategory string    `json:"status_category"`
+	Created        time.Time `json:"created"`
+	Updated        time.Time `json:"updated"`
+	Links          []string  `json:"links"`
+}
+
+type Link struct {
+	URL           string     `json:"url"`
+	Repo          string     `json:"repo"`
+	Number        int        `json:"number"`
+	Title         string     `json:"title"`
+	IsPullRequest bool       `json:"is_pull_request"`
+	State         string     `json:"state"`
+	StateReason   string     `json:"state_reason,omitempty"`
+	Merged        bool       `json:"merged"`
+	Base          string     `json:"base,omitempty"`
+	ClosedAt      *time.Time `json:"closed_at,omitempty"`
+	MergedAt      *time.Time `json:"merged_at,omitempty"`
+	CheckedAt     time.Time  `json:"checked_at"`
+	// Error is set when the link could not be resolved on the last sync
+	Error string `json:"error,omitempty"`
+}
+
+// Terminal reports whether a link has reached a state it won't normally leave, so doesn't need checking again
+func (l Link) Terminal() bool {
+	return l.Error == "" && l.State == "closed"
+}
+
+func New(jiraUrl string, jql string) *Index {
+	return &Index{
+		JiraUrl: jiraUrl,
+		Jql:     jql,
+		Issues:  make(map[string]Issue),
+		Links:   make(map[string]Link),
+	}
+}
+
+// DefaultPath is where the index is stored when no path is given
+func DefaultPath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("finding user cache dir: %v", err)
+	}
+	return filepath.Join(cacheDir, "jirallreadyforthis", "index.json"), nil
+}
+
+// Load reads the index at path, returning nil without an error if there is no index there yet
+func Load(path string) (*Index, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading index %s: %v", path, err)
+	}
+
+	i := &Index{}
+	if err := json.Unmarshal(b, i); err != nil {
+		return nil, fmt.Errorf("parsing index %s: %v", path, err)
+	}
+	if i.Issues == nil {
+		i.Issues = make(map[string]Issue)
+	}
+	if i.Links == nil {
+		i.Links = make(map[string]Link)
+	}
+
+	return i, nil
+}
+
+// Save writes the index to path, replacing the previous file only once the new one is fully written
+func (i *Index) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating index dir: %v", err)
+	}
+
+	b, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding index: %v", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("writing index %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replacing index %s: %v", path, err)
+	}
+
+	return nil
+}
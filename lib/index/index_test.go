@@ -0,0 +1,80 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "index.json")
+
+	i, err := Load(path)
+	if err != nil || i != nil {
+		t.Fatalf("expected no index before one is saved, got %v %v", i, err)
+	}
+
+	merged := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	i = New("https://jira.example", "project = IPL")
+	i.Issues["IPL-1"] = Issue{Key: "IPL-1", ID: "10001", Status: "Done", Links: []string{"https://github.com/acme/app/pull/1"}}
+	i.Links["https://github.com/acme/app/pull/1"] = Link{URL: "https://github.com/acme/app/pull/1", State: "closed", Merged: true, MergedAt: &merged}
+	if err := i.Save(path); err != nil {
+		t.Fatalf("saving index: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary file to be renamed over the index, got %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("loading index: %v", err)
+	}
+	if loaded.Jql != "project = IPL" || loaded.Issues["IPL-1"].Status != "Done" || len(loaded.Issues["IPL-1"].Links) != 1 {
+		t.Errorf("expected the saved issues to be loaded, got %+v", loaded)
+	}
+	link := loaded.Links["https://github.com/acme/app/pull/1"]
+	if !link.Merged || link.MergedAt == nil || !link.MergedAt.Equal(merged) || !link.Terminal() {
+		t.Errorf("expected the saved link to be loaded, got %+v", link)
+	}
+}
+
+func TestLoadFillsMissingMaps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := os.WriteFile(path, []byte(`{"jql": "project = IPL"}`), 0o600); err != nil {
+		t.Fatalf("writing index: %v", err)
+	}
+
+	i, err := Load(path)
+	if err != nil {
+		t.Fatalf("loading index: %v", err)
+	}
+	if i.Issues == nil || i.Links == nil {
+		t.Errorf("expected the issues and links to be usable after loading, got %+v", i)
+	}
+
+	if err := os.WriteFile(path, []byte(`{`), 0o600); err != nil {
+		t.Fatalf("writing index: %v", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Errorf("expected an error loading a corrupt index")
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	cases := []struct {
+		link     Link
+		expected bool
+	}{
+		{Link{State: "open"}, false},
+		{Link{State: "closed"}, true},
+		{Link{State: "closed", Merged: true}, true},
+		// links that failed to resolve are checked again
+		{Link{State: "closed", Error: "not found"}, false},
+	}
+	for _, tc := range cases {
+		if actual := tc.link.Terminal(); actual != tc.expected {
+			t.Errorf("expected %+v to be terminal %v, got %v", tc.link, tc.expected, actual)
+		}
+	}
+}
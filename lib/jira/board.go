@@ -0,0 +1,159 @@
+package jira
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	j "github.com/andygrunwald/go-jira"
+)
+
+// Sprint is a jira sprint, go-jira's sprint is missing the goal
+type Sprint struct {
+	j.Sprint
+	Goal string `json:"goal"`
+}
+
+type sprintList struct {
+	IsLast bool     `json:"isLast"`
+	Values []Sprint `json:"values"`
+}
+
+// boardTypeScrum is the type of board that has sprints, jira rejects requests for the sprints of kanban boards
+const boardTypeScrum = "scrum"
+
+// ListBoards lists the scrum boards whose name contains name, optionally only those of a project
+func (p Project) ListBoards(name string, projectKey string) ([]j.Board, error) {
+	client, err := p.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	boards := make([]j.Board, 0)
+	opts := &j.BoardListOptions{
+		Name:           name,
+		ProjectKeyOrID: projectKey,
+		BoardType:      boardTypeScrum,
+		SearchOptions:  j.SearchOptions{MaxResults: 50},
+	}
+	for {
+		page, _, err := client.Board.GetAllBoards(opts)
+		if err != nil {
+			return nil, fmt.Errorf("listing boards: %v", err)
+		}
+		boards = append(boards, page.Values...)
+		if page.IsLast || len(page.Values) == 0 {
+			return boards, nil
+		}
+		opts.StartAt += len(page.Values)
+	}
+}
+
+// FindBoard finds a scrum board from its id or name, a name matches if it is the only board containing it or if it
+// is an exact match ignoring case
+func (p Project) FindBoard(board string, projectKey string) (*j.Board, error) {
+	if id, err := strconv.Atoi(board); err == nil {
+		client, err := p.NewClient()
+		if err != nil {
+			return nil, fmt.Errorf("creating jira client: %v: ", err)
+		}
+		b, _, err := client.Board.GetBoard(id)
+		if err != nil {
+			return nil, fmt.Errorf("getting board id %d: %v", id, err)
+		}
+		if b.Type != boardTypeScrum {
+			return nil, fmt.Errorf("board %s (id %d) is a %s board, only scrum boards have sprints", b.Name, b.ID, b.Type)
+		}
+		return b, nil
+	}
+
+	boards, err := p.ListBoards(board, projectKey)
+	if err != nil {
+		return nil, err
+	}
+	for i := range boards {
+		if strings.EqualFold(boards[i].Name, board) {
+			return &boards[i], nil
+		}
+	}
+	switch len(boards) {
+	case 0:
+		return nil, fmt.Errorf("no scrum board found matching %q", board)
+	case 1:
+		return &boards[0], nil
+	}
+
+	names := make([]string, 0)
+	for _, b := range boards {
+		names = append(names, fmt.Sprintf("%q (id %d)", b.Name, b.ID))
+	}
+	return nil, fmt.Errorf("board %q matches more than one board: %s", board, strings.Join(names, ", "))
+}
+
+// ListSprints lists the sprints of a board in the order jira shows them, state is a comma separated list of
+// 'future', 'active' and 'closed' or empty for all sprints
+func (p Project) ListSprints(boardId int, state string) ([]Sprint, error) {
+	client, err := p.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	sprints := make([]Sprint, 0)
+	for {
+		url := fmt.Sprintf("rest/agile/1.0/board/%d/sprint?startAt=%d&maxResults=50", boardId, len(sprints))
+		if state != "" {
+			url += "&state=" + state
+		}
+		req, err := client.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request for sprints of board id %d: %v", boardId, err)
+		}
+
+		page := sprintList{}
+		if _, err := client.Do(req, &page); err != nil {
+			return nil, fmt.Errorf("listing sprints of board id %d: %v", boardId, err)
+		}
+		sprints = append(sprints, page.Values...)
+		if page.IsLast || len(page.Values) == 0 {
+			return sprints, nil
+		}
+	}
+}
+
+// ResolveSprint finds a sprint of a board from its id, its name, or 'active' for the board's active sprint or 'next'
+// for the first of its future sprints
+func (p Project) ResolveSprint(boardId int, sprint string) (*Sprint, error) {
+	state := ""
+	switch strings.ToLower(sprint) {
+	case "active":
+		state = "active"
+	case "next":
+		state = "future"
+	}
+
+	sprints, err := p.ListSprints(boardId, state)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(sprint) {
+	case "active":
+		if len(sprints) != 1 {
+			return nil, fmt.Errorf("board id %d has %d active sprints, use the sprint name or id instead", boardId, len(sprints))
+		}
+		return &sprints[0], nil
+	case "next":
+		if len(sprints) == 0 {
+			return nil, fmt.Errorf("board id %d has no future sprints", boardId)
+		}
+		return &sprints[0], nil
+	}
+
+	id, idErr := strconv.Atoi(sprint)
+	for i := range sprints {
+		if (idErr == nil && sprints[i].ID == id) || strings.EqualFold(sprints[i].Name, sprint) {
+			return &sprints[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no sprint %q found on board id %d", sprint, boardId)
+}
@@ -0,0 +1,113 @@
+package jira
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func boardServer() *jiratest.Server {
+	sprints := []jiratest.Sprint{
+		{ID: 1, BoardID: 1, Name: "Sprint 40", State: "closed"},
+		{ID: 2, BoardID: 1, Name: "Sprint 41", State: "active", Goal: "ship it"},
+		{ID: 3, BoardID: 1, Name: "Sprint 42", State: "future"},
+		{ID: 4, BoardID: 1, Name: "Sprint 43", State: "future"},
+		{ID: 5, BoardID: 2, Name: "Y Sprint 1", State: "active"},
+		{ID: 6, BoardID: 2, Name: "Y Sprint 2", State: "active"},
+	}
+	for i := 7; i <= 60; i++ {
+		sprints = append(sprints, jiratest.Sprint{ID: i, BoardID: 3, Name: fmt.Sprintf("Z Sprint %d", i), State: "closed"})
+	}
+
+	return jiratest.NewServer(jiratest.Seed{
+		Boards: []jiratest.Board{
+			{ID: 1, Name: "Team X", Type: "scrum", ProjectKey: "IPL"},
+			{ID: 2, Name: "Team Y", Type: "scrum", ProjectKey: "IPL"},
+			{ID: 3, Name: "Team Z", Type: "scrum", ProjectKey: "OTHER"},
+			{ID: 4, Name: "Team X kanban", Type: "kanban", ProjectKey: "IPL"},
+		},
+		Sprints: sprints,
+	})
+}
+
+func TestFindBoard(t *testing.T) {
+	s := boardServer()
+	defer s.Close()
+
+	p := Project{JiraUrl: s.URL}
+	cases := []struct {
+		board      string
+		projectKey string
+		expected   int
+		err        bool
+	}{
+		{board: "2", expected: 2},
+		{board: "team x", expected: 1},
+		{board: "kanban", err: true},
+		{board: "4", err: true},
+		{board: "Team", err: true},
+		{board: "Team", projectKey: "OTHER", expected: 3},
+		{board: "nope", err: true},
+	}
+
+	for _, tc := range cases {
+		board, err := p.FindBoard(tc.board, tc.projectKey)
+		if tc.err {
+			if err == nil {
+				t.Errorf("expected an error finding board %q, got board %d", tc.board, board.ID)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("finding board %q: %v", tc.board, err)
+		} else if board.ID != tc.expected {
+			t.Errorf("expected board %q to be %d, got %d", tc.board, tc.expected, board.ID)
+		}
+	}
+}
+
+func TestResolveSprint(t *testing.T) {
+	s := boardServer()
+	defer s.Close()
+
+	p := Project{JiraUrl: s.URL}
+	cases := []struct {
+		board    int
+		sprint   string
+		expected int
+		err      bool
+	}{
+		{board: 1, sprint: "active", expected: 2},
+		{board: 1, sprint: "next", expected: 3},
+		{board: 1, sprint: "sprint 43", expected: 4},
+		{board: 1, sprint: "1", expected: 1},
+		{board: 1, sprint: "5", err: true},
+		{board: 2, sprint: "active", err: true},
+		{board: 2, sprint: "next", err: true},
+		{board: 3, sprint: "Z Sprint 58", expected: 58},
+	}
+
+	for _, tc := range cases {
+		sprint, err := p.ResolveSprint(tc.board, tc.sprint)
+		if tc.err {
+			if err == nil {
+				t.Errorf("expected an error resolving sprint %q of board %d, got sprint %d", tc.sprint, tc.board, sprint.ID)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("resolving sprint %q of board %d: %v", tc.sprint, tc.board, err)
+		} else if sprint.ID != tc.expected {
+			t.Errorf("expected sprint %q of board %d to be %d, got %d", tc.sprint, tc.board, tc.expected, sprint.ID)
+		}
+	}
+
+	sprint, err := p.ResolveSprint(1, "active")
+	if err != nil {
+		t.Fatalf("resolving active sprint: %v", err)
+	}
+	if sprint.Goal != "ship it" {
+		t.Errorf("expected the sprint goal to be read, got %q", sprint.Goal)
+	}
+}
@@ -0,0 +1,29 @@
+package jira
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FindFieldID finds the id of the first of the named fields that exists, eg 'customfield_10016' for 'Story Points',
+// returning an empty id if none do
+func (p Project) FindFieldID(names ...string) (string, error) {
+	client, err := p.NewClient()
+	if err != nil {
+		return "", fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	fields, _, err := client.Field.GetList()
+	if err != nil {
+		return "", fmt.Errorf("listing fields: %v", err)
+	}
+
+	for _, name := range names {
+		for _, field := range fields {
+			if strings.EqualFold(field.Name, name) || field.ID == name {
+				return field.ID, nil
+			}
+		}
+	}
+	return "", nil
+}
@@ -1,6 +1,7 @@
 package jira
 
 import (
+	"encoding/json"
 	"fmt"
 
 	j "github.com/andygrunwald/go-jira"
@@ -35,8 +36,6 @@ func (p Project) ListIssues(jql string) ([]j.Issue, error) {
 			return issues, nil
 		}
 	}
-
-	return issues, nil
 }
 
 func (p Project) GetIssue(issueId string) (*j.Issue, error) {
@@ -53,21 +52,58 @@ func (p Project) GetIssue(issueId string) (*j.Issue, error) {
 	return issue, nil
 }
 
+// GetIssueWithChangeLog gets an issue with its whole changelog. Jira cloud only includes the first 100 changes when
+// the changelog is expanded, so the rest are paged through with the changelog api
 func (p Project) GetIssueWithChangeLog(issueId string) (*j.Issue, error) {
 	client, err := p.NewClient()
 	if err != nil {
 		return nil, fmt.Errorf("creating jira client: %v: ", err)
 	}
 
-	opts := j.GetQueryOptions{
-		Expand: "changelog",
-	}
-
-	issue, _, err := client.Issue.Get(issueId, &opts)
+	req, err := client.NewRequest("GET", fmt.Sprintf("rest/api/2/issue/%s?expand=changelog", issueId), nil)
 	if err != nil {
+		return nil, fmt.Errorf("creating request for jira issue %s: %v", issueId, err)
+	}
+	raw := json.RawMessage{}
+	if _, err := client.Do(req, &raw); err != nil {
 		return nil, fmt.Errorf("getting jira issue %s: %v: ", issueId, err)
 	}
 
+	issue := &j.Issue{}
+	if err := json.Unmarshal(raw, issue); err != nil {
+		return nil, fmt.Errorf("parsing jira issue %s: %v", issueId, err)
+	}
+	changelog := struct {
+		Changelog struct {
+			Total int `json:"total"`
+		} `json:"changelog"`
+	}{}
+	if err := json.Unmarshal(raw, &changelog); err != nil {
+		return nil, fmt.Errorf("parsing changelog of jira issue %s: %v", issueId, err)
+	}
+	if issue.Changelog == nil {
+		return issue, nil
+	}
+
+	for len(issue.Changelog.Histories) < changelog.Changelog.Total {
+		startAt := len(issue.Changelog.Histories)
+		req, err := client.NewRequest("GET", fmt.Sprintf("rest/api/2/issue/%s/changelog?startAt=%d&maxResults=100", issueId, startAt), nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request for the changelog of jira issue %s: %v", issueId, err)
+		}
+
+		page := struct {
+			Values []j.ChangelogHistory `json:"values"`
+		}{}
+		if _, err := client.Do(req, &page); err != nil {
+			return nil, fmt.Errorf("getting changes %d onwards of jira issue %s: %v", startAt, issueId, err)
+		}
+		if len(page.Values) == 0 {
+			break
+		}
+		issue.Changelog.Histories = append(issue.Changelog.Histories, page.Values...)
+	}
+
 	return issue, nil
 }
 
@@ -99,3 +135,31 @@ func (p Project) GetPossibleIssueTransitions(issueId string) ([]j.Transition, er
 
 	return transitions, nil
 }
+
+func (p Project) AddComment(issueId string, body string) error {
+	client, err := p.NewClient()
+	if err != nil {
+		return fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	_, _, err = client.Issue.AddComment(issueId, &j.Comment{Body: body})
+	if err != nil {
+		return fmt.Errorf("adding comment to issue id %s: %v: ", issueId, err)
+	}
+
+	return nil
+}
+
+// GetCurrentUser gets the user the client authenticates as
+func (p Project) GetCurrentUser() (*j.User, error) {
+	client, err := p.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	user, _, err := client.User.GetSelf()
+	if err != nil {
+		return nil, fmt.Errorf("getting the current jira user: %v", err)
+	}
+	return user, nil
+}
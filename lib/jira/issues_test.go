@@ -0,0 +1,89 @@
+package jira
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestListIssuesPaginates(t *testing.T) {
+	issues := make([]jiratest.Issue, 0)
+	for i := 1; i <= 7; i++ {
+		issues = append(issues, jiratest.Issue{Key: fmt.Sprintf("IPL-%d", i), Status: "To Do"})
+	}
+	s := jiratest.NewServer(jiratest.Seed{Issues: issues, PageSize: 3})
+	defer s.Close()
+
+	p := Project{JiraUrl: s.URL}
+	listed, err := p.ListIssues("project = IPL")
+	if err != nil {
+		t.Fatalf("listing issues: %v", err)
+	}
+
+	if len(listed) != 7 {
+		t.Fatalf("expected 7 issues over 3 pages, got %d", len(listed))
+	}
+	for i, issue := range listed {
+		if expected := fmt.Sprintf("IPL-%d", i+1); issue.Key != expected {
+			t.Errorf("expected issue %d to be %s, got %s", i, expected, issue.Key)
+		}
+	}
+}
+
+func TestGetIssueWithChangeLog(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := jiratest.NewServer(jiratest.Seed{
+		Issues: []jiratest.Issue{{
+			Key:    "IPL-1",
+			Status: "Done",
+			Changelog: []jiratest.History{
+				{Author: "someone", Created: created, Items: []jiratest.HistoryItem{{Field: "status", From: "In Review", To: "Done"}}},
+			},
+		}},
+	})
+	defer s.Close()
+
+	p := Project{JiraUrl: s.URL}
+	issue, err := p.GetIssueWithChangeLog("IPL-1")
+	if err != nil {
+		t.Fatalf("getting issue: %v", err)
+	}
+
+	if issue.Changelog == nil || len(issue.Changelog.Histories) != 1 {
+		t.Fatalf("expected one changelog history, got %+v", issue.Changelog)
+	}
+	item := issue.Changelog.Histories[0].Items[0]
+	if item.Field != "status" || item.FromString != "In Review" || item.ToString != "Done" {
+		t.Errorf("unexpected changelog item %+v", item)
+	}
+}
+
+func TestGetIssueWithChangeLogPages(t *testing.T) {
+	changelog := make([]jiratest.History, 0)
+	for i := 1; i <= 5; i++ {
+		changelog = append(changelog, jiratest.History{Author: "someone", Created: time.Date(2024, 1, i, 0, 0, 0, 0, time.UTC),
+			Items: []jiratest.HistoryItem{{Field: "labels", To: fmt.Sprintf("label-%d", i)}}})
+	}
+	s := jiratest.NewServer(jiratest.Seed{
+		Issues:            []jiratest.Issue{{Key: "IPL-1", Status: "To Do", Changelog: changelog}},
+		ChangelogPageSize: 2,
+	})
+	defer s.Close()
+
+	p := Project{JiraUrl: s.URL}
+	issue, err := p.GetIssueWithChangeLog("IPL-1")
+	if err != nil {
+		t.Fatalf("getting issue: %v", err)
+	}
+
+	if issue.Changelog == nil || len(issue.Changelog.Histories) != 5 {
+		t.Fatalf("expected all 5 changes past the 2 included with the issue, got %+v", issue.Changelog)
+	}
+	for i, history := range issue.Changelog.Histories {
+		if expected := fmt.Sprintf("label-%d", i+1); history.Items[0].ToString != expected {
+			t.Errorf("expected change %d to be %s, got %s", i, expected, history.Items[0].ToString)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package jira
 
 import (
 	j "github.com/andygrunwald/go-jira"
+	"github.com/jirallreadyforthis/lib/replay"
 )
 
 type Project struct {
@@ -14,6 +15,8 @@ func (p Project) NewClient() (*j.Client, error) {
 	tp := j.BasicAuthTransport{
 		Username: p.UserName,
 		Password: p.Token,
+		// records or replays requests when enabled, otherwise this is the default transport
+		Transport: replay.Transport(nil),
 	}
 
 	client, err := j.NewClient(tp.Client(), p.JiraUrl)
@@ -0,0 +1,478 @@
+package jiratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxIssuesPerMove is the most issues jira accepts in one request to move or rank issues
+const MaxIssuesPerMove = 50
+
+type Board struct {
+	ID         int
+	Name       string
+	Type       string
+	ProjectKey string
+}
+
+type Sprint struct {
+	ID      int
+	BoardID int
+	Name    string
+	// State is 'future', 'active' or 'closed'
+	State        string
+	Goal         string
+	StartDate    *time.Time
+	EndDate      *time.Time
+	CompleteDate *time.Time
+}
+
+// Sprint returns a copy of the current state of a sprint, or nil if there is no sprint with the id
+func (s *Server) Sprint(id int) *Sprint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sprint := s.findSprint(id); sprint != nil {
+		copied := *sprint
+		return &copied
+	}
+	return nil
+}
+
+// Sprints returns a copy of the current state of every sprint on a board
+func (s *Server) Sprints(boardID int) []Sprint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sprints := make([]Sprint, 0)
+	for _, sprint := range s.sprints {
+		if sprint.BoardID == boardID {
+			sprints = append(sprints, *sprint)
+		}
+	}
+	return sprints
+}
+
+func (s *Server) findSprint(id int) *Sprint {
+	for _, sprint := range s.sprints {
+		if sprint.ID == id {
+			return sprint
+		}
+	}
+	return nil
+}
+
+// page returns the start and end of the page of n results requested with startAt and maxResults
+func page(r *http.Request, n int) (int, int) {
+	startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+	maxResults, _ := strconv.Atoi(r.URL.Query().Get("maxResults"))
+	if maxResults <= 0 {
+		maxResults = 50
+	}
+	if startAt > n {
+		startAt = n
+	}
+	end := startAt + maxResults
+	if end > n {
+		end = n
+	}
+	return startAt, end
+}
+
+func (s *Server) handleBoard(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/rest/agile/1.0/board"), "/"), "/")
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s %s is not supported by jiratest", r.Method, r.URL.Path))
+		return
+	}
+
+	if parts[0] == "" {
+		name := strings.ToLower(r.URL.Query().Get("name"))
+		project := r.URL.Query().Get("projectKeyOrId")
+		boardType := r.URL.Query().Get("type")
+		boards := make([]interface{}, 0)
+		for _, board := range s.boards {
+			if name != "" && !strings.Contains(strings.ToLower(board.Name), name) {
+				continue
+			}
+			if project != "" && !strings.EqualFold(board.ProjectKey, project) {
+				continue
+			}
+			if boardType != "" && board.Type != boardType {
+				continue
+			}
+			boards = append(boards, boardJSON(board))
+		}
+
+		start, end := page(r, len(boards))
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"startAt":    start,
+			"maxResults": end - start,
+			"total":      len(boards),
+			"isLast":     end >= len(boards),
+			"values":     boards[start:end],
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	var board *Board
+	for i := range s.boards {
+		if s.boards[i].ID == id {
+			board = &s.boards[i]
+		}
+	}
+	if err != nil || board == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("board %s does not exist", parts[0]))
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		writeJSON(w, http.StatusOK, boardJSON(*board))
+	case len(parts) == 2 && parts[1] == "sprint" && board.Type != "scrum":
+		writeError(w, http.StatusBadRequest, "The board does not support sprints")
+	case len(parts) == 2 && parts[1] == "sprint":
+		states := make([]string, 0)
+		if state := r.URL.Query().Get("state"); state != "" {
+			states = strings.Split(state, ",")
+		}
+		sprints := make([]interface{}, 0)
+		for _, sprint := range s.sprints {
+			if sprint.BoardID != board.ID || (len(states) > 0 && !containsFold(states, sprint.State)) {
+				continue
+			}
+			sprints = append(sprints, sprintJSON(sprint))
+		}
+
+		start, end := page(r, len(sprints))
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"startAt":    start,
+			"maxResults": end - start,
+			"isLast":     end >= len(sprints),
+			"values":     sprints[start:end],
+		})
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s is not supported by jiratest", r.Method, r.URL.Path))
+	}
+}
+
+func (s *Server) handleSprint(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/rest/agile/1.0/sprint"), "/")
+	if path == "" {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s %s is not supported by jiratest", r.Method, r.URL.Path))
+			return
+		}
+		s.createSprint(w, r)
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	sprintID, err := strconv.Atoi(parts[0])
+	if err != nil || len(parts) > 2 || (len(parts) == 2 && parts[1] != "issue") {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s is not supported by jiratest", r.Method, r.URL.Path))
+		return
+	}
+
+	if len(parts) == 1 {
+		sprint := s.findSprint(sprintID)
+		if sprint == nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("sprint %d does not exist", sprintID))
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, sprintJSON(sprint))
+		case http.MethodPost:
+			s.updateSprint(w, r, sprint)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s %s is not supported by jiratest", r.Method, r.URL.Path))
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.moveIssues(w, r, sprintID)
+
+	case http.MethodGet:
+		matched := make([]*Issue, 0)
+		for _, issue := range s.issues {
+			if issue.SprintID == sprintID {
+				matched = append(matched, issue)
+			}
+		}
+
+		start, end := page(r, len(matched))
+		issues := make([]interface{}, 0)
+		for _, issue := range matched[start:end] {
+			issues = append(issues, issueJSON(issue, 0))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"startAt":    start,
+			"maxResults": end - start,
+			"total":      len(matched),
+			"issues":     issues,
+		})
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s %s is not supported by jiratest", r.Method, r.URL.Path))
+	}
+}
+
+func (s *Server) handleBacklog(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s %s is not supported by jiratest", r.Method, r.URL.Path))
+		return
+	}
+	s.moveIssues(w, r, 0)
+}
+
+// moveIssues moves the issues in the request to a sprint, or to the backlog when sprintID is 0
+func (s *Server) moveIssues(w http.ResponseWriter, r *http.Request, sprintID int) {
+	payload := struct {
+		Issues []string `json:"issues"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	issues, ok := s.movedIssues(w, payload.Issues)
+	if !ok {
+		return
+	}
+	for _, issue := range issues {
+		if issue.SprintID != sprintID {
+			s.setSprint(issue, sprintID)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// movedIssues finds the issues of a move or rank request, writing an error and returning false if there are too many
+// or any don't exist
+func (s *Server) movedIssues(w http.ResponseWriter, idsOrKeys []string) ([]*Issue, bool) {
+	if len(idsOrKeys) > MaxIssuesPerMove {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d issues can be moved at once", MaxIssuesPerMove))
+		return nil, false
+	}
+
+	issues := make([]*Issue, 0)
+	for _, idOrKey := range idsOrKeys {
+		issue := s.findIssue(idOrKey)
+		if issue == nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("issue %s does not exist", idOrKey))
+			return nil, false
+		}
+		issues = append(issues, issue)
+	}
+	return issues, true
+}
+
+// Ranked returns the keys of every issue in rank order
+func (s *Server) Ranked() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0)
+	for _, issue := range s.issues {
+		keys = append(keys, issue.Key)
+	}
+	return keys
+}
+
+// handleRank reorders issues, the order of s.issues is their rank, and is the order searches return them in
+func (s *Server) handleRank(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s %s is not supported by jiratest", r.Method, r.URL.Path))
+		return
+	}
+
+	payload := struct {
+		Issues          []string `json:"issues"`
+		RankBeforeIssue string   `json:"rankBeforeIssue"`
+		RankAfterIssue  string   `json:"rankAfterIssue"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	issues, ok := s.movedIssues(w, payload.Issues)
+	if !ok {
+		return
+	}
+	if (payload.RankBeforeIssue == "") == (payload.RankAfterIssue == "") {
+		writeError(w, http.StatusBadRequest, "one of rankBeforeIssue or rankAfterIssue is required")
+		return
+	}
+	anchor := s.findIssue(payload.RankBeforeIssue + payload.RankAfterIssue)
+	if anchor == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("issue %s does not exist", payload.RankBeforeIssue+payload.RankAfterIssue))
+		return
+	}
+
+	moving := map[*Issue]bool{}
+	for _, issue := range issues {
+		if issue == anchor {
+			writeError(w, http.StatusBadRequest, "an issue can't be ranked relative to itself")
+			return
+		}
+		moving[issue] = true
+	}
+
+	ranked := make([]*Issue, 0, len(s.issues))
+	for _, issue := range s.issues {
+		if moving[issue] {
+			continue
+		}
+		if issue == anchor && payload.RankBeforeIssue != "" {
+			ranked = append(ranked, issues...)
+		}
+		ranked = append(ranked, issue)
+		if issue == anchor && payload.RankAfterIssue != "" {
+			ranked = append(ranked, issues...)
+		}
+	}
+	s.issues = ranked
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type sprintPayload struct {
+	Name          *string `json:"name"`
+	Goal          *string `json:"goal"`
+	State         *string `json:"state"`
+	StartDate     *string `json:"startDate"`
+	EndDate       *string `json:"endDate"`
+	OriginBoardID int     `json:"originBoardId"`
+}
+
+func (s *Server) createSprint(w http.ResponseWriter, r *http.Request) {
+	payload := sprintPayload{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if payload.Name == nil || *payload.Name == "" {
+		writeError(w, http.StatusBadRequest, "a sprint name is required")
+		return
+	}
+
+	id := 1
+	for _, sprint := range s.sprints {
+		if sprint.ID >= id {
+			id = sprint.ID + 1
+		}
+	}
+	sprint := &Sprint{ID: id, BoardID: payload.OriginBoardID, State: "future"}
+	if !applySprint(w, sprint, payload) {
+		return
+	}
+	s.sprints = append(s.sprints, sprint)
+	writeJSON(w, http.StatusCreated, sprintJSON(sprint))
+}
+
+func (s *Server) updateSprint(w http.ResponseWriter, r *http.Request, sprint *Sprint) {
+	payload := sprintPayload{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !applySprint(w, sprint, payload) {
+		return
+	}
+
+	if sprint.State == "active" && (sprint.StartDate == nil || sprint.EndDate == nil) {
+		writeError(w, http.StatusBadRequest, "a sprint needs a start and end date to be started")
+		return
+	}
+	if sprint.State == "closed" && sprint.CompleteDate == nil {
+		// like jira, issues that aren't done go back to the backlog when a sprint is completed
+		now := time.Now()
+		sprint.CompleteDate = &now
+		for _, issue := range s.issues {
+			if issue.SprintID == sprint.ID && issue.StatusCategory != "done" {
+				s.setSprint(issue, 0)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, sprintJSON(sprint))
+}
+
+// applySprint sets the fields of a sprint from a create or update payload, writing an error and returning false if
+// any are invalid
+func applySprint(w http.ResponseWriter, sprint *Sprint, payload sprintPayload) bool {
+	if payload.Name != nil {
+		sprint.Name = *payload.Name
+	}
+	if payload.Goal != nil {
+		sprint.Goal = *payload.Goal
+	}
+	if payload.State != nil {
+		sprint.State = *payload.State
+	}
+	for _, date := range []struct {
+		value  *string
+		target **time.Time
+	}{{payload.StartDate, &sprint.StartDate}, {payload.EndDate, &sprint.EndDate}} {
+		if date.value == nil {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, *date.value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("parsing sprint date %q: %v", *date.value, err))
+			return false
+		}
+		*date.target = &t
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func boardJSON(board Board) map[string]interface{} {
+	return map[string]interface{}{
+		"id":   board.ID,
+		"name": board.Name,
+		"type": board.Type,
+	}
+}
+
+func sprintJSON(sprint *Sprint) map[string]interface{} {
+	j := map[string]interface{}{
+		"id":            sprint.ID,
+		"name":          sprint.Name,
+		"state":         sprint.State,
+		"goal":          sprint.Goal,
+		"originBoardId": sprint.BoardID,
+	}
+	for name, date := range map[string]*time.Time{"startDate": sprint.StartDate, "endDate": sprint.EndDate, "completeDate": sprint.CompleteDate} {
+		if date != nil {
+			j[name] = date.UTC().Format(time.RFC3339)
+		}
+	}
+	return j
+}
@@ -0,0 +1,835 @@
+// Package jiratest provides an in-memory fake of the parts of the jira rest api used by jira.Project, for testing
+// code that works with jira without a real jira instance.
+package jiratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const timeFormat = "2006-01-02T15:04:05.000-0700"
+
+type Issue struct {
+	ID          string
+	Key         string
+	Summary     string
+	Description string
+	Type        string
+	Status      string
+	// StatusCategory is the key of the status category eg 'new', 'indeterminate' or 'done'
+	StatusCategory string
+	Assignee       string
+	Labels         []string
+	FixVersions    []string
+	Created        time.Time
+	Updated        time.Time
+	Comments       []Comment
+	// Changelog is in chronological order, oldest first, as jira returns it
+	Changelog []History
+	// CustomFields are returned alongside the standard fields eg 'customfield_10001'
+	CustomFields map[string]interface{}
+	SprintID     int
+}
+
+type Comment struct {
+	Author  string
+	Body    string
+	Created time.Time
+}
+
+type History struct {
+	Author  string
+	Created time.Time
+	Items   []HistoryItem
+}
+
+type HistoryItem struct {
+	Field string
+	From  string
+	To    string
+	// FromID and ToID are the raw values of the field, eg the sprint ids of a Sprint change
+	FromID string
+	ToID   string
+}
+
+// Transition moves an issue to the status To, it is available to issues in any of the From statuses
+type Transition struct {
+	ID   string
+	Name string
+	From []string
+	To   string
+}
+
+type Version struct {
+	ID          string
+	Name        string
+	Description string
+	Released    bool
+	ReleaseDate string
+}
+
+// Field is a custom field, returned by the fields api alongside the standard fields
+type Field struct {
+	ID   string
+	Name string
+}
+
+type Project struct {
+	ID       string
+	Key      string
+	Name     string
+	Versions []Version
+}
+
+// Seed is the initial state of a Server
+type Seed struct {
+	Issues      []Issue
+	Transitions []Transition
+	Projects    []Project
+	Statuses    []string
+	// StatusCategories maps a status name to its category key, statuses not in here take the category of an issue
+	// in them, or 'new'
+	StatusCategories map[string]string
+	Boards           []Board
+	Sprints          []Sprint
+	Fields           []Field
+	// Queries maps a jql query to the keys of the issues it returns. Queries not in here of the form
+	// 'issueKey = X' or 'issueKey in (X, Y)' return the matching issues, and anything else returns every issue
+	Queries map[string][]string
+	// PageSize caps the number of issues returned per search page, to exercise pagination
+	PageSize int
+	// ChangelogPageSize caps the changes returned with an issue and per page of its changelog, defaulting to 100
+	// like jira cloud
+	ChangelogPageSize int
+	// Myself is the account id and name of the user requests are made as, defaulting to 'jiratest' which is the
+	// author of the changes the server records. Like jira cloud, its email isn't shown in changelogs
+	Myself string
+}
+
+// Server is a fake jira, its URL can be used as the JiraUrl of a jira.Project
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	issues      []*Issue
+	transitions []Transition
+	projects    []*Project
+	statuses    []string
+	categories  map[string]string
+	boards      []Board
+	sprints     []*Sprint
+	fields      []Field
+	queries     map[string][]string
+	pageSize    int
+	changelogs  int
+	myself      string
+	nextID      int
+	// Requests records the method and path of each request made, in order
+	requests []string
+}
+
+func NewServer(seed Seed) *Server {
+	s := &Server{
+		transitions: seed.Transitions,
+		statuses:    seed.Statuses,
+		categories:  seed.StatusCategories,
+		boards:      seed.Boards,
+		fields:      seed.Fields,
+		queries:     seed.Queries,
+		pageSize:    seed.PageSize,
+		changelogs:  seed.ChangelogPageSize,
+		myself:      seed.Myself,
+		nextID:      10000,
+	}
+	if s.myself == "" {
+		s.myself = "jiratest"
+	}
+	if s.changelogs == 0 {
+		s.changelogs = 100
+	}
+
+	for i := range seed.Issues {
+		issue := seed.Issues[i]
+		if issue.ID == "" {
+			issue.ID = strconv.Itoa(10000 + i)
+		}
+		s.issues = append(s.issues, &issue)
+	}
+	for i := range seed.Sprints {
+		sprint := seed.Sprints[i]
+		s.sprints = append(s.sprints, &sprint)
+	}
+	for i := range seed.Projects {
+		project := seed.Projects[i]
+		if project.ID == "" {
+			project.ID = strconv.Itoa(100 + i)
+		}
+		s.projects = append(s.projects, &project)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/2/search", s.handleSearch)
+	mux.HandleFunc("/rest/api/2/issue/", s.handleIssue)
+	mux.HandleFunc("/rest/api/2/status", s.handleStatuses)
+	mux.HandleFunc("/rest/api/2/field", s.handleFields)
+	mux.HandleFunc("/rest/api/2/myself", s.handleMyself)
+	mux.HandleFunc("/rest/api/2/project/", s.handleProject)
+	mux.HandleFunc("/rest/api/2/version", s.handleVersion)
+	mux.HandleFunc("/rest/api/2/version/", s.handleVersion)
+	mux.HandleFunc("/rest/agile/1.0/board", s.handleBoard)
+	mux.HandleFunc("/rest/agile/1.0/board/", s.handleBoard)
+	mux.HandleFunc("/rest/agile/1.0/sprint", s.handleSprint)
+	mux.HandleFunc("/rest/agile/1.0/sprint/", s.handleSprint)
+	mux.HandleFunc("/rest/agile/1.0/backlog/issue", s.handleBacklog)
+	mux.HandleFunc("/rest/agile/1.0/issue/rank", s.handleRank)
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		s.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+
+	return s
+}
+
+func (s *Server) handleMyself(w http.ResponseWriter, r *http.Request) {
+	email, _, _ := r.BasicAuth()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"accountId":    s.myself,
+		"name":         s.myself,
+		"displayName":  s.myself,
+		"emailAddress": email,
+	})
+}
+
+// Issue returns a copy of the current state of an issue, or nil if there is no issue with the key
+func (s *Server) Issue(key string) *Issue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, issue := range s.issues {
+		if issue.Key == key {
+			i := *issue
+			return &i
+		}
+	}
+	return nil
+}
+
+// Project returns a copy of the current state of a project, or nil if there is no project with the key
+func (s *Server) Project(key string) *Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, project := range s.projects {
+		if project.Key == key {
+			p := *project
+			return &p
+		}
+	}
+	return nil
+}
+
+// hasVersion reports whether the project of an issue has a version with the name, issues of projects that
+// aren't seeded accept any version
+func (s *Server) hasVersion(issueKey string, name string) bool {
+	for _, project := range s.projects {
+		if !strings.HasPrefix(issueKey, project.Key+"-") {
+			continue
+		}
+		for _, v := range project.Versions {
+			if strings.EqualFold(v.Name, name) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// SetQuery changes the issues a jql query returns
+func (s *Server) SetQuery(jql string, keys []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.queries == nil {
+		s.queries = make(map[string][]string)
+	}
+	s.queries[jql] = keys
+}
+
+// Requests returns the method and path of every request the server has received
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.requests...)
+}
+
+func (s *Server) findIssue(idOrKey string) *Issue {
+	for _, issue := range s.issues {
+		if issue.ID == idOrKey || issue.Key == idOrKey {
+			return issue
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jql := r.URL.Query().Get("jql")
+	matched := s.search(jql)
+
+	startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+	maxResults, _ := strconv.Atoi(r.URL.Query().Get("maxResults"))
+	if maxResults <= 0 || maxResults > 1000 {
+		maxResults = 50
+	}
+	if s.pageSize > 0 && maxResults > s.pageSize {
+		maxResults = s.pageSize
+	}
+
+	page := make([]interface{}, 0)
+	for i := startAt; i < len(matched) && i < startAt+maxResults; i++ {
+		page = append(page, issueJSON(matched[i], 0))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"startAt":    startAt,
+		"maxResults": maxResults,
+		"total":      len(matched),
+		"issues":     page,
+	})
+}
+
+// updatedSinceQuery is a query limited to issues updated within the last number of minutes, as an incremental
+// index sync makes
+var updatedSinceQuery = regexp.MustCompile(`^\((.*)\) AND updated >= "-(\d+)m"$`)
+
+var issueKeyQuery = regexp.MustCompile(`(?i)^\s*(?:issueKey|key)\s*(=|in)\s*\(?([^)]*)\)?\s*$`)
+
+func (s *Server) search(jql string) []*Issue {
+	if m := updatedSinceQuery.FindStringSubmatch(jql); m != nil {
+		minutes, _ := strconv.Atoi(m[2])
+		since := time.Now().Add(-time.Duration(minutes) * time.Minute)
+		matched := make([]*Issue, 0)
+		for _, issue := range s.search(m[1]) {
+			if !issue.Updated.Before(since) {
+				matched = append(matched, issue)
+			}
+		}
+		return matched
+	}
+
+	if keys, ok := s.queries[jql]; ok {
+		matched := make([]*Issue, 0)
+		for _, key := range keys {
+			if issue := s.findIssue(key); issue != nil {
+				matched = append(matched, issue)
+			}
+		}
+		return matched
+	}
+
+	if m := issueKeyQuery.FindStringSubmatch(jql); m != nil {
+		matched := make([]*Issue, 0)
+		for _, key := range strings.Split(m[2], ",") {
+			if issue := s.findIssue(strings.Trim(strings.TrimSpace(key), `"'`)); issue != nil {
+				matched = append(matched, issue)
+			}
+		}
+		return matched
+	}
+
+	return append([]*Issue{}, s.issues...)
+}
+
+func (s *Server) handleIssue(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/"), "/"), "/")
+	issue := s.findIssue(parts[0])
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		changes := 0
+		if strings.Contains(r.URL.Query().Get("expand"), "changelog") {
+			changes = s.changelogs
+		}
+		writeJSON(w, http.StatusOK, issueJSON(issue, changes))
+
+	case len(parts) == 2 && parts[1] == "changelog" && r.Method == http.MethodGet:
+		startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+		maxResults, _ := strconv.Atoi(r.URL.Query().Get("maxResults"))
+		if maxResults <= 0 || maxResults > s.changelogs {
+			maxResults = s.changelogs
+		}
+		values := historiesJSON(issue, startAt, maxResults)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"startAt":    startAt,
+			"maxResults": maxResults,
+			"total":      len(issue.Changelog),
+			"isLast":     startAt+len(values) >= len(issue.Changelog),
+			"values":     values,
+		})
+
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		s.updateIssue(w, r, issue)
+
+	case len(parts) == 2 && parts[1] == "transitions" && r.Method == http.MethodGet:
+		transitions := make([]interface{}, 0)
+		for _, t := range s.availableTransitions(issue) {
+			transitions = append(transitions, map[string]interface{}{
+				"id":   t.ID,
+				"name": t.Name,
+				"to":   map[string]interface{}{"name": t.To},
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"transitions": transitions})
+
+	case len(parts) == 2 && parts[1] == "transitions" && r.Method == http.MethodPost:
+		payload := struct {
+			Transition struct {
+				ID string `json:"id"`
+			} `json:"transition"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		for _, t := range s.availableTransitions(issue) {
+			if t.ID == payload.Transition.ID {
+				s.setStatus(issue, t.To)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Transition id '%s' is not valid for this issue.", payload.Transition.ID))
+
+	case len(parts) == 2 && parts[1] == "comment" && r.Method == http.MethodPost:
+		payload := struct {
+			Body string `json:"body"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		comment := Comment{Author: "jiratest", Body: payload.Body, Created: time.Now()}
+		issue.Comments = append(issue.Comments, comment)
+		writeJSON(w, http.StatusCreated, commentJSON(len(issue.Comments), comment))
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s %s is not supported by jiratest", r.Method, r.URL.Path))
+	}
+}
+
+func (s *Server) availableTransitions(issue *Issue) []Transition {
+	available := make([]Transition, 0)
+	for _, t := range s.transitions {
+		if len(t.From) == 0 {
+			available = append(available, t)
+			continue
+		}
+		for _, from := range t.From {
+			if strings.EqualFold(from, issue.Status) {
+				available = append(available, t)
+				break
+			}
+		}
+	}
+	return available
+}
+
+func (s *Server) setStatus(issue *Issue, status string) {
+	now := time.Now()
+	issue.Changelog = append(issue.Changelog, History{
+		Author:  "jiratest",
+		Created: now,
+		Items:   []HistoryItem{{Field: "status", From: issue.Status, To: status}},
+	})
+	issue.Status = status
+	issue.Updated = now
+}
+
+func (s *Server) setSprint(issue *Issue, sprintID int) {
+	item := HistoryItem{Field: "Sprint"}
+	if issue.SprintID != 0 {
+		item.FromID = strconv.Itoa(issue.SprintID)
+	}
+	if sprintID != 0 {
+		item.ToID = strconv.Itoa(sprintID)
+	}
+
+	now := time.Now()
+	issue.Changelog = append(issue.Changelog, History{
+		Author:  "jiratest",
+		Created: now,
+		Items:   []HistoryItem{item},
+	})
+	issue.SprintID = sprintID
+	issue.Updated = now
+}
+
+func (s *Server) updateIssue(w http.ResponseWriter, r *http.Request, issue *Issue) {
+	payload := struct {
+		Fields map[string]interface{}              `json:"fields"`
+		Update map[string][]map[string]interface{} `json:"update"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for field, ops := range payload.Update {
+		for _, op := range ops {
+			for verb, value := range op {
+				name := ""
+				if m, ok := value.(map[string]interface{}); ok {
+					name, _ = m["name"].(string)
+				} else if v, ok := value.(string); ok {
+					name = v
+				}
+
+				switch field {
+				case "fixVersions":
+					if verb != "remove" && !s.hasVersion(issue.Key, name) {
+						writeError(w, http.StatusBadRequest, fmt.Sprintf("Version name '%s' is not valid", name))
+						return
+					}
+					issue.FixVersions = applyOp(issue.FixVersions, verb, name)
+				case "labels":
+					issue.Labels = applyOp(issue.Labels, verb, name)
+				default:
+					writeError(w, http.StatusBadRequest, fmt.Sprintf("updating field %s is not supported by jiratest", field))
+					return
+				}
+			}
+		}
+	}
+
+	for field, value := range payload.Fields {
+		if issue.CustomFields == nil {
+			issue.CustomFields = make(map[string]interface{})
+		}
+		switch field {
+		case "summary":
+			issue.Summary, _ = value.(string)
+		case "description":
+			issue.Description, _ = value.(string)
+		default:
+			issue.CustomFields[field] = value
+		}
+	}
+
+	issue.Updated = time.Now()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func applyOp(values []string, verb string, value string) []string {
+	switch verb {
+	case "add":
+		for _, v := range values {
+			if v == value {
+				return values
+			}
+		}
+		return append(values, value)
+	case "remove":
+		kept := make([]string, 0)
+		for _, v := range values {
+			if v != value {
+				kept = append(kept, v)
+			}
+		}
+		return kept
+	case "set":
+		return []string{value}
+	}
+	return values
+}
+
+func (s *Server) handleStatuses(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := append([]string{}, s.statuses...)
+	if len(names) == 0 {
+		seen := make(map[string]bool)
+		for _, issue := range s.issues {
+			if !seen[issue.Status] {
+				seen[issue.Status] = true
+				names = append(names, issue.Status)
+			}
+		}
+		for name := range s.categories {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	statuses := make([]interface{}, 0)
+	for i, name := range names {
+		category := s.categories[name]
+		for _, issue := range s.issues {
+			if category == "" && issue.Status == name {
+				category = issue.StatusCategory
+			}
+		}
+		if category == "" {
+			category = "new"
+		}
+		statuses = append(statuses, map[string]interface{}{
+			"id":   strconv.Itoa(i + 1),
+			"name": name,
+			"statusCategory": map[string]interface{}{
+				"key":  category,
+				"name": categoryName(category),
+			},
+		})
+	}
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func (s *Server) handleFields(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fields := make([]interface{}, 0)
+	for _, name := range []string{"Summary", "Description", "Status", "Assignee", "Labels"} {
+		id := strings.ToLower(name)
+		fields = append(fields, map[string]interface{}{"id": id, "key": id, "name": name, "custom": false})
+	}
+	for _, field := range s.fields {
+		fields = append(fields, map[string]interface{}{"id": field.ID, "key": field.ID, "name": field.Name, "custom": true})
+	}
+	writeJSON(w, http.StatusOK, fields)
+}
+
+func (s *Server) handleProject(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.Trim(strings.TrimPrefix(r.URL.Path, "/rest/api/2/project/"), "/")
+	for _, project := range s.projects {
+		if project.Key == key || project.ID == key {
+			versions := make([]interface{}, 0)
+			for _, v := range project.Versions {
+				versions = append(versions, versionJSON(v))
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"id":       project.ID,
+				"key":      project.Key,
+				"name":     project.Name,
+				"versions": versions,
+			})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("No project could be found with key '%s'.", key))
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payload := struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		ProjectID   int    `json:"projectId"`
+		Released    *bool  `json:"released"`
+		ReleaseDate string `json:"releaseDate"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		for _, project := range s.projects {
+			if project.ID == strconv.Itoa(payload.ProjectID) {
+				s.nextID++
+				v := Version{ID: strconv.Itoa(s.nextID), Name: payload.Name, Description: payload.Description}
+				project.Versions = append(project.Versions, v)
+				writeJSON(w, http.StatusCreated, versionJSON(v))
+				return
+			}
+		}
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("project id %d not found", payload.ProjectID))
+
+	case http.MethodPut:
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/rest/api/2/version/"), "/")
+		for _, project := range s.projects {
+			for i, v := range project.Versions {
+				if v.ID != id {
+					continue
+				}
+				if payload.Released != nil {
+					v.Released = *payload.Released
+				}
+				if payload.ReleaseDate != "" {
+					v.ReleaseDate = payload.ReleaseDate
+				}
+				project.Versions[i] = v
+				writeJSON(w, http.StatusOK, versionJSON(v))
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, fmt.Sprintf("version id %s not found", id))
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s %s is not supported by jiratest", r.Method, r.URL.Path))
+	}
+}
+
+// issueJSON is an issue with up to the first changes of its changelog, or without a changelog if changes is 0
+func issueJSON(issue *Issue, changes int) map[string]interface{} {
+	comments := make([]interface{}, 0)
+	for i, comment := range issue.Comments {
+		comments = append(comments, commentJSON(i+1, comment))
+	}
+
+	category := issue.StatusCategory
+	if category == "" {
+		category = "new"
+	}
+
+	fields := map[string]interface{}{
+		"summary":     issue.Summary,
+		"description": issue.Description,
+		"issuetype":   map[string]interface{}{"name": issue.Type},
+		"status": map[string]interface{}{
+			"name": issue.Status,
+			"statusCategory": map[string]interface{}{
+				"key":  category,
+				"name": categoryName(category),
+			},
+		},
+		"labels":  issue.Labels,
+		"created": issue.Created.Format(timeFormat),
+		"updated": issue.Updated.Format(timeFormat),
+		"comment": map[string]interface{}{
+			"comments":   comments,
+			"total":      len(comments),
+			"maxResults": len(comments),
+		},
+	}
+	if issue.Assignee != "" {
+		fields["assignee"] = map[string]interface{}{"name": issue.Assignee, "displayName": issue.Assignee}
+	}
+	fixVersions := make([]interface{}, 0)
+	for _, v := range issue.FixVersions {
+		fixVersions = append(fixVersions, map[string]interface{}{"name": v})
+	}
+	fields["fixVersions"] = fixVersions
+	for name, value := range issue.CustomFields {
+		fields[name] = value
+	}
+
+	i := map[string]interface{}{
+		"id":     issue.ID,
+		"key":    issue.Key,
+		"fields": fields,
+	}
+
+	if changes > 0 {
+		histories := historiesJSON(issue, 0, changes)
+		i["changelog"] = map[string]interface{}{
+			"startAt":    0,
+			"maxResults": changes,
+			"total":      len(issue.Changelog),
+			"histories":  histories,
+		}
+	}
+
+	return i
+}
+
+// historiesJSON is a page of the changelog of an issue
+func historiesJSON(issue *Issue, startAt int, maxResults int) []interface{} {
+	histories := make([]interface{}, 0)
+	for n := startAt; n < len(issue.Changelog) && n < startAt+maxResults; n++ {
+		history := issue.Changelog[n]
+		items := make([]interface{}, 0)
+		for _, item := range history.Items {
+			i := map[string]interface{}{
+				"field":      item.Field,
+				"fromString": item.From,
+				"toString":   item.To,
+			}
+			if item.FromID != "" {
+				i["from"] = item.FromID
+			}
+			if item.ToID != "" {
+				i["to"] = item.ToID
+			}
+			items = append(items, i)
+		}
+		histories = append(histories, map[string]interface{}{
+			"id":      strconv.Itoa(n + 1),
+			"author":  map[string]interface{}{"accountId": history.Author, "name": history.Author, "displayName": history.Author},
+			"created": history.Created.Format(timeFormat),
+			"items":   items,
+		})
+	}
+	return histories
+}
+
+func commentJSON(id int, comment Comment) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      strconv.Itoa(id),
+		"author":  map[string]interface{}{"name": comment.Author, "displayName": comment.Author},
+		"body":    comment.Body,
+		"created": comment.Created.Format(timeFormat),
+	}
+}
+
+func versionJSON(v Version) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          v.ID,
+		"name":        v.Name,
+		"description": v.Description,
+		"released":    v.Released,
+		"releaseDate": v.ReleaseDate,
+	}
+}
+
+func categoryName(key string) string {
+	switch key {
+	case "done":
+		return "Done"
+	case "indeterminate":
+		return "In Progress"
+	}
+	return "To Do"
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]interface{}{"errorMessages": []string{msg}})
+}
@@ -2,17 +2,201 @@ package jira
 
 import (
 	"fmt"
+	"time"
+
+	j "github.com/andygrunwald/go-jira"
 )
 
+// maxIssuesPerMove is the most issues the agile api accepts in one request to move or rank issues, larger moves are
+// split into chunks of this size
+const maxIssuesPerMove = 50
+
 func (p Project) AddToSprint(sprintId int, issueIds []string) error {
 	client, err := p.NewClient()
 	if err != nil {
 		return fmt.Errorf("creating jira client: %v: ", err)
 	}
 
-	_, err = client.Sprint.MoveIssuesToSprint(sprintId, issueIds)
+	for _, chunk := range chunkIds(issueIds) {
+		_, err = client.Sprint.MoveIssuesToSprint(sprintId, chunk)
+		if err != nil {
+			return fmt.Errorf("moving issues to sprint id %d: %v", sprintId, err)
+		}
+	}
+	return nil
+}
+
+// GetSprint gets a sprint by id
+func (p Project) GetSprint(sprintId int) (*Sprint, error) {
+	client, err := p.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	req, err := client.NewRequest("GET", fmt.Sprintf("rest/agile/1.0/sprint/%d", sprintId), nil)
 	if err != nil {
-		return fmt.Errorf("moving issues to sprint id %d", sprintId)
+		return nil, fmt.Errorf("creating request for sprint id %d: %v", sprintId, err)
+	}
+	sprint := &Sprint{}
+	if _, err := client.Do(req, sprint); err != nil {
+		return nil, fmt.Errorf("getting sprint id %d: %v", sprintId, err)
+	}
+	return sprint, nil
+}
+
+// CreateSprint creates a future sprint on a board, the dates are optional
+func (p Project) CreateSprint(boardId int, name string, goal string, startDate *time.Time, endDate *time.Time) (*Sprint, error) {
+	client, err := p.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	body := map[string]interface{}{
+		"name":          name,
+		"originBoardId": boardId,
+	}
+	if goal != "" {
+		body["goal"] = goal
+	}
+	if startDate != nil {
+		body["startDate"] = startDate.Format(time.RFC3339)
+	}
+	if endDate != nil {
+		body["endDate"] = endDate.Format(time.RFC3339)
+	}
+
+	req, err := client.NewRequest("POST", "rest/agile/1.0/sprint", body)
+	if err != nil {
+		return nil, fmt.Errorf("creating request to create sprint %s: %v", name, err)
+	}
+	sprint := &Sprint{}
+	if _, err := client.Do(req, sprint); err != nil {
+		return nil, fmt.Errorf("creating sprint %s on board id %d: %v", name, boardId, err)
+	}
+	return sprint, nil
+}
+
+// StartSprint makes a future sprint the active sprint, running between the input dates
+func (p Project) StartSprint(sprintId int, startDate time.Time, endDate time.Time) error {
+	return p.updateSprint(sprintId, map[string]interface{}{
+		"state":     "active",
+		"startDate": startDate.Format(time.RFC3339),
+		"endDate":   endDate.Format(time.RFC3339),
+	})
+}
+
+// CompleteSprint closes an active sprint, jira moves any issues still in it that aren't done to the backlog
+func (p Project) CompleteSprint(sprintId int) error {
+	return p.updateSprint(sprintId, map[string]interface{}{
+		"state": "closed",
+	})
+}
+
+func (p Project) updateSprint(sprintId int, body map[string]interface{}) error {
+	client, err := p.NewClient()
+	if err != nil {
+		return fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	req, err := client.NewRequest("POST", fmt.Sprintf("rest/agile/1.0/sprint/%d", sprintId), body)
+	if err != nil {
+		return fmt.Errorf("creating request to update sprint id %d: %v", sprintId, err)
+	}
+	if _, err := client.Do(req, nil); err != nil {
+		return fmt.Errorf("updating sprint id %d to %v: %v", sprintId, body["state"], err)
+	}
+	return nil
+}
+
+// ListSprintIssues lists every issue in a sprint
+func (p Project) ListSprintIssues(sprintId int) ([]j.Issue, error) {
+	client, err := p.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	issues := make([]j.Issue, 0)
+	for {
+		req, err := client.NewRequest("GET", fmt.Sprintf("rest/agile/1.0/sprint/%d/issue?startAt=%d&maxResults=50", sprintId, len(issues)), nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request for issues of sprint id %d: %v", sprintId, err)
+		}
+
+		page := struct {
+			Total  int       `json:"total"`
+			Issues []j.Issue `json:"issues"`
+		}{}
+		if _, err := client.Do(req, &page); err != nil {
+			return nil, fmt.Errorf("listing issues of sprint id %d: %v", sprintId, err)
+		}
+		issues = append(issues, page.Issues...)
+		if len(page.Issues) == 0 || len(issues) >= page.Total {
+			return issues, nil
+		}
+	}
+}
+
+// MoveToBacklog moves issues out of whichever sprint they are in to the backlog
+func (p Project) MoveToBacklog(issueIds []string) error {
+	client, err := p.NewClient()
+	if err != nil {
+		return fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	for _, chunk := range chunkIds(issueIds) {
+		req, err := client.NewRequest("POST", "rest/agile/1.0/backlog/issue", map[string]interface{}{"issues": chunk})
+		if err != nil {
+			return fmt.Errorf("creating request to move issues to the backlog: %v", err)
+		}
+		if _, err := client.Do(req, nil); err != nil {
+			return fmt.Errorf("moving issues to the backlog: %v", err)
+		}
+	}
+	return nil
+}
+
+// RankIssues ranks issues, in the order given, directly before or after another issue. Only one of before and after
+// should be set
+func (p Project) RankIssues(issueIds []string, before string, after string) error {
+	if (before == "") == (after == "") {
+		return fmt.Errorf("exactly one issue to rank before or after is required")
+	}
+
+	client, err := p.NewClient()
+	if err != nil {
+		return fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	for _, chunk := range chunkIds(issueIds) {
+		body := map[string]interface{}{"issues": chunk}
+		if before != "" {
+			body["rankBeforeIssue"] = before
+		} else {
+			body["rankAfterIssue"] = after
+		}
+
+		req, err := client.NewRequest("PUT", "rest/agile/1.0/issue/rank", body)
+		if err != nil {
+			return fmt.Errorf("creating request to rank issues: %v", err)
+		}
+		if _, err := client.Do(req, nil); err != nil {
+			return fmt.Errorf("ranking issues: %v", err)
+		}
+
+		// keep later chunks in order by ranking them after the last issue of this one
+		before, after = "", chunk[len(chunk)-1]
 	}
 	return nil
 }
+
+func chunkIds(ids []string) [][]string {
+	chunks := make([][]string, 0)
+	for len(ids) > maxIssuesPerMove {
+		chunks = append(chunks, ids[:maxIssuesPerMove])
+		ids = ids[maxIssuesPerMove:]
+	}
+	if len(ids) > 0 {
+		chunks = append(chunks, ids)
+	}
+	return chunks
+}
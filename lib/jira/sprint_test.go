@@ -0,0 +1,61 @@
+package jira
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jirallreadyforthis/lib/jira/jiratest"
+)
+
+func TestSprintMovesAreChunked(t *testing.T) {
+	issues := make([]jiratest.Issue, 0)
+	ids := make([]string, 0)
+	for i := 1; i <= 120; i++ {
+		issues = append(issues, jiratest.Issue{ID: fmt.Sprint(i), Key: fmt.Sprintf("IPL-%d", i), Status: "To Do"})
+		ids = append(ids, fmt.Sprint(i))
+	}
+	issues = append(issues, jiratest.Issue{ID: "121", Key: "IPL-121", Status: "To Do"})
+	s := jiratest.NewServer(jiratest.Seed{Issues: issues})
+	defer s.Close()
+
+	p := Project{JiraUrl: s.URL}
+	if err := p.AddToSprint(7, ids); err != nil {
+		t.Fatalf("adding issues to sprint: %v", err)
+	}
+	for _, key := range []string{"IPL-1", "IPL-60", "IPL-120"} {
+		if actual := s.Issue(key).SprintID; actual != 7 {
+			t.Errorf("expected %s to be in sprint 7, got %d", key, actual)
+		}
+	}
+
+	if err := p.MoveToBacklog(ids); err != nil {
+		t.Fatalf("moving issues to the backlog: %v", err)
+	}
+	if actual := s.Issue("IPL-120").SprintID; actual != 0 {
+		t.Errorf("expected IPL-120 to be in the backlog, got sprint %d", actual)
+	}
+
+	// rank every issue but the last before the last, in reverse order
+	reversed := make([]string, 0)
+	for i := len(ids) - 1; i >= 0; i-- {
+		reversed = append(reversed, ids[i])
+	}
+	if err := p.RankIssues(reversed, "IPL-121", ""); err != nil {
+		t.Fatalf("ranking issues: %v", err)
+	}
+	ranked := s.Ranked()
+	if ranked[0] != "IPL-120" || ranked[49] != "IPL-71" || ranked[50] != "IPL-70" || ranked[119] != "IPL-1" || ranked[120] != "IPL-121" {
+		t.Errorf("expected the issues to be ranked in reverse before IPL-121, got %s", strings.Join(ranked, ","))
+	}
+
+	moves := 0
+	for _, request := range s.Requests() {
+		if strings.HasPrefix(request, "POST /rest/agile/1.0/sprint/7/issue") {
+			moves++
+		}
+	}
+	if moves != 3 {
+		t.Errorf("expected 120 issues to be moved in 3 requests, got %d", moves)
+	}
+}
@@ -0,0 +1,105 @@
+package jira
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	j "github.com/andygrunwald/go-jira"
+)
+
+func (p Project) CreateVersion(projectKey string, name string, description string) (*j.Version, error) {
+	client, err := p.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	project, _, err := client.Project.Get(projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("getting jira project %s: %v", projectKey, err)
+	}
+
+	projectId, err := strconv.Atoi(project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("parsing id of jira project %s: %v", projectKey, err)
+	}
+
+	version, _, err := client.Version.Create(&j.Version{
+		Name:        name,
+		Description: description,
+		ProjectID:   projectId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating version %s in project %s: %v", name, projectKey, err)
+	}
+
+	return version, nil
+}
+
+// GetVersion looks up a version of a project by name, returning nil if the project has no such version
+func (p Project) GetVersion(projectKey string, name string) (*j.Version, error) {
+	client, err := p.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	project, _, err := client.Project.Get(projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("getting jira project %s: %v", projectKey, err)
+	}
+
+	for _, version := range project.Versions {
+		if strings.EqualFold(version.Name, name) {
+			v := version
+			return &v, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// AddFixVersion adds the named version to the fixVersions of an issue, leaving any existing fixVersions in place
+func (p Project) AddFixVersion(issueId string, versionName string) error {
+	client, err := p.NewClient()
+	if err != nil {
+		return fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	update := map[string]interface{}{
+		"update": map[string]interface{}{
+			"fixVersions": []map[string]interface{}{
+				{
+					"add": map[string]string{
+						"name": versionName,
+					},
+				},
+			},
+		},
+	}
+
+	_, err = client.Issue.UpdateIssue(issueId, update)
+	if err != nil {
+		return fmt.Errorf("adding fix version %s to issue id %s: %v", versionName, issueId, err)
+	}
+
+	return nil
+}
+
+func (p Project) ReleaseVersion(versionId string, releaseDate string) error {
+	client, err := p.NewClient()
+	if err != nil {
+		return fmt.Errorf("creating jira client: %v: ", err)
+	}
+
+	released := true
+	_, _, err = client.Version.Update(&j.Version{
+		ID:          versionId,
+		Released:    &released,
+		ReleaseDate: releaseDate,
+	})
+	if err != nil {
+		return fmt.Errorf("releasing version id %s: %v", versionId, err)
+	}
+
+	return nil
+}
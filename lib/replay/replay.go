@@ -0,0 +1,337 @@
+package replay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	ModeOff    = ""
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+var (
+	mode string
+	dir  string
+
+	mu       sync.Mutex
+	sequence = make(map[string]int)
+)
+
+// Configure sets whether the jira and github clients record their http traffic to dir, replay it from dir, or
+// neither. It is called once on start up before any clients are created
+func Configure(recordDir string, replayDir string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	sequence = make(map[string]int)
+
+	switch {
+	case recordDir != "" && replayDir != "":
+		return fmt.Errorf("only one of record or replay can be set")
+	case recordDir != "":
+		if err := os.MkdirAll(recordDir, 0o755); err != nil {
+			return fmt.Errorf("creating record dir %s: %v", recordDir, err)
+		}
+		mode, dir = ModeRecord, recordDir
+	case replayDir != "":
+		if _, err := os.Stat(replayDir); err != nil {
+			return fmt.Errorf("opening replay dir %s: %v", replayDir, err)
+		}
+		mode, dir = ModeReplay, replayDir
+	default:
+		mode, dir = ModeOff, ""
+	}
+
+	return nil
+}
+
+// Enabled reports whether requests are currently being recorded or replayed
+func Enabled() bool {
+	return mode != ModeOff
+}
+
+// Transport wraps base so requests are recorded or replayed as configured, base is returned as is when neither is
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	switch mode {
+	case ModeRecord:
+		return &recorder{base: base, dir: dir}
+	case ModeReplay:
+		return &replayer{dir: dir}
+	}
+	return base
+}
+
+// interaction is a sanitized request and response pair as stored on disk
+type interaction struct {
+	Request  request  `json:"request"`
+	Response response `json:"response"`
+}
+
+type request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	// BodyBase64 is set instead of Body when the body isn't valid utf-8
+	BodyBase64 string `json:"body_base64,omitempty"`
+}
+
+// sensitiveHeaders are never written to disk
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization", "X-Atlassian-Token", "Private-Token"}
+
+// sensitiveParams are query parameters, form fields and json keys that may carry credentials
+var sensitiveParams = regexp.MustCompile("(?i)^(access_?token|refresh_?token|id_?token|private_?token|token|api_?key|secret|client_?secret|password|signature|sig)$")
+
+const redacted = "REDACTED"
+
+func sanitizeURL(u *url.URL) string {
+	clean := *u
+	clean.User = nil
+
+	query := clean.Query()
+	for name := range query {
+		if sensitiveParams.MatchString(name) {
+			query.Set(name, redacted)
+		}
+	}
+	// Encode sorts by key, so the same request always produces the same url
+	clean.RawQuery = query.Encode()
+
+	return clean.String()
+}
+
+func sanitizeHeader(h http.Header) http.Header {
+	clean := h.Clone()
+	for _, name := range sensitiveHeaders {
+		clean.Del(name)
+	}
+	return clean
+}
+
+// sanitizeBody redacts credentials from json and form bodies, such as the tokens in an oauth token exchange.
+// Bodies without credentials are returned as they are
+func sanitizeBody(body []byte, contentType string) []byte {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		var v interface{}
+		if err := json.Unmarshal(trimmed, &v); err != nil || !redactJSON(v) {
+			return body
+		}
+		clean, err := json.Marshal(v)
+		if err != nil {
+			return body
+		}
+		return clean
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+		changed := false
+		for name := range form {
+			if sensitiveParams.MatchString(name) {
+				form.Set(name, redacted)
+				changed = true
+			}
+		}
+		if changed {
+			return []byte(form.Encode())
+		}
+	}
+
+	return body
+}
+
+// redactJSON replaces the values of sensitive keys anywhere in v, reporting whether it replaced any
+func redactJSON(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if _, ok := value.(string); ok && sensitiveParams.MatchString(k) {
+				v[k] = redacted
+				changed = true
+			} else if redactJSON(value) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if redactJSON(value) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// key identifies a request so a replayed request finds the response recorded for it
+func key(method string, sanitizedURL string, body []byte) string {
+	sum := sha256.Sum256([]byte(method + " " + sanitizedURL + "\n" + string(body)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// nextFile returns the file for the next occurrence of a request, identical requests are numbered in the order
+// they are made so replaying returns responses in the order they were recorded
+func nextFile(dir string, method string, u *url.URL, k string) string {
+	mu.Lock()
+	n := sequence[k]
+	sequence[k] = n + 1
+	mu.Unlock()
+
+	name := strings.Trim(regexp.MustCompile("[^a-zA-Z0-9]+").ReplaceAllString(u.Host+u.Path, "-"), "-")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+
+	return filepath.Join(dir, fmt.Sprintf("%s-%s-%s-%03d.json", strings.ToLower(method), name, k, n))
+}
+
+func readBody(body io.ReadCloser) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+type recorder struct {
+	base http.RoundTripper
+	dir  string
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading request body to record: %v", err)
+	}
+	if reqBody != nil {
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readBody(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body to record: %v", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	sanitized := sanitizeURL(req.URL)
+	sanitizedBody := sanitizeBody(reqBody, req.Header.Get("Content-Type"))
+	i := interaction{
+		Request: request{
+			Method: req.Method,
+			URL:    sanitized,
+			Header: sanitizeHeader(req.Header),
+			Body:   string(sanitizedBody),
+		},
+		Response: response{
+			StatusCode: resp.StatusCode,
+			Header:     sanitizeHeader(resp.Header),
+		},
+	}
+	if utf8.Valid(respBody) {
+		i.Response.Body = string(sanitizeBody(respBody, resp.Header.Get("Content-Type")))
+	} else {
+		i.Response.BodyBase64 = base64.StdEncoding.EncodeToString(respBody)
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(i); err != nil {
+		return nil, fmt.Errorf("encoding recorded request: %v", err)
+	}
+	file := nextFile(r.dir, req.Method, req.URL, key(req.Method, sanitized, sanitizedBody))
+	if err := os.WriteFile(file, b.Bytes(), 0o644); err != nil {
+		return nil, fmt.Errorf("writing recorded request %s: %v", file, err)
+	}
+
+	return resp, nil
+}
+
+type replayer struct {
+	dir string
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading request body to replay: %v", err)
+	}
+
+	// requests are matched on what was written to disk, so credentials that differ between runs don't matter
+	sanitized := sanitizeURL(req.URL)
+	file := nextFile(r.dir, req.Method, req.URL, key(req.Method, sanitized, sanitizeBody(reqBody, req.Header.Get("Content-Type"))))
+
+	b, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no recorded response for %s %s in %s", req.Method, sanitized, r.dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading recorded request %s: %v", file, err)
+	}
+
+	i := interaction{}
+	if err := json.Unmarshal(b, &i); err != nil {
+		return nil, fmt.Errorf("parsing recorded request %s: %v", file, err)
+	}
+
+	body := []byte(i.Response.Body)
+	if i.Response.BodyBase64 != "" {
+		body, err = base64.StdEncoding.DecodeString(i.Response.BodyBase64)
+		if err != nil {
+			return nil, fmt.Errorf("decoding recorded response body %s: %v", file, err)
+		}
+	}
+
+	header := i.Response.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Response.StatusCode, http.StatusText(i.Response.StatusCode)),
+		StatusCode:    i.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
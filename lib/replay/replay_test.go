@@ -0,0 +1,172 @@
+package replay_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jirallreadyforthis/lib/gh"
+	"github.com/jirallreadyforthis/lib/gh/ghtest"
+	"github.com/jirallreadyforthis/lib/gitlab"
+	"github.com/jirallreadyforthis/lib/gitlab/gitlabtest"
+	"github.com/jirallreadyforthis/lib/replay"
+)
+
+// record configures recording to a temporary dir for the duration of the test
+func record(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := replay.Configure(dir, ""); err != nil {
+		t.Fatalf("configuring record: %v", err)
+	}
+	t.Cleanup(func() { replay.Configure("", "") })
+	return dir
+}
+
+// replayFrom configures replaying from dir for the rest of the test
+func replayFrom(t *testing.T, dir string) {
+	t.Helper()
+	if err := replay.Configure("", dir); err != nil {
+		t.Fatalf("configuring replay: %v", err)
+	}
+}
+
+// recorded returns the contents of every file recorded to dir
+func recorded(t *testing.T, dir string) string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("expected recorded requests in %s, got %v %v", dir, files, err)
+	}
+	all := ""
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("reading %s: %v", file, err)
+		}
+		all += string(b)
+	}
+	return all
+}
+
+func TestRecordRedactsGitLabToken(t *testing.T) {
+	s := gitlabtest.NewServer(gitlabtest.Seed{
+		MergeRequests: []gitlabtest.MergeRequest{{Project: "group/name", IID: 1, State: "opened"}},
+	})
+	defer s.Close()
+	dir := record(t)
+
+	if _, err := gitlab.NewProject(s.URL, "group/name", "glpat-secret").GetMergeRequest(1); err != nil {
+		t.Fatalf("getting merge request: %v", err)
+	}
+
+	if out := recorded(t, dir); strings.Contains(out, "glpat-secret") || strings.Contains(strings.ToLower(out), "private-token") {
+		t.Errorf("expected the gitlab token to be redacted, got %s", out)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	s := ghtest.NewServer(ghtest.Seed{
+		Issues: []ghtest.Issue{{Repo: "acme/app", Number: 1, Title: "crash", State: "open"}},
+	})
+	dir := record(t)
+
+	repo := gh.NewRepo("acme/app", "ghp-secret")
+	repo.BaseURL = s.URL
+	states := func() []string {
+		t.Helper()
+		states := make([]string, 0)
+		for i := 0; i < 2; i++ {
+			issue, err := repo.GetIssue(1)
+			if err != nil {
+				t.Fatalf("getting issue: %v", err)
+			}
+			states = append(states, issue.GetState())
+			if i == 0 {
+				if err := repo.CloseIssue(1, gh.CloseReasonCompleted); err != nil {
+					t.Fatalf("closing issue: %v", err)
+				}
+			}
+		}
+		return states
+	}
+
+	if actual := strings.Join(states(), ","); actual != "open,closed" {
+		t.Fatalf("expected the issue to be closed while recording, got %s", actual)
+	}
+	s.Close()
+	if out := recorded(t, dir); strings.Contains(out, "ghp-secret") {
+		t.Errorf("expected the github token to be redacted, got %s", out)
+	}
+
+	// identical requests get the responses recorded for them in order, without the server
+	replayFrom(t, dir)
+	if actual := strings.Join(states(), ","); actual != "open,closed" {
+		t.Errorf("expected the recorded responses in order, got %s", actual)
+	}
+	if _, err := repo.GetIssue(1); err == nil || !strings.Contains(err.Error(), "no recorded response for GET") {
+		t.Errorf("expected an error for a request made more times than recorded, got %v", err)
+	}
+	if _, err := repo.GetIssue(2); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("expected an error for a request that wasn't recorded, got %v", err)
+	}
+}
+
+func TestRecordRedactsBodies(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login/oauth/access_token":
+			w.Header().Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+			io.WriteString(w, "access_token=gho_secret&scope=repo&token_type=bearer")
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"session":{"name":"me","access_token":"session-secret"},"values":[{"refresh_token":"refresh-secret"}]}`)
+		}
+	}))
+	defer s.Close()
+
+	client := func() *http.Client { return &http.Client{Transport: replay.Transport(nil)} }
+	exchange := func() string {
+		t.Helper()
+		form := url.Values{"client_id": {"app"}, "client_secret": {"client-secret"}, "code": {"abc"}}
+		resp, err := client().PostForm(s.URL+"/login/oauth/access_token?client_id=app", form)
+		if err != nil {
+			t.Fatalf("exchanging code: %v", err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+
+		resp, err = client().Post(s.URL+"/session?token=query-secret", "application/json", strings.NewReader(`{"user":"me","password":"hunter2"}`))
+		if err != nil {
+			t.Fatalf("logging in: %v", err)
+		}
+		defer resp.Body.Close()
+		session, _ := io.ReadAll(resp.Body)
+		return string(b) + "\n" + string(session)
+	}
+
+	dir := record(t)
+	if out := exchange(); !strings.Contains(out, "gho_secret") {
+		t.Fatalf("expected the real response while recording, got %s", out)
+	}
+	out := recorded(t, dir)
+	for _, secret := range []string{"gho_secret", "client-secret", "session-secret", "refresh-secret", "query-secret", "hunter2"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("expected %s to be redacted, got %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "scope=repo") || !strings.Contains(out, `\"user\":\"me\"`) {
+		t.Errorf("expected the rest of the bodies to be kept, got %s", out)
+	}
+
+	// the replayed requests carry the real credentials but still match the redacted recording
+	replayFrom(t, dir)
+	if out := exchange(); !strings.Contains(out, "access_token=REDACTED") || !strings.Contains(out, `"name":"me"`) {
+		t.Errorf("expected the redacted responses to be replayed, got %s", out)
+	}
+}
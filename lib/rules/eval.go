@@ -0,0 +1,353 @@
+package rules
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+type node interface {
+	eval(e *evaluator, dot interface{}) (interface{}, error)
+}
+
+type evaluator struct {
+	env Env
+	now time.Time
+}
+
+// Eval evaluates the rule against env, the rule must evaluate to a bool
+func (r *Rule) Eval(env Env) (bool, error) {
+	e := &evaluator{
+		env: env,
+		now: time.Now(),
+	}
+
+	v, err := r.root.eval(e, nil)
+	if err != nil {
+		return false, fmt.Errorf("evaluating rule %q for %s: %v", r.Source, env.Issue.Key, err)
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("evaluating rule %q for %s: expected the rule to result in a bool but got a %s", r.Source, env.Issue.Key, describe(v))
+	}
+	return b, nil
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(_ *evaluator, _ interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+type fieldNode struct {
+	// root is empty for fields of the current list element
+	root string
+	path []string
+	pos  int
+}
+
+func (n fieldNode) eval(e *evaluator, dot interface{}) (interface{}, error) {
+	v := dot
+	if n.root != "" {
+		v = roots[n.root](e.env)
+	} else if dot == nil {
+		return nil, fmt.Errorf("column %d: '.%s' can only be used inside a function such as all(prs, .%s)", n.pos+1, n.path[0], n.path[0])
+	}
+
+	for _, name := range n.path {
+		o, ok := v.(object)
+		if !ok {
+			return nil, fmt.Errorf("column %d: cannot get field '%s' of a %s", n.pos+1, name, describe(v))
+		}
+		v, ok = o.field(name)
+		if !ok {
+			return nil, fmt.Errorf("column %d: %s has no field '%s'", n.pos+1, o.kind(), name)
+		}
+	}
+	return v, nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(e *evaluator, dot interface{}) (interface{}, error) {
+	v, err := evalBool(n.operand, e, dot)
+	if err != nil {
+		return nil, err
+	}
+	return !v, nil
+}
+
+type binaryNode struct {
+	op    string
+	left  node
+	right node
+}
+
+func (n binaryNode) eval(e *evaluator, dot interface{}) (interface{}, error) {
+	switch n.op {
+	case "&&", "||":
+		left, err := evalBool(n.left, e, dot)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "&&" && !left {
+			return false, nil
+		}
+		if n.op == "||" && left {
+			return true, nil
+		}
+		return evalBool(n.right, e, dot)
+	}
+
+	left, err := n.left.eval(e, dot)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(e, dot)
+	if err != nil {
+		return nil, err
+	}
+	return compare(n.op, left, right)
+}
+
+func evalBool(n node, e *evaluator, dot interface{}) (bool, error) {
+	v, err := n.eval(e, dot)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a bool but got a %s", describe(v))
+	}
+	return b, nil
+}
+
+func compare(op string, left interface{}, right interface{}) (interface{}, error) {
+	if left == nil || right == nil {
+		switch op {
+		case "==":
+			return left == right, nil
+		case "!=":
+			return left != right, nil
+		}
+		// ordering against a missing value is never true, eg a pr that isn't merged has no mergedAt
+		return false, nil
+	}
+
+	var c int
+	switch l := left.(type) {
+	case bool:
+		r, ok := right.(bool)
+		if !ok || (op != "==" && op != "!=") {
+			return nil, mismatch(op, left, right)
+		}
+		if op == "==" {
+			return l == r, nil
+		}
+		return l != r, nil
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, mismatch(op, left, right)
+		}
+		c = compareFloats(l, r)
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, mismatch(op, left, right)
+		}
+		c = strings.Compare(l, r)
+	case time.Time:
+		r, ok := right.(time.Time)
+		if !ok {
+			return nil, mismatch(op, left, right)
+		}
+		c = l.Compare(r)
+	default:
+		return nil, mismatch(op, left, right)
+	}
+
+	switch op {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	}
+	return nil, fmt.Errorf("unknown operator '%s'", op)
+}
+
+func compareFloats(l float64, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+func mismatch(op string, left interface{}, right interface{}) error {
+	return fmt.Errorf("cannot compare a %s and a %s with '%s'", describe(left), describe(right), op)
+}
+
+type callNode struct {
+	name string
+	args []node
+	pos  int
+}
+
+func (n callNode) eval(e *evaluator, dot interface{}) (interface{}, error) {
+	v, err := functions[n.name].call(e, n.args, dot)
+	if err != nil {
+		return nil, fmt.Errorf("column %d: %s: %v", n.pos+1, n.name, err)
+	}
+	return v, nil
+}
+
+type function struct {
+	usage   string
+	minArgs int
+	maxArgs int
+	call    func(e *evaluator, args []node, dot interface{}) (interface{}, error)
+}
+
+var functions = map[string]function{
+	"all": {
+		usage:   "a list and a condition eg all(prs, .merged)",
+		minArgs: 2, maxArgs: 2,
+		// like a for all this is true for an empty list, so all(prs, .merged) matches issues without pull requests
+		call: func(e *evaluator, args []node, dot interface{}) (interface{}, error) {
+			n, total, err := countMatching(e, args, dot)
+			return n == total, err
+		},
+	},
+	"any": {
+		usage:   "a list and a condition eg any(prs, .merged)",
+		minArgs: 2, maxArgs: 2,
+		call: func(e *evaluator, args []node, dot interface{}) (interface{}, error) {
+			n, _, err := countMatching(e, args, dot)
+			return n > 0, err
+		},
+	},
+	"none": {
+		usage:   "a list and a condition eg none(issues, .closed)",
+		minArgs: 2, maxArgs: 2,
+		call: func(e *evaluator, args []node, dot interface{}) (interface{}, error) {
+			n, _, err := countMatching(e, args, dot)
+			return n == 0, err
+		},
+	},
+	"count": {
+		usage:   "a list and an optional condition eg count(prs, .merged)",
+		minArgs: 1, maxArgs: 2,
+		call: func(e *evaluator, args []node, dot interface{}) (interface{}, error) {
+			n, _, err := countMatching(e, args, dot)
+			return float64(n), err
+		},
+	},
+	"contains": {
+		usage:   "a list or string and a value eg contains(issue.labels, \"ready\")",
+		minArgs: 2, maxArgs: 2,
+		call: func(e *evaluator, args []node, dot interface{}) (interface{}, error) {
+			haystack, err := args[0].eval(e, dot)
+			if err != nil {
+				return nil, err
+			}
+			needle, err := args[1].eval(e, dot)
+			if err != nil {
+				return nil, err
+			}
+			switch h := haystack.(type) {
+			case string:
+				s, ok := needle.(string)
+				if !ok {
+					return nil, fmt.Errorf("cannot look for a %s in a string", describe(needle))
+				}
+				return strings.Contains(h, s), nil
+			case []interface{}:
+				for _, v := range h {
+					if eq, err := compare("==", v, needle); err == nil && eq == true {
+						return true, nil
+					}
+				}
+				return false, nil
+			}
+			return nil, fmt.Errorf("expected a list or string but got a %s", describe(haystack))
+		},
+	},
+	"lower": {
+		usage:   "a string eg lower(issue.status)",
+		minArgs: 1, maxArgs: 1,
+		call: func(e *evaluator, args []node, dot interface{}) (interface{}, error) {
+			v, err := args[0].eval(e, dot)
+			if err != nil {
+				return nil, err
+			}
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a string but got a %s", describe(v))
+			}
+			return strings.ToLower(s), nil
+		},
+	},
+	"days_since": {
+		usage:   "a time eg days_since(issue.lastComment)",
+		minArgs: 1, maxArgs: 1,
+		call: func(e *evaluator, args []node, dot interface{}) (interface{}, error) {
+			v, err := args[0].eval(e, dot)
+			if err != nil {
+				return nil, err
+			}
+			switch t := v.(type) {
+			case nil:
+				// something that never happened happened infinitely long ago, eg an issue with no comments
+				return math.Inf(1), nil
+			case time.Time:
+				return e.now.Sub(t).Hours() / 24, nil
+			}
+			return nil, fmt.Errorf("expected a time but got a %s", describe(v))
+		},
+	},
+}
+
+// countMatching evaluates the list in args[0] and counts the elements matching the optional condition in args[1]
+func countMatching(e *evaluator, args []node, dot interface{}) (int, int, error) {
+	v, err := args[0].eval(e, dot)
+	if err != nil {
+		return 0, 0, err
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return 0, 0, fmt.Errorf("expected a list but got a %s", describe(v))
+	}
+
+	if len(args) == 1 {
+		return len(list), len(list), nil
+	}
+
+	n := 0
+	for _, element := range list {
+		matched, err := evalBool(args[1], e, element)
+		if err != nil {
+			return 0, 0, err
+		}
+		if matched {
+			n++
+		}
+	}
+	return n, len(list), nil
+}
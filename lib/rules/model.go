@@ -0,0 +1,216 @@
+package rules
+
+import (
+	"fmt"
+	"time"
+)
+
+// Env is the data a rule is evaluated against, a jira issue and the github items linked to it
+type Env struct {
+	Issue        Issue
+	PullRequests []PullRequest
+	Issues       []GithubIssue
+}
+
+type Issue struct {
+	Key            string
+	Summary        string
+	Status         string
+	StatusCategory string
+	Type           string
+	Assignee       string
+	Labels         []string
+	Created        *time.Time
+	Updated        *time.Time
+	LastComment    *time.Time
+	Comments       int
+}
+
+type PullRequest struct {
+	Number   int
+	URL      string
+	Repo     string
+	Title    string
+	State    string
+	Draft    bool
+	Merged   bool
+	Base     string
+	Head     string
+	MergedAt *time.Time
+	ClosedAt *time.Time
+	Approved bool
+	Checks   string
+}
+
+type GithubIssue struct {
+	Number   int
+	URL      string
+	Repo     string
+	Title    string
+	State    string
+	Closed   bool
+	ClosedAt *time.Time
+	// StateReason is why the issue was closed, 'completed' or 'not_planned', and empty while it is open
+	StateReason string
+}
+
+// object is implemented by values with fields that can be referenced from a rule
+type object interface {
+	field(name string) (interface{}, bool)
+	kind() string
+}
+
+// roots are the names a rule can start a field reference from
+var roots = map[string]func(env Env) interface{}{
+	"issue": func(env Env) interface{} {
+		return env.Issue
+	},
+	"prs": func(env Env) interface{} {
+		list := make([]interface{}, 0, len(env.PullRequests))
+		for _, pr := range env.PullRequests {
+			list = append(list, pr)
+		}
+		return list
+	},
+	"issues": func(env Env) interface{} {
+		list := make([]interface{}, 0, len(env.Issues))
+		for _, issue := range env.Issues {
+			list = append(list, issue)
+		}
+		return list
+	},
+}
+
+func rootNames() []string {
+	return []string{"issue", "prs", "issues"}
+}
+
+func (i Issue) kind() string {
+	return "jira issue"
+}
+
+func (i Issue) field(name string) (interface{}, bool) {
+	switch name {
+	case "key":
+		return i.Key, true
+	case "summary":
+		return i.Summary, true
+	case "status":
+		return i.Status, true
+	case "statusCategory":
+		return i.StatusCategory, true
+	case "type":
+		return i.Type, true
+	case "assignee":
+		return i.Assignee, true
+	case "labels":
+		return stringList(i.Labels), true
+	case "created":
+		return timeValue(i.Created), true
+	case "updated":
+		return timeValue(i.Updated), true
+	case "lastComment":
+		return timeValue(i.LastComment), true
+	case "comments":
+		return float64(i.Comments), true
+	}
+	return nil, false
+}
+
+func (pr PullRequest) kind() string {
+	return "pull request"
+}
+
+func (pr PullRequest) field(name string) (interface{}, bool) {
+	switch name {
+	case "number":
+		return float64(pr.Number), true
+	case "url":
+		return pr.URL, true
+	case "repo":
+		return pr.Repo, true
+	case "title":
+		return pr.Title, true
+	case "state":
+		return pr.State, true
+	case "draft":
+		return pr.Draft, true
+	case "merged":
+		return pr.Merged, true
+	case "base":
+		return pr.Base, true
+	case "head":
+		return pr.Head, true
+	case "mergedAt":
+		return timeValue(pr.MergedAt), true
+	case "closedAt":
+		return timeValue(pr.ClosedAt), true
+	case "approved":
+		return pr.Approved, true
+	case "checks":
+		return pr.Checks, true
+	}
+	return nil, false
+}
+
+func (i GithubIssue) kind() string {
+	return "github issue"
+}
+
+func (i GithubIssue) field(name string) (interface{}, bool) {
+	switch name {
+	case "number":
+		return float64(i.Number), true
+	case "url":
+		return i.URL, true
+	case "repo":
+		return i.Repo, true
+	case "title":
+		return i.Title, true
+	case "state":
+		return i.State, true
+	case "closed":
+		return i.Closed, true
+	case "closedAt":
+		return timeValue(i.ClosedAt), true
+	case "stateReason":
+		return i.StateReason, true
+	}
+	return nil, false
+}
+
+func stringList(s []string) []interface{} {
+	list := make([]interface{}, 0, len(s))
+	for _, v := range s {
+		list = append(list, v)
+	}
+	return list
+}
+
+// timeValue avoids a nil *time.Time ending up as a non-nil interface value
+func timeValue(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+func describe(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case time.Time:
+		return "time"
+	case []interface{}:
+		return "list"
+	case object:
+		return v.kind()
+	}
+	return fmt.Sprintf("%T", v)
+}
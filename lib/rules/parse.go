@@ -0,0 +1,347 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Rule is a parsed readiness rule which can be evaluated against an Env
+type Rule struct {
+	Source string
+	root   node
+	fields map[string]bool
+}
+
+// ParseError describes where and why a rule could not be parsed
+type ParseError struct {
+	Source string
+	Pos    int
+	Msg    string
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("parsing rule at column %d: %s\n\t%s\n\t%s^", e.Pos+1, e.Msg, e.Source, strings.Repeat(" ", e.Pos))
+}
+
+// References reports whether the rule refers to a field with the given name anywhere, this is used to avoid
+// fetching data the rule will never look at
+func (r *Rule) References(field string) bool {
+	return r.fields[field]
+}
+
+func Parse(source string) (*Rule, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{
+		source: source,
+		tokens: tokens,
+		fields: make(map[string]bool),
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %s after end of expression", t)
+	}
+
+	return &Rule{
+		Source: source,
+		root:   root,
+		fields: p.fields,
+	}, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of rule"
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", ",", "."}
+
+func lex(source string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+
+		case unicode.IsDigit(r):
+			start := i
+			dot := false
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || (runes[i] == '.' && !dot)) {
+				dot = dot || runes[i] == '.'
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+
+		case r == '"' || r == '\'':
+			start := i
+			i++
+			var sb strings.Builder
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, ParseError{Source: source, Pos: start, Msg: "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: start})
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, ParseError{Source: source, Pos: i, Msg: fmt.Sprintf("unexpected character '%c'", r)}
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	source string
+	tokens []token
+	pos    int
+	fields map[string]bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOp && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		return p.errorf(t, "expected '%s' but found %s", op, t)
+	}
+	return nil
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return ParseError{Source: p.source, Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.accept("!") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind == tokenOp {
+		switch t.text {
+		case "==", "!=", "<", "<=", ">", ">=":
+			p.next()
+			right, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return binaryNode{op: t.text, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf(t, "invalid number %s", t)
+		}
+		return literalNode{value: n}, nil
+
+	case tokenString:
+		return literalNode{value: t.text}, nil
+
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		case "null", "nil":
+			return literalNode{value: nil}, nil
+		}
+
+		if p.accept("(") {
+			if _, ok := functions[t.text]; !ok {
+				return nil, p.errorf(t, "unknown function '%s'", t.text)
+			}
+			args := make([]node, 0)
+			if !p.accept(")") {
+				for {
+					arg, err := p.parseOr()
+					if err != nil {
+						return nil, err
+					}
+					args = append(args, arg)
+					if p.accept(")") {
+						break
+					}
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if f := functions[t.text]; len(args) < f.minArgs || len(args) > f.maxArgs {
+				return nil, p.errorf(t, "%s expects %s", t.text, f.usage)
+			}
+			return callNode{name: t.text, args: args, pos: t.pos}, nil
+		}
+
+		if _, ok := roots[t.text]; !ok {
+			return nil, p.errorf(t, "unknown name '%s', expected one of %s", t.text, strings.Join(rootNames(), ", "))
+		}
+		path, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		return fieldNode{root: t.text, path: path, pos: t.pos}, nil
+
+	case tokenOp:
+		switch t.text {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		case ".":
+			// a field of the current list element eg '.merged' inside all(prs, .merged)
+			p.pos--
+			path, err := p.parsePath()
+			if err != nil {
+				return nil, err
+			}
+			return fieldNode{path: path, pos: t.pos}, nil
+		}
+	}
+
+	return nil, p.errorf(t, "expected a value but found %s", t)
+}
+
+func (p *parser) parsePath() ([]string, error) {
+	path := make([]string, 0)
+	for p.accept(".") {
+		t := p.next()
+		if t.kind != tokenIdent {
+			return nil, p.errorf(t, "expected a field name after '.' but found %s", t)
+		}
+		p.fields[t.text] = true
+		path = append(path, t.text)
+	}
+	return path, nil
+}
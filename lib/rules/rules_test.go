@@ -0,0 +1,146 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEval(t *testing.T) {
+	merged := time.Now().AddDate(0, 0, -3)
+	env := Env{
+		Issue: Issue{Key: "IPL-1", Status: "In Review", Type: "Story", Labels: []string{"ready"}, Comments: 2},
+		PullRequests: []PullRequest{
+			{Number: 1, Merged: true, MergedAt: &merged, Base: "main"},
+			{Number: 2, Base: "release/1.2"},
+		},
+	}
+	empty := Env{Issue: Issue{Key: "IPL-2"}}
+
+	cases := []struct {
+		rule     string
+		env      Env
+		expected bool
+	}{
+		// && binds tighter than ||
+		{`true || false && false`, env, true},
+		{`(true || false) && false`, env, false},
+		{`false && false || true`, env, true},
+		// ! binds tighter than && and comparisons bind tighter than !
+		{`!false && false`, env, false},
+		{`!issue.status == "Done"`, env, true},
+		{`!!true`, env, true},
+		{`issue.comments >= 2 && issue.comments < 2.5`, env, true},
+		{`issue.type == 'Story' && issue.status != "Done"`, env, true},
+		{`issue.assignee == null`, env, false},
+		{`contains(issue.labels, "ready") && lower(issue.status) == "in review"`, env, true},
+
+		{`any(prs, .merged)`, env, true},
+		{`all(prs, .merged)`, env, false},
+		{`none(prs, .draft)`, env, true},
+		{`all(prs, .base == "main" || .base == "release/1.2")`, env, true},
+		{`count(prs, .merged) == 1 && count(prs) == 2`, env, true},
+		{`any(prs, .merged && days_since(.mergedAt) > 2)`, env, true},
+		// ordering against a missing time is never true
+		{`any(prs, .mergedAt < issue.created)`, env, false},
+
+		// all is true for an empty list while any is false, count() tells them apart
+		{`all(prs, .merged)`, empty, true},
+		{`none(prs, .merged)`, empty, true},
+		{`any(prs, .merged)`, empty, false},
+		{`count(prs) > 0 && all(prs, .merged)`, empty, false},
+		{`days_since(issue.lastComment) > 1000`, empty, true},
+	}
+
+	for _, tc := range cases {
+		rule, err := Parse(tc.rule)
+		if err != nil {
+			t.Errorf("parsing %s: %v", tc.rule, err)
+			continue
+		}
+		actual, err := rule.Eval(tc.env)
+		if err != nil {
+			t.Errorf("evaluating %s: %v", tc.rule, err)
+			continue
+		}
+		if actual != tc.expected {
+			t.Errorf("expected %s to be %v for %s", tc.rule, tc.expected, tc.env.Issue.Key)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	env := Env{
+		Issue:        Issue{Key: "IPL-1", Status: "Done"},
+		PullRequests: []PullRequest{{Number: 1}},
+	}
+
+	cases := map[string]string{
+		`issue.status`:                 "expected the rule to result in a bool but got a string",
+		`issue.comments == "two"`:      "cannot compare a number and a string with '=='",
+		`issue.status > true`:          "cannot compare a string and a bool with '>'",
+		`true < false`:                 "cannot compare a bool and a bool with '<'",
+		`issue.status && true`:         "expected a bool but got a string",
+		`!issue.comments`:              "expected a bool but got a number",
+		`all(issue, .merged)`:          "expected a list but got a jira issue",
+		`any(prs, .number)`:            "expected a bool but got a number",
+		`any(prs, .nope)`:              "pull request has no field 'nope'",
+		`issue.status.length == 1`:     "cannot get field 'length' of a string",
+		`lower(issue.comments) == "2"`: "expected a string but got a number",
+		`days_since(issue.key) > 1`:    "expected a time but got a string",
+		`.merged`:                      "'.merged' can only be used inside a function",
+	}
+
+	for source, expected := range cases {
+		rule, err := Parse(source)
+		if err != nil {
+			t.Errorf("parsing %s: %v", source, err)
+			continue
+		}
+		_, err = rule.Eval(env)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected evaluating %s to fail with %q, got %v", source, expected, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		rule string
+		pos  int
+		msg  string
+	}{
+		{`1.2.3 > 1`, 3, "unexpected '.' after end of expression"},
+		{`issue.status == "Done`, 16, "unterminated string"},
+		{`issue.status = "Done"`, 13, "unexpected character"},
+		{`all(prs, .merged`, 16, "expected ',' but found end of rule"},
+		{`all(prs, .merged &&`, 19, "expected a value but found end of rule"},
+		{`all(prs)`, 0, "all expects a list and a condition"},
+		{`every(prs, .merged)`, 0, "unknown function 'every'"},
+		{`pulls.merged`, 0, "unknown name 'pulls', expected one of issue, prs, issues"},
+		{`issue.`, 6, "expected a field name after '.'"},
+		{`true true`, 5, "unexpected 'true' after end of expression"},
+	}
+
+	for _, tc := range cases {
+		_, err := Parse(tc.rule)
+		perr, ok := err.(ParseError)
+		if !ok {
+			t.Errorf("expected a parse error for %s, got %v", tc.rule, err)
+			continue
+		}
+		if perr.Pos != tc.pos || !strings.Contains(perr.Msg, tc.msg) {
+			t.Errorf("expected %s to fail at column %d with %q, got column %d: %s", tc.rule, tc.pos+1, tc.msg, perr.Pos+1, perr.Msg)
+		}
+	}
+}
+
+func TestReferences(t *testing.T) {
+	rule, err := Parse(`any(prs, .approved) && issue.lastComment != null`)
+	if err != nil {
+		t.Fatalf("parsing: %v", err)
+	}
+	if !rule.References("approved") || !rule.References("lastComment") || rule.References("checks") {
+		t.Errorf("expected only the fields in the rule to be referenced, got %v", rule.fields)
+	}
+}